@@ -0,0 +1,51 @@
+package rakuda
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// background tracks the goroutines started with Go.
+var background sync.WaitGroup
+
+// Go runs fn in a new goroutine for fire-and-forget work started by a handler,
+// such as sending an email. The context passed to fn keeps the values of ctx
+// (the logger, principal, tenant, trace headers, and so on) but is not canceled
+// when the request ends. Panics in fn are recovered and logged.
+//
+// Call Wait during server shutdown, so that the work is not cut off:
+//
+//	srv.Shutdown(ctx)
+//	rakuda.Wait(ctx)
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	ctx = context.WithoutCancel(ctx)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				logger := LoggerFromContext(ctx)
+				logger.ErrorContext(ctx, "panic recovered in background job", "error", fmt.Sprint(r), "stack", string(debug.Stack()))
+			}
+		}()
+		fn(ctx)
+	}()
+}
+
+// Wait blocks until all goroutines started with Go have returned, or ctx is done.
+// It returns ctx.Err() if ctx is done first.
+func Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
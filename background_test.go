@@ -0,0 +1,52 @@
+package rakuda
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGo(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	reqCtx, cancel := context.WithCancel(NewContextWithLogger(context.Background(), logger))
+
+	var gotLogger atomic.Bool
+	var canceled atomic.Bool
+	release := make(chan struct{})
+	Go(reqCtx, func(ctx context.Context) {
+		<-release
+		gotLogger.Store(LoggerFromContext(ctx) == logger)
+		canceled.Store(ctx.Err() != nil)
+	})
+	Go(reqCtx, func(ctx context.Context) {
+		panic("boom") // must not crash the process
+	})
+
+	cancel() // the request ends before the job runs
+	close(release)
+
+	if err := Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() failed: %v", err)
+	}
+	if !gotLogger.Load() {
+		t.Error("expected the job to keep the request logger")
+	}
+	if canceled.Load() {
+		t.Error("expected the job context not to be canceled with the request")
+	}
+}
+
+func TestWait_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	Go(context.Background(), func(ctx context.Context) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got %v", err)
+	}
+}
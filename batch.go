@@ -0,0 +1,231 @@
+package rakuda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// BatchConfig holds the configuration for a batch endpoint.
+type BatchConfig struct {
+	// MaxItems is the maximum number of sub-requests in a single batch. Default is 20.
+	MaxItems int
+	// MaxConcurrency is the maximum number of sub-requests executed concurrently. Default is 4.
+	MaxConcurrency int
+	// MaxBodySize is the maximum size of the batch request body in bytes. Default is 1 MB.
+	MaxBodySize int64
+	// InheritHeaders lists the headers of the batch request copied to each sub-request.
+	// Default is DefaultBatchInheritHeaders.
+	InheritHeaders []string
+}
+
+// DefaultBatchInheritHeaders are the headers that sub-requests inherit from the batch
+// request by default: credentials, tracing, and the language preference. Headers that
+// describe a single request, such as Idempotency-Key, Content-Type, or If-Match, are not
+// inherited; set them per item.
+var DefaultBatchInheritHeaders = []string{"Authorization", "Cookie", "X-Request-ID", "Traceparent", "Tracestate", "Accept-Language"}
+
+// BatchRequest is a single sub-request in a batch.
+type BatchRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"` // including the query string
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// BatchResponse is the result of a single sub-request in a batch.
+// If the sub-response is JSON, Body holds it as is; otherwise, it holds a JSON string.
+type BatchResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Batch registers a POST handler implementing a batch protocol, so that clients can
+// reduce round trips. The request body is a JSON array of BatchRequest, and each
+// sub-request is executed in-process against the built router. The response is a
+// JSON array of BatchResponse in the same order.
+//
+// Sub-requests inherit only the allowlisted headers of the batch request
+// (see BatchConfig.InheritHeaders), overridden by their own headers.
+// Nested batch requests are rejected.
+func (b *Builder) Batch(pattern string, config *BatchConfig) {
+	if config == nil {
+		config = &BatchConfig{}
+	}
+	h := &batchHandler{
+		responder:      NewResponder(),
+		maxItems:       config.MaxItems,
+		maxConcurrency: config.MaxConcurrency,
+		maxBodySize:    config.MaxBodySize,
+		inheritHeaders: config.InheritHeaders,
+	}
+	if h.maxItems <= 0 {
+		h.maxItems = 20
+	}
+	if h.maxConcurrency <= 0 {
+		h.maxConcurrency = 4
+	}
+	if h.maxBodySize <= 0 {
+		h.maxBodySize = 1 << 20
+	}
+	if h.inheritHeaders == nil {
+		h.inheritHeaders = DefaultBatchInheritHeaders
+	}
+	b.addHandler(http.MethodPost, pattern, h, callerSource(2))
+}
+
+// batchHandler is the http.Handler registered by Builder.Batch.
+type batchHandler struct {
+	responder      *Responder
+	maxItems       int
+	maxConcurrency int
+	maxBodySize    int64
+	inheritHeaders []string
+
+	router *routerRef // set by bindRouter
+}
+
+// bindRouter implements the routerAware interface.
+func (h *batchHandler) bindRouter(router *routerRef) http.Handler {
+	bound := *h
+	bound.router = router
+	return &bound
+}
+
+func (h *batchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if ctx.Value(batchKey) != nil {
+		h.responder.Error(w, r, http.StatusBadRequest, errors.New("nested batch requests are not allowed"))
+		return
+	}
+
+	if h.router == nil || h.router.Handler == nil {
+		h.responder.Error(w, r, http.StatusInternalServerError, errors.New("batch handler is not bound to a router"))
+		return
+	}
+
+	var items []BatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.maxBodySize)).Decode(&items); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			h.responder.Error(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("batch request body exceeds %d bytes", maxErr.Limit))
+			return
+		}
+		h.responder.Error(w, r, http.StatusBadRequest, fmt.Errorf("invalid batch request: %w", err))
+		return
+	}
+	if len(items) > h.maxItems {
+		h.responder.Error(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("too many batch items: %d > %d", len(items), h.maxItems))
+		return
+	}
+	for i, item := range items {
+		if item.Method == "" || !strings.HasPrefix(item.Path, "/") {
+			h.responder.Error(w, r, http.StatusBadRequest, fmt.Errorf("invalid batch item %d: method and an absolute path are required", i))
+			return
+		}
+	}
+
+	ctx = context.WithValue(ctx, batchKey, true)
+	results := make([]BatchResponse, len(items))
+	sem := make(chan struct{}, h.maxConcurrency)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			// net/http recovers panics only on the goroutine of the batch request,
+			// so a panicking sub-request fails its own item instead of the process.
+			defer func() {
+				if rec := recover(); rec != nil {
+					LoggerFromContext(ctx).ErrorContext(ctx, "panic recovered in batch item", "method", item.Method, "path", item.Path, "error", fmt.Sprint(rec), "stack", string(debug.Stack()))
+					results[i] = BatchResponse{Status: http.StatusInternalServerError, Body: json.RawMessage(`{"error":"Internal Server Error"}`)}
+				}
+			}()
+			results[i] = dispatch(ctx, r, h.router, item, h.inheritHeaders)
+		}()
+	}
+	wg.Wait()
+
+	h.responder.JSON(w, r, http.StatusOK, results)
+}
+
+// dispatch executes a single sub-request against the router.
+// The sub-request has the headers of item and the inherit headers of parent.
+func dispatch(ctx context.Context, parent *http.Request, router http.Handler, item BatchRequest, inherit []string) BatchResponse {
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(item.Method), item.Path, bytes.NewReader(item.Body))
+	if err != nil {
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		return BatchResponse{Status: http.StatusBadRequest, Body: body}
+	}
+	for _, name := range inherit {
+		for _, v := range parent.Header.Values(name) {
+			req.Header.Add(name, v)
+		}
+	}
+	if len(item.Body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range item.Headers {
+		req.Header.Set(k, v)
+	}
+	req.RemoteAddr = parent.RemoteAddr
+
+	rec := &bufferedResponseWriter{header: http.Header{}}
+	router.ServeHTTP(rec, req)
+	return rec.batchResponse()
+}
+
+// bufferedResponseWriter is an in-memory http.ResponseWriter.
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// batchResponse converts the recorded response into a BatchResponse.
+func (w *bufferedResponseWriter) batchResponse() BatchResponse {
+	res := BatchResponse{Status: w.status}
+	if res.Status == 0 {
+		res.Status = http.StatusOK
+	}
+	if len(w.header) > 0 {
+		res.Headers = make(map[string]string, len(w.header))
+		for k := range w.header {
+			res.Headers[k] = w.header.Get(k)
+		}
+	}
+	if body := bytes.TrimSpace(w.body.Bytes()); len(body) > 0 {
+		if json.Valid(body) {
+			res.Body = json.RawMessage(body)
+		} else {
+			res.Body, _ = json.Marshal(string(body))
+		}
+	}
+	return res
+}
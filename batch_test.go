@@ -0,0 +1,189 @@
+package rakuda
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBatch(t *testing.T) {
+	responder := NewResponder()
+
+	b := NewBuilder()
+	b.Get("/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		responder.JSON(w, r, http.StatusOK, map[string]string{"id": r.PathValue("id"), "auth": r.Header.Get("Authorization")})
+	}))
+	b.Post("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input map[string]string
+		json.NewDecoder(r.Body).Decode(&input)
+		responder.JSON(w, r, http.StatusCreated, input)
+	}))
+	b.Get("/text", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("plain"))
+	}))
+	b.Get("/panic", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	var orders atomic.Int32
+	b.Post("/orders", Lift(responder, Idempotent(NewMemoryIdempotencyStore(time.Hour), func(r *http.Request) (map[string]int32, error) {
+		return map[string]int32{"id": orders.Add(1)}, nil
+	})))
+	b.Batch("/batch", &BatchConfig{MaxItems: 5, MaxBodySize: 1 << 10})
+
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	do := func(t *testing.T, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token")
+		req.Header.Set(IdempotencyKeyHeader, "batch-key")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		rr := do(t, `[
+			{"method": "GET", "path": "/users/1"},
+			{"method": "POST", "path": "/users", "body": {"name": "foo"}},
+			{"method": "GET", "path": "/text"},
+			{"method": "GET", "path": "/missing"}
+		]`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status code: got %d, want %d, body: %s", rr.Code, http.StatusOK, rr.Body.String())
+		}
+
+		var got []BatchResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		type summary struct {
+			Status int
+			Body   string
+		}
+		var summaries []summary
+		for _, res := range got {
+			summaries = append(summaries, summary{Status: res.Status, Body: string(res.Body)})
+		}
+		want := []summary{
+			{http.StatusOK, `{"auth":"Bearer token","id":"1"}`},
+			{http.StatusCreated, `{"name":"foo"}`},
+			{http.StatusOK, `"plain"`},
+			{http.StatusNotFound, `{"error":"not found"}`},
+		}
+		if diff := cmp.Diff(want, summaries); diff != "" {
+			t.Errorf("batch responses mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("too many items", func(t *testing.T) {
+		items := strings.Repeat(`{"method": "GET", "path": "/text"},`, 6)
+		rr := do(t, "["+strings.TrimSuffix(items, ",")+"]")
+		if rr.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("status code: got %d, want %d", rr.Code, http.StatusRequestEntityTooLarge)
+		}
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		rr := do(t, `[{"method": "POST", "path": "/users", "body": {"name": "`+strings.Repeat("x", 1<<10)+`"}}]`)
+		if rr.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("status code: got %d, want %d", rr.Code, http.StatusRequestEntityTooLarge)
+		}
+	})
+
+	t.Run("batch headers are not inherited by items", func(t *testing.T) {
+		rr := do(t, `[{"method": "POST", "path": "/orders"}, {"method": "POST", "path": "/orders"}]`)
+		var got []BatchResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		var bodies []string
+		for _, res := range got {
+			bodies = append(bodies, string(res.Body))
+		}
+		// Both items run; neither is rejected or replayed with the key of the batch request.
+		if len(got) != 2 || got[0].Status != http.StatusOK || got[1].Status != http.StatusOK || bodies[0] == bodies[1] {
+			t.Errorf("expected two distinct orders, got %+v", got)
+		}
+	})
+
+	t.Run("invalid item", func(t *testing.T) {
+		rr := do(t, `[{"method": "GET", "path": "users"}]`)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("status code: got %d, want %d", rr.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		rr := do(t, `[{"method": "GET", "path": "/panic"}, {"method": "GET", "path": "/text"}]`)
+		var got []BatchResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(got) != 2 || got[0].Status != http.StatusInternalServerError || got[1].Status != http.StatusOK {
+			t.Errorf("expected only the panicking item to fail, got %+v", got)
+		}
+	})
+
+	t.Run("nested batch", func(t *testing.T) {
+		rr := do(t, `[{"method": "POST", "path": "/batch", "body": []}]`)
+		var got []BatchResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(got) != 1 || got[0].Status != http.StatusBadRequest {
+			t.Errorf("expected the nested batch to be rejected, got %+v", got)
+		}
+	})
+}
+
+func TestBatch_PerBuild(t *testing.T) {
+	b := NewBuilder()
+	b.Batch("/batch", nil)
+	original, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	// Building a clone must not rebind the batch handler of the original router.
+	clone := b.Clone()
+	clone.Get("/only-in-clone", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("CLONE"))
+	}))
+	cloned, err := clone.Build()
+	if err != nil {
+		t.Fatalf("clone.Build() failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		router     http.Handler
+		wantStatus int
+	}{
+		{name: "original", router: original, wantStatus: http.StatusNotFound},
+		{name: "clone", router: cloned, wantStatus: http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`[{"method": "GET", "path": "/only-in-clone"}]`))
+			rr := httptest.NewRecorder()
+			tt.router.ServeHTTP(rr, req)
+
+			var got []BatchResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to decode response: %v, body: %s", err, rr.Body.String())
+			}
+			if len(got) != 1 || got[0].Status != tt.wantStatus {
+				t.Errorf("got %+v, want a single item with status %d", got, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -8,15 +8,33 @@ import (
 	"fmt"
 	"net/http"
 	"net/textproto"
+	"slices"
 	"strings"
 )
 
+// Severity is the severity level of a binding Error.
+type Severity string
+
+const (
+	// SeverityError marks a failure that rejects the request. The zero value is treated as an error.
+	SeverityError Severity = "error"
+	// SeverityWarning marks a problem that does not fail the request (e.g., a deprecated
+	// parameter was used). Warnings are recorded with Binding.Warn.
+	SeverityWarning Severity = "warning"
+)
+
 // Error represents a single validation error, providing structured details.
 type Error struct {
-	Source Source `json:"source"` // e.g., "query", "header"
-	Key    string `json:"key"`    // The parameter name (e.g., "id", "sort")
-	Value  any    `json:"value"`  // The invalid value that was provided
-	Err    error  `json:"-"`      // The underlying error (not exposed in JSON)
+	Source   Source   `json:"source"`             // e.g., "query", "header"
+	Key      string   `json:"key"`                // The parameter name (e.g., "id", "sort")
+	Value    any      `json:"value"`              // The invalid value that was provided
+	Severity Severity `json:"severity,omitempty"` // Empty means SeverityError
+	Err      error    `json:"-"`                  // The underlying error (not exposed in JSON)
+}
+
+// IsWarning reports whether the error is a warning that does not fail the request.
+func (e *Error) IsWarning() bool {
+	return e.Severity == SeverityWarning
 }
 
 func (e *Error) Error() string {
@@ -62,7 +80,8 @@ func (e *ValidationErrors) StatusCode() int {
 }
 
 // Join collects binding errors into a single ValidationErrors instance.
-// It filters out nil errors. If no errors are found, it returns nil.
+// It filters out nil errors and warnings, because warnings do not fail the request.
+// If no errors are found, it returns nil.
 func Join(errs ...error) error {
 	var validationErrs []*Error
 	for _, err := range errs {
@@ -72,9 +91,15 @@ func Join(errs ...error) error {
 		var vErrs *ValidationErrors
 		var bErr *Error
 		if errors.As(err, &vErrs) {
-			validationErrs = append(validationErrs, vErrs.Errors...)
+			for _, e := range vErrs.Errors {
+				if !e.IsWarning() {
+					validationErrs = append(validationErrs, e)
+				}
+			}
 		} else if errors.As(err, &bErr) {
-			validationErrs = append(validationErrs, bErr)
+			if !bErr.IsWarning() {
+				validationErrs = append(validationErrs, bErr)
+			}
 		} else {
 			// It's some other error type, wrap it for consistency
 			validationErrs = append(validationErrs, &Error{Err: err})
@@ -96,6 +121,7 @@ const (
 	Cookie Source = "cookie"
 	Path   Source = "path"
 	Form   Source = "form"
+	Body   Source = "body"
 )
 
 // Requirement specifies whether a value is required or optional.
@@ -118,6 +144,8 @@ type Parser[T any] func(string) (T, error)
 type Binding struct {
 	req       *http.Request
 	pathValue func(string) string
+	warnings  []*Error
+	queried   map[string]bool // query keys looked up so far, for UnknownQuery
 }
 
 // New creates a new Binding instance from an *http.Request and a function to retrieve path parameters.
@@ -126,10 +154,100 @@ func New(req *http.Request, pathValue func(string) string) *Binding {
 	return &Binding{req: req, pathValue: pathValue}
 }
 
+// Warn records a warning that does not fail the request, such as the use of a deprecated parameter.
+// Recorded warnings are available via Warnings, so that they can be surfaced in
+// response headers and logs (see rakuda.Responder.Warn).
+func (b *Binding) Warn(source Source, key string, value any, err error) {
+	b.warnings = append(b.warnings, &Error{
+		Source:   source,
+		Key:      key,
+		Value:    value,
+		Severity: SeverityWarning,
+		Err:      err,
+	})
+}
+
+// ErrDeprecated is the underlying error of warnings recorded by Deprecated.
+var ErrDeprecated = errors.New("deprecated parameter")
+
+// Deprecated records a warning if the legacy parameter is present in the request,
+// so that API owners can measure when it is safe to remove it.
+// The note should tell clients what to use instead. It does not bind any value.
+func Deprecated(b *Binding, source Source, key string, note string) {
+	val, ok := b.Lookup(source, key)
+	if !ok {
+		return
+	}
+	b.Warn(source, key, val, fmt.Errorf("%w: %s", ErrDeprecated, note))
+}
+
+// Warnings returns the warnings recorded during binding.
+func (b *Binding) Warnings() []*Error {
+	return b.warnings
+}
+
+// ErrUnknownParameter is the underlying error of the errors and warnings
+// reported by UnknownQuery and StripUnknownQuery.
+var ErrUnknownParameter = errors.New("unknown parameter")
+
+// UnknownQuery rejects the query parameters that were not looked up by b, catching
+// client typos like "?limti=10". Call it after binding all parameters (Deprecated counts
+// as a lookup, so legacy parameters are accepted). It returns a *ValidationErrors with
+// one error per unexpected parameter, sorted by name, or nil if there are none.
+func UnknownQuery(b *Binding) error {
+	var errs []error
+	for _, key := range b.unknownQuery() {
+		errs = append(errs, &Error{
+			Source: Query,
+			Key:    key,
+			Value:  b.req.URL.Query().Get(key),
+			Err:    ErrUnknownParameter,
+		})
+	}
+	return Join(errs...)
+}
+
+// StripUnknownQuery is the lenient counterpart of UnknownQuery. It removes the query
+// parameters that were not looked up by b from the request URL, so that they do not reach
+// the handler or upstream services, and records a warning for each of them.
+// It returns the names of the removed parameters, sorted.
+func StripUnknownQuery(b *Binding) []string {
+	keys := b.unknownQuery()
+	if len(keys) == 0 {
+		return nil
+	}
+	query := b.req.URL.Query()
+	for _, key := range keys {
+		b.Warn(Query, key, query.Get(key), ErrUnknownParameter)
+		query.Del(key)
+	}
+	b.req.URL.RawQuery = query.Encode()
+	return keys
+}
+
+func (b *Binding) unknownQuery() []string {
+	var keys []string
+	for key := range b.req.URL.Query() {
+		if !b.queried[key] {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func (b *Binding) markQueried(key string) {
+	if b.queried == nil {
+		b.queried = map[string]bool{}
+	}
+	b.queried[key] = true
+}
+
 // Lookup is an internal method that retrieves a value and its existence from a given source.
 func (b *Binding) Lookup(source Source, key string) (string, bool) {
 	switch source {
 	case Query:
+		b.markQueried(key)
 		if b.req.URL.Query().Has(key) {
 			return b.req.URL.Query().Get(key), true
 		}
@@ -176,6 +294,7 @@ func (b *Binding) Lookup(source Source, key string) (string, bool) {
 func (b *Binding) valuesFromSource(source Source, key string) ([]string, bool) {
 	switch source {
 	case Query:
+		b.markQueried(key)
 		if values, ok := b.req.URL.Query()[key]; ok && len(values) > 0 {
 			return values, true
 		}
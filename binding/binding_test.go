@@ -1,6 +1,8 @@
 package binding
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -370,3 +372,122 @@ func TestSlicePtr(t *testing.T) {
 		}
 	})
 }
+
+func TestWarnings(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?limit=10", nil)
+	b := New(req, nil)
+
+	b.Warn(Query, "limit", "10", errors.New("use page_size instead"))
+
+	warnings := b.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %d", len(warnings))
+	}
+	if !warnings[0].IsWarning() {
+		t.Error("expected IsWarning() to be true")
+	}
+
+	// Warnings do not fail the request.
+	if err := Join(warnings[0], nil); err != nil {
+		t.Errorf("expected Join to ignore warnings, got %v", err)
+	}
+	err := Join(warnings[0], &Error{Source: Query, Key: "page", Err: errors.New("invalid")})
+	var vErrs *ValidationErrors
+	if !errors.As(err, &vErrs) {
+		t.Fatalf("expected *ValidationErrors, got %T", err)
+	}
+	if got, want := len(vErrs.Errors), 1; got != want {
+		t.Errorf("len(Errors): got %d, want %d", got, want)
+	}
+
+	got, err := json.Marshal(warnings[0])
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	want := `{"message":"use page_size instead","source":"query","key":"limit","value":"10","severity":"warning"}`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("JSON mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	t.Run("present", func(t *testing.T) {
+		b := New(httptest.NewRequest("GET", "/?limit=10", nil), nil)
+		Deprecated(b, Query, "limit", "use page_size instead")
+
+		warnings := b.Warnings()
+		if len(warnings) != 1 {
+			t.Fatalf("expected 1 warning, got %d", len(warnings))
+		}
+		if !errors.Is(warnings[0], ErrDeprecated) {
+			t.Errorf("expected the warning to wrap ErrDeprecated, got %v", warnings[0].Err)
+		}
+		if got, want := warnings[0].Error(), "source=query, key=limit, value=10, err=deprecated parameter: use page_size instead"; got != want {
+			t.Errorf("Error(): got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		b := New(httptest.NewRequest("GET", "/?page_size=10", nil), nil)
+		Deprecated(b, Query, "limit", "use page_size instead")
+
+		if got := len(b.Warnings()); got != 0 {
+			t.Errorf("expected no warnings, got %d", got)
+		}
+	})
+}
+
+func TestUnknownQuery(t *testing.T) {
+	bind := func(target string) (*Binding, *http.Request) {
+		req := httptest.NewRequest("GET", target, nil)
+		b := New(req, nil)
+		var limit int
+		var tags []string
+		_ = One(b, &limit, Query, "limit", parseInt, Optional)
+		_ = Slice(b, &tags, Query, "tag", parseString, Optional)
+		Deprecated(b, Query, "page_size", "use limit instead")
+		return b, req
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		b, _ := bind("/?limti=10&tag=a&sort=asc&page_size=5")
+		err := UnknownQuery(b)
+
+		var vErrs *ValidationErrors
+		if !errors.As(err, &vErrs) {
+			t.Fatalf("expected *ValidationErrors, got %T", err)
+		}
+		var got []string
+		for _, e := range vErrs.Errors {
+			if !errors.Is(e, ErrUnknownParameter) {
+				t.Errorf("expected the error to wrap ErrUnknownParameter, got %v", e.Err)
+			}
+			got = append(got, e.Key+"="+e.Value.(string))
+		}
+		if diff := cmp.Diff([]string{"limti=10", "sort=asc"}, got); diff != "" {
+			t.Errorf("unknown parameters mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("known only", func(t *testing.T) {
+		b, _ := bind("/?limit=10&tag=a")
+		if err := UnknownQuery(b); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("strip", func(t *testing.T) {
+		b, req := bind("/?limti=10&limit=20")
+		removed := StripUnknownQuery(b)
+
+		if diff := cmp.Diff([]string{"limti"}, removed); diff != "" {
+			t.Errorf("removed mismatch (-want +got):\n%s", diff)
+		}
+		if got, want := req.URL.RawQuery, "limit=20"; got != want {
+			t.Errorf("RawQuery: got %q, want %q", got, want)
+		}
+		if warnings := b.Warnings(); len(warnings) != 1 || !errors.Is(warnings[0], ErrUnknownParameter) {
+			t.Errorf("expected an unknown parameter warning, got %v", warnings)
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package binding
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Decoder decodes a request body into dest.
+type Decoder func(r io.Reader, dest any) error
+
+// Decoders is a registry mapping media types (e.g., "application/json") to decoders.
+// It is safe for concurrent use.
+type Decoders struct {
+	mu       sync.RWMutex
+	decoders map[string]Decoder
+}
+
+// NewDecoders creates an empty decoder registry.
+func NewDecoders() *Decoders {
+	return &Decoders{decoders: map[string]Decoder{}}
+}
+
+// Register registers a decoder for the given media type, replacing any existing one.
+// Media types are matched case-insensitively and without parameters.
+func (d *Decoders) Register(mediaType string, decoder Decoder) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.decoders[strings.ToLower(mediaType)] = decoder
+}
+
+// Lookup returns the decoder for the given Content-Type value.
+// Parameters such as charset are ignored. For structured syntax suffixes
+// (e.g., "application/vnd.example+json"), it falls back to "application/json".
+func (d *Decoders) Lookup(contentType string) (Decoder, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if decoder, ok := d.decoders[mediaType]; ok {
+		return decoder, true
+	}
+	if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+		decoder, ok := d.decoders["application/"+mediaType[i+1:]]
+		return decoder, ok
+	}
+	return nil, false
+}
+
+// DecodeJSON is the default decoder for "application/json".
+func DecodeJSON(r io.Reader, dest any) error {
+	return json.NewDecoder(r).Decode(dest)
+}
+
+// DefaultDecoders is the registry used by BodyAuto.
+// By default, only "application/json" is registered.
+var DefaultDecoders = func() *Decoders {
+	d := NewDecoders()
+	d.Register("application/json", DecodeJSON)
+	return d
+}()
+
+// RegisterDecoder registers a decoder for the given media type in DefaultDecoders,
+// so that applications can plug in protobuf, CBOR, or vendor media types.
+func RegisterDecoder(mediaType string, decoder Decoder) {
+	DefaultDecoders.Register(mediaType, decoder)
+}
+
+// BodyAuto decodes the request body into dest, choosing the decoder registered
+// in DefaultDecoders for the request's Content-Type.
+// A missing Content-Type is treated as "application/json".
+func BodyAuto[T any](b *Binding, dest *T, req Requirement) error {
+	contentType := b.req.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	decoder, ok := DefaultDecoders.Lookup(contentType)
+	if !ok {
+		return &Error{
+			Source: Header,
+			Key:    "Content-Type",
+			Value:  contentType,
+			Err:    errors.New("unsupported media type"),
+		}
+	}
+
+	if b.req.Body == nil {
+		return missingBody(req)
+	}
+	br := bufio.NewReader(b.req.Body)
+	if _, err := br.Peek(1); err == io.EOF {
+		return missingBody(req)
+	}
+
+	if err := decoder(br, dest); err != nil {
+		var bErr *Error
+		if errors.As(err, &bErr) {
+			return bErr // already addressed, e.g., by a decoder created with NewJSONDecoder
+		}
+		return &Error{
+			Source: Body,
+			Err:    err,
+		}
+	}
+	return nil
+}
+
+// missingBody returns an error for an empty body if it is required.
+func missingBody(req Requirement) error {
+	if req == Required {
+		return &Error{
+			Source: Body,
+			Err:    errors.New("required body is missing"),
+		}
+	}
+	return nil
+}
+
+// BodyTooLargeError reports that a request body exceeds the maximum size of BodyStream.
+type BodyTooLargeError struct {
+	// Limit is the maximum size in bytes.
+	Limit int64
+}
+
+// Error implements the error interface.
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("request body too large: the maximum size is %d bytes", e.Limit)
+}
+
+// StatusCode returns 413 Request Entity Too Large, allowing it to work with the lift handler.
+func (e *BodyTooLargeError) StatusCode() int {
+	return http.StatusRequestEntityTooLarge
+}
+
+// BodyStream hands the request body to fn as a streaming reader of at most maxBytes bytes,
+// so that large uploads are processed as they arrive instead of being buffered in memory
+// or temporary files, as form parsing does. Call it after the other parameters are bound
+// and validated, and do not bind Form values of the same request, which would consume the body:
+//
+//	if err := binding.Join(
+//		binding.One(b, &name, binding.Path, "name", parseName, binding.Required),
+//	); err != nil {
+//		return nil, err
+//	}
+//	err := binding.BodyStream(b, func(r io.Reader) error {
+//		_, err := io.Copy(dst, r)
+//		return err
+//	}, 1<<30)
+//
+// If the Content-Length of the request exceeds maxBytes, fn is not called. If the body
+// turns out to be larger while it is read, reads fail after maxBytes bytes. In both cases,
+// it returns a *BodyTooLargeError; otherwise, it returns the error of fn as is.
+func BodyStream(b *Binding, fn func(io.Reader) error, maxBytes int64) error {
+	if b.req.ContentLength > maxBytes {
+		return &BodyTooLargeError{Limit: maxBytes}
+	}
+	body := b.req.Body
+	if body == nil {
+		body = http.NoBody
+	}
+	lr := &limitedReader{r: body, n: maxBytes}
+	err := fn(lr)
+	if lr.exceeded {
+		return &BodyTooLargeError{Limit: maxBytes} // even if fn ignored the read error
+	}
+	return err
+}
+
+// limitedReader reads at most n bytes from r, and fails if there are more.
+type limitedReader struct {
+	r        io.Reader
+	n        int64 // the remaining bytes
+	exceeded bool
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if lr.exceeded {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > lr.n+1 {
+		p = p[:lr.n+1] // one more byte than allowed, to detect a larger body
+	}
+	n, err := lr.r.Read(p)
+	if int64(n) > lr.n {
+		n, lr.exceeded = int(lr.n), true
+		lr.n = 0
+		return n, errBodyTooLarge
+	}
+	lr.n -= int64(n)
+	return n, err
+}
+
+var errBodyTooLarge = errors.New("request body too large")
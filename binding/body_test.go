@@ -0,0 +1,133 @@
+package binding
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBodyAuto(t *testing.T) {
+	type Item struct {
+		Name string `json:"name" xml:"name"`
+	}
+
+	RegisterDecoder("application/xml", func(r io.Reader, dest any) error {
+		return xml.NewDecoder(r).Decode(dest)
+	})
+	t.Cleanup(func() {
+		DefaultDecoders.mu.Lock()
+		delete(DefaultDecoders.decoders, "application/xml")
+		DefaultDecoders.mu.Unlock()
+	})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		req         Requirement
+		want        Item
+		wantErr     string
+	}{
+		{name: "json", contentType: "application/json; charset=utf-8", body: `{"name":"foo"}`, req: Required, want: Item{Name: "foo"}},
+		{name: "no content type", body: `{"name":"foo"}`, req: Required, want: Item{Name: "foo"}},
+		{name: "vendor json", contentType: "application/vnd.example+json", body: `{"name":"foo"}`, req: Required, want: Item{Name: "foo"}},
+		{name: "registered xml", contentType: "application/xml", body: `<Item><name>foo</name></Item>`, req: Required, want: Item{Name: "foo"}},
+		{name: "unsupported", contentType: "application/cbor", body: `...`, req: Required, wantErr: "source=header, key=Content-Type, value=application/cbor, err=unsupported media type"},
+		{name: "invalid json", contentType: "application/json", body: `{`, req: Required, wantErr: "source=body, key=, value=<nil>, err=unexpected EOF"},
+		{name: "missing required", contentType: "application/json", body: ``, req: Required, wantErr: "source=body, key=, value=<nil>, err=required body is missing"},
+		{name: "missing optional", contentType: "application/json", body: ``, req: Optional},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			b := New(req, nil)
+
+			var got Item
+			err := BodyAuto(b, &got, tt.req)
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error %q, got nil", tt.wantErr)
+				}
+				if err.Error() != tt.wantErr {
+					t.Errorf("error mismatch:\ngot:  %q\nwant: %q", err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("BodyAuto() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBodyStream(t *testing.T) {
+	errStore := errors.New("store failed")
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64 // -1 for unknown (chunked)
+		fnErr         error
+		want          string
+		wantErr       string
+		wantNotCalled bool
+	}{
+		{name: "ok", body: "hello", contentLength: 5, want: "hello"},
+		{name: "exactly the limit", body: "0123456789", contentLength: 10, want: "0123456789"},
+		{name: "declared length too large", body: "0123456789a", contentLength: 11, wantErr: "request body too large: the maximum size is 10 bytes", wantNotCalled: true},
+		{name: "chunked body too large", body: "0123456789a", contentLength: -1, wantErr: "request body too large: the maximum size is 10 bytes"},
+		{name: "empty", contentLength: 0, want: ""},
+		{name: "error of fn", body: "hello", contentLength: 5, fnErr: errStore, want: "hello", wantErr: "store failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/uploads", strings.NewReader(tt.body))
+			req.ContentLength = tt.contentLength
+			b := New(req, nil)
+
+			called := false
+			var got strings.Builder
+			err := BodyStream(b, func(r io.Reader) error {
+				called = true
+				if _, err := io.Copy(&got, r); err != nil {
+					return err
+				}
+				return tt.fnErr
+			}, 10)
+
+			if called == tt.wantNotCalled {
+				t.Errorf("fn called: got %v, want %v", called, !tt.wantNotCalled)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error: got %v, want %q", err, tt.wantErr)
+				}
+				if tt.fnErr == nil {
+					var tooLarge *BodyTooLargeError
+					if !errors.As(err, &tooLarge) || tooLarge.StatusCode() != http.StatusRequestEntityTooLarge {
+						t.Errorf("error %v is not a *BodyTooLargeError with 413", err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got.String()); diff != "" {
+				t.Errorf("body mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
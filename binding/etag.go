@@ -0,0 +1,51 @@
+package binding
+
+import (
+	"errors"
+	"strings"
+)
+
+// ParseETags parses the value of an If-Match or If-None-Match header into a list of
+// entity tags, keeping quotes and weak prefixes (e.g., `"v1"`, `W/"v2"`, or `*`).
+func ParseETags(s string) ([]string, error) {
+	s = strings.TrimSpace(s)
+	if s == "*" {
+		return []string{"*"}, nil
+	}
+
+	var tags []string
+	for s != "" {
+		tag := ""
+		rest := s
+		if strings.HasPrefix(rest, "W/") {
+			tag = "W/"
+			rest = rest[2:]
+		}
+		if !strings.HasPrefix(rest, `"`) {
+			return nil, errors.New("entity tag must be quoted")
+		}
+		end := strings.IndexByte(rest[1:], '"')
+		if end < 0 {
+			return nil, errors.New("unterminated entity tag")
+		}
+		tags = append(tags, tag+rest[:end+2])
+
+		s = strings.TrimSpace(rest[end+2:])
+		if s != "" {
+			if s[0] != ',' {
+				return nil, errors.New("entity tags must be separated by commas")
+			}
+			s = strings.TrimSpace(s[1:])
+		}
+	}
+	if len(tags) == 0 {
+		return nil, errors.New("no entity tag")
+	}
+	return tags, nil
+}
+
+// IfMatch binds the entity tags of the If-Match header, for optimistic concurrency control.
+// Compare them with the current version of the resource using rakuda.CheckIfMatch.
+func IfMatch(b *Binding, dest *[]string, req Requirement) error {
+	return One(b, dest, Header, "If-Match", ParseETags, req)
+}
@@ -0,0 +1,52 @@
+package binding
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseETags(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{input: `"v1"`, want: []string{`"v1"`}},
+		{input: `"v1", W/"v2" ,"v,3"`, want: []string{`"v1"`, `W/"v2"`, `"v,3"`}},
+		{input: `*`, want: []string{"*"}},
+		{input: `v1`, wantErr: true},
+		{input: `"v1`, wantErr: true},
+		{input: `"v1" "v2"`, wantErr: true},
+		{input: ``, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseETags(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseETags(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseETags(%q) mismatch (-want +got):\n%s", tt.input, diff)
+			}
+		})
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	req := httptest.NewRequest("PUT", "/", nil)
+	req.Header.Set("If-Match", `"v1"`)
+
+	var got []string
+	if err := IfMatch(New(req, nil), &got, Required); err != nil {
+		t.Fatalf("IfMatch() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{`"v1"`}, got); diff != "" {
+		t.Errorf("IfMatch() mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := IfMatch(New(httptest.NewRequest("PUT", "/", nil), nil), &got, Required); err == nil {
+		t.Error("expected an error for a missing required If-Match header")
+	}
+}
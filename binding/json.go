@@ -0,0 +1,144 @@
+package binding
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Errors reported by the decoders created with NewJSONDecoder, wrapped in *Error.
+var (
+	ErrUnknownField = errors.New("unknown field")
+	ErrDuplicateKey = errors.New("duplicate key")
+	ErrMaxDepth     = errors.New("maximum nesting depth exceeded")
+)
+
+// JSONConfig holds the strictness options of a JSON decoder created with NewJSONDecoder.
+type JSONConfig struct {
+	// DisallowUnknownFields rejects object keys that do not match a field of the destination struct.
+	DisallowUnknownFields bool
+	// DisallowDuplicateKeys rejects objects with the same key more than once, which
+	// encoding/json accepts silently, keeping the last value.
+	DisallowDuplicateKeys bool
+	// MaxDepth limits the nesting depth of objects and arrays; the top-level value has depth 1.
+	// Zero means no limit.
+	MaxDepth int
+	// UseNumber decodes numbers into interface{} values as json.Number instead of float64,
+	// so that large integers keep their precision.
+	UseNumber bool
+}
+
+// NewJSONDecoder returns a JSON decoder with the given strictness options, to be registered
+// for "application/json" in place of DecodeJSON:
+//
+//	binding.RegisterDecoder("application/json", binding.NewJSONDecoder(&binding.JSONConfig{
+//		DisallowUnknownFields: true,
+//		DisallowDuplicateKeys: true,
+//		MaxDepth:              32,
+//	}))
+//
+// Violations and type mismatches are returned as *Error with the source Body and the key set
+// to the path of the offending field (e.g., "items.1.name"), so that BodyAuto reports them
+// like the errors of other sources. If config is nil, it behaves like DecodeJSON.
+func NewJSONDecoder(config *JSONConfig) Decoder {
+	if config == nil {
+		config = &JSONConfig{}
+	}
+	return func(r io.Reader, dest any) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if config.DisallowDuplicateKeys || config.MaxDepth > 0 {
+			dec := json.NewDecoder(bytes.NewReader(data))
+			dec.UseNumber() // numbers are not needed, so don't pay for parsing them
+			if err := checkJSONValue(dec, "", 1, config); err != nil {
+				return err
+			}
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(data))
+		if config.DisallowUnknownFields {
+			dec.DisallowUnknownFields()
+		}
+		if config.UseNumber {
+			dec.UseNumber()
+		}
+		if err := dec.Decode(dest); err != nil {
+			return jsonFieldError(err)
+		}
+		return nil
+	}
+}
+
+// checkJSONValue walks the next value of dec, checking the duplicate keys and nesting depth.
+func checkJSONValue(dec *json.Decoder, path string, depth int, config *JSONConfig) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+	if config.MaxDepth > 0 && depth > config.MaxDepth {
+		return &Error{Source: Body, Key: path, Err: fmt.Errorf("%w: %d", ErrMaxDepth, config.MaxDepth)}
+	}
+
+	switch delim {
+	case '{':
+		seen := map[string]bool{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key := tok.(string)
+			child := joinJSONPath(path, key)
+			if config.DisallowDuplicateKeys && seen[key] {
+				return &Error{Source: Body, Key: child, Err: ErrDuplicateKey}
+			}
+			seen[key] = true
+			if err := checkJSONValue(dec, child, depth+1, config); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for i := 0; dec.More(); i++ {
+			if err := checkJSONValue(dec, joinJSONPath(path, strconv.Itoa(i)), depth+1, config); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = dec.Token() // the closing delimiter
+	return err
+}
+
+// joinJSONPath joins the path of a field or an array element, in the format of
+// json.UnmarshalTypeError.Field (e.g., "items.1.name").
+func joinJSONPath(path, elem string) string {
+	if path == "" {
+		return elem
+	}
+	return path + "." + elem
+}
+
+// jsonFieldError converts the field-related errors of encoding/json into *Error.
+func jsonFieldError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &Error{Source: Body, Key: typeErr.Field, Value: typeErr.Value, Err: err}
+	}
+	// encoding/json reports unknown fields with an unexported error type.
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		if unquoted, uerr := strconv.Unquote(name); uerr == nil {
+			name = unquoted
+		}
+		return &Error{Source: Body, Key: name, Err: ErrUnknownField}
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package binding
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewJSONDecoder(t *testing.T) {
+	type Item struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+	type Order struct {
+		ID    string         `json:"id"`
+		Items []Item         `json:"items"`
+		Extra map[string]any `json:"extra"`
+	}
+
+	tests := []struct {
+		name    string
+		config  *JSONConfig
+		body    string
+		wantKey string
+		wantErr error
+	}{
+		{name: "default", body: `{"id":"o1","unknown":1,"id":"o2"}`},
+		{name: "unknown field", config: &JSONConfig{DisallowUnknownFields: true}, body: `{"id":"o1","limti":10}`, wantKey: "limti", wantErr: ErrUnknownField},
+		{name: "duplicate key", config: &JSONConfig{DisallowDuplicateKeys: true}, body: `{"id":"o1","items":[{"name":"a"},{"name":"b","name":"c"}]}`, wantKey: "items.1.name", wantErr: ErrDuplicateKey},
+		{name: "same key in different objects", config: &JSONConfig{DisallowDuplicateKeys: true}, body: `{"id":"o1","items":[{"name":"a"},{"name":"b"}]}`},
+		{name: "max depth", config: &JSONConfig{MaxDepth: 2}, body: `{"id":"o1","extra":{"a":{"b":1}}}`, wantKey: "extra.a", wantErr: ErrMaxDepth},
+		{name: "within max depth", config: &JSONConfig{MaxDepth: 3}, body: `{"id":"o1","extra":{"a":{"b":1}}}`},
+		{name: "type mismatch", config: &JSONConfig{}, body: `{"items":[{"count":"many"}]}`, wantKey: "items.0.count"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Order
+			err := NewJSONDecoder(tt.config)(strings.NewReader(tt.body), &got)
+			if tt.wantKey == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var bErr *Error
+			if !errors.As(err, &bErr) {
+				t.Fatalf("expected *Error, got %T: %v", err, err)
+			}
+			if bErr.Source != Body {
+				t.Errorf("Source: got %q, want %q", bErr.Source, Body)
+			}
+			if bErr.Key != tt.wantKey {
+				t.Errorf("Key: got %q, want %q", bErr.Key, tt.wantKey)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected the error to wrap %v, got %v", tt.wantErr, bErr.Err)
+			}
+		})
+	}
+}
+
+func TestNewJSONDecoderUseNumber(t *testing.T) {
+	var got map[string]any
+	if err := NewJSONDecoder(&JSONConfig{UseNumber: true})(strings.NewReader(`{"id":9007199254740993}`), &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(map[string]any{"id": json.Number("9007199254740993")}, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
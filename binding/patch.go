@@ -0,0 +1,427 @@
+package binding
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// Media types of PATCH request bodies.
+const (
+	JSONPatchMediaType  = "application/json-patch+json"  // RFC 6902
+	MergePatchMediaType = "application/merge-patch+json" // RFC 7396
+)
+
+// PatchOperation is a single operation of a JSON Patch document (RFC 6902).
+type PatchOperation struct {
+	Op    string          `json:"op"` // "add", "remove", "replace", "move", "copy", or "test"
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// PatchBody applies the request body to target, according to its Content-Type:
+// a JSON Patch (application/json-patch+json) or a JSON Merge Patch (application/merge-patch+json).
+// target is round-tripped through JSON, so its JSON field names are used as paths.
+// Failures are reported as *Error, like other binding functions; target is left
+// unchanged unless the whole patch succeeds.
+func PatchBody[T any](b *Binding, target *T) error {
+	contentType := b.req.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != JSONPatchMediaType && mediaType != MergePatchMediaType {
+		return &Error{
+			Source: Header,
+			Key:    "Content-Type",
+			Value:  contentType,
+			Err:    fmt.Errorf("unsupported media type, expected %s or %s", JSONPatchMediaType, MergePatchMediaType),
+		}
+	}
+	if b.req.Body == nil {
+		return missingBody(Required)
+	}
+	body, err := io.ReadAll(b.req.Body)
+	if err != nil {
+		return &Error{Source: Body, Err: err}
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return missingBody(Required)
+	}
+
+	doc, err := json.Marshal(target)
+	if err != nil {
+		return &Error{Source: Body, Err: fmt.Errorf("marshal patch target: %w", err)}
+	}
+
+	var patched []byte
+	if mediaType == JSONPatchMediaType {
+		var ops []PatchOperation
+		if err := json.Unmarshal(body, &ops); err != nil {
+			return &Error{Source: Body, Err: err}
+		}
+		patched, err = ApplyJSONPatch(doc, ops)
+	} else {
+		patched, err = ApplyMergePatch(doc, body)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Decode into a fresh value, so that removed fields do not keep their old values.
+	var result T
+	if err := json.Unmarshal(patched, &result); err != nil {
+		return &Error{Source: Body, Err: err}
+	}
+	*target = result
+	return nil
+}
+
+// ApplyJSONPatch applies the operations to a JSON document (RFC 6902).
+// Invalid operations are all reported at once as *ValidationErrors;
+// otherwise, the first failing operation is reported as *Error with the operation's path as Key.
+func ApplyJSONPatch(doc []byte, ops []PatchOperation) ([]byte, error) {
+	if err := validatePatch(ops); err != nil {
+		return nil, err
+	}
+	root, err := decodeJSON(doc)
+	if err != nil {
+		return nil, &Error{Source: Body, Err: fmt.Errorf("invalid target document: %w", err)}
+	}
+
+	for i, op := range ops {
+		root, err = applyOperation(root, op)
+		if err != nil {
+			return nil, &Error{Source: Body, Key: op.Path, Value: op.Op, Err: fmt.Errorf("operation %d: %w", i, err)}
+		}
+	}
+	return json.Marshal(root)
+}
+
+func validatePatch(ops []PatchOperation) error {
+	var errs []error
+	for i, op := range ops {
+		var err error
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				err = errors.New(`"value" is required`)
+			}
+		case "move", "copy":
+			if _, perr := parsePointer(op.From); perr != nil {
+				err = fmt.Errorf(`invalid "from": %w`, perr)
+			}
+		case "remove":
+		default:
+			err = fmt.Errorf("unknown operation %q", op.Op)
+		}
+		if _, perr := parsePointer(op.Path); err == nil && perr != nil {
+			err = fmt.Errorf(`invalid "path": %w`, perr)
+		}
+		if err != nil {
+			errs = append(errs, &Error{Source: Body, Key: op.Path, Value: op.Op, Err: fmt.Errorf("operation %d: %w", i, err)})
+		}
+	}
+	return Join(errs...)
+}
+
+func applyOperation(root any, op PatchOperation) (any, error) {
+	path, _ := parsePointer(op.Path) // validated
+	switch op.Op {
+	case "add":
+		value, err := decodeJSON(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(root, path, value)
+	case "remove":
+		root, _, err := pointerRemove(root, path)
+		return root, err
+	case "replace":
+		value, err := decodeJSON(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		if root, _, err = pointerRemove(root, path); err != nil {
+			return nil, err
+		}
+		return pointerAdd(root, path, value)
+	case "move":
+		if op.Path == op.From {
+			return root, nil
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, errors.New("cannot move a value into one of its children")
+		}
+		from, _ := parsePointer(op.From)
+		root, value, err := pointerRemove(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(root, path, value)
+	case "copy":
+		from, _ := parsePointer(op.From)
+		value, err := pointerGet(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(root, path, deepCopy(value))
+	case "test":
+		want, err := decodeJSON(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		got, err := pointerGet(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(got, want) {
+			return nil, errors.New("test failed")
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// ApplyMergePatch applies a JSON Merge Patch to a JSON document (RFC 7396).
+func ApplyMergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decodeJSON(doc)
+	if err != nil {
+		return nil, &Error{Source: Body, Err: fmt.Errorf("invalid target document: %w", err)}
+	}
+	p, err := decodeJSON(patch)
+	if err != nil {
+		return nil, &Error{Source: Body, Err: err}
+	}
+	return json.Marshal(mergePatch(target, p))
+}
+
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
+
+// parsePointer parses a JSON Pointer (RFC 6901) into its reference tokens.
+func parsePointer(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("json pointer %q must start with '/'", s)
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i, tok := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func pointerGet(node any, path []string) (any, error) {
+	for _, tok := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			child, ok := n[tok]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", tok)
+			}
+			node = child
+		case []any:
+			i, err := arrayIndex(tok, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("cannot traverse into %q", tok)
+		}
+	}
+	return node, nil
+}
+
+// pointerAdd adds value at path and returns the updated root.
+func pointerAdd(node any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	tok, rest := path[0], path[1:]
+	switch n := node.(type) {
+	case map[string]any:
+		if len(rest) == 0 {
+			n[tok] = value
+			return n, nil
+		}
+		child, ok := n[tok]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", tok)
+		}
+		child, err := pointerAdd(child, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		n[tok] = child
+		return n, nil
+	case []any:
+		if len(rest) == 0 {
+			if tok == "-" {
+				return append(n, value), nil
+			}
+			i, err := arrayIndex(tok, len(n))
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		}
+		i, err := arrayIndex(tok, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := pointerAdd(n[i], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = child
+		return n, nil
+	}
+	return nil, fmt.Errorf("cannot traverse into %q", tok)
+}
+
+// pointerRemove removes the value at path and returns the updated root and the removed value.
+func pointerRemove(node any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("cannot remove the whole document")
+	}
+	tok, rest := path[0], path[1:]
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[tok]
+		if !ok {
+			return nil, nil, fmt.Errorf("member %q not found", tok)
+		}
+		if len(rest) == 0 {
+			delete(n, tok)
+			return n, child, nil
+		}
+		child, removed, err := pointerRemove(child, rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		n[tok] = child
+		return n, removed, nil
+	case []any:
+		i, err := arrayIndex(tok, len(n)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rest) == 0 {
+			removed := n[i]
+			return append(n[:i], n[i+1:]...), removed, nil
+		}
+		child, removed, err := pointerRemove(n[i], rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		n[i] = child
+		return n, removed, nil
+	}
+	return nil, nil, fmt.Errorf("cannot traverse into %q", tok)
+}
+
+// arrayIndex parses an array index in the range [0, max].
+func arrayIndex(tok string, max int) (int, error) {
+	i, err := strconv.Atoi(tok)
+	if err != nil || (len(tok) > 1 && tok[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	if i < 0 || i > max {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func decodeJSON(b []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func deepCopy(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = deepCopy(e)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, e := range v {
+			s[i] = deepCopy(e)
+		}
+		return s
+	}
+	return v
+}
+
+func jsonEqual(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			w, ok := b[k]
+			if !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, errA := a.Float64()
+		y, errB := b.Float64()
+		return errA == nil && errB == nil && x == y
+	}
+	return a == b
+}
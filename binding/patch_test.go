@@ -0,0 +1,191 @@
+package binding
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	doc := `{"name":"foo","tags":["a","b"],"profile":{"age":20}}`
+
+	tests := []struct {
+		name    string
+		ops     []PatchOperation
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "add, remove, replace",
+			ops: []PatchOperation{
+				{Op: "add", Path: "/tags/1", Value: []byte(`"x"`)},
+				{Op: "add", Path: "/tags/-", Value: []byte(`"z"`)},
+				{Op: "remove", Path: "/tags/0"},
+				{Op: "replace", Path: "/profile/age", Value: []byte(`21`)},
+			},
+			want: `{"name":"foo","profile":{"age":21},"tags":["x","b","z"]}`,
+		},
+		{
+			name: "move and copy",
+			ops: []PatchOperation{
+				{Op: "copy", From: "/profile", Path: "/backup"},
+				{Op: "move", From: "/name", Path: "/profile/name"},
+			},
+			want: `{"backup":{"age":20},"profile":{"age":20,"name":"foo"},"tags":["a","b"]}`,
+		},
+		{
+			name: "escaped pointer",
+			ops:  []PatchOperation{{Op: "add", Path: "/a~1b~0c", Value: []byte(`1`)}},
+			want: `{"a/b~c":1,"name":"foo","profile":{"age":20},"tags":["a","b"]}`,
+		},
+		{
+			name: "test passes",
+			ops:  []PatchOperation{{Op: "test", Path: "/profile/age", Value: []byte(`20.0`)}},
+			want: `{"name":"foo","profile":{"age":20},"tags":["a","b"]}`,
+		},
+		{
+			name:    "test fails",
+			ops:     []PatchOperation{{Op: "test", Path: "/name", Value: []byte(`"bar"`)}},
+			wantErr: true,
+		},
+		{
+			name:    "missing member",
+			ops:     []PatchOperation{{Op: "replace", Path: "/missing", Value: []byte(`1`)}},
+			wantErr: true,
+		},
+		{
+			name:    "index out of range",
+			ops:     []PatchOperation{{Op: "add", Path: "/tags/5", Value: []byte(`"x"`)}},
+			wantErr: true,
+		},
+		{
+			name:    "move into child",
+			ops:     []PatchOperation{{Op: "move", From: "/profile", Path: "/profile/nested"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyJSONPatch([]byte(doc), tt.ops)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyJSONPatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); err == nil && diff != "" {
+				t.Errorf("ApplyJSONPatch() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatch_InvalidOperations(t *testing.T) {
+	ops := []PatchOperation{
+		{Op: "frobnicate", Path: "/name"},
+		{Op: "add", Path: "/name"},
+		{Op: "remove", Path: "name"},
+	}
+	_, err := ApplyJSONPatch([]byte(`{}`), ops)
+
+	var vErrs *ValidationErrors
+	if !errors.As(err, &vErrs) {
+		t.Fatalf("expected *ValidationErrors, got %T: %v", err, err)
+	}
+	var got []string
+	for _, e := range vErrs.Errors {
+		got = append(got, e.Err.Error())
+	}
+	want := []string{
+		`operation 0: unknown operation "frobnicate"`,
+		`operation 1: "value" is required`,
+		`operation 2: invalid "path": json pointer "name" must start with '/'`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("errors mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	doc := `{"title":"Goodbye!","author":{"givenName":"John","familyName":"Doe"},"tags":["example","sample"]}`
+	patch := `{"title":"Hello!","phoneNumber":"+01-123-456-7890","author":{"familyName":null},"tags":["example"]}`
+
+	got, err := ApplyMergePatch([]byte(doc), []byte(patch))
+	if err != nil {
+		t.Fatalf("ApplyMergePatch() failed: %v", err)
+	}
+	want := `{"author":{"givenName":"John"},"phoneNumber":"+01-123-456-7890","tags":["example"],"title":"Hello!"}`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("ApplyMergePatch() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPatchBody(t *testing.T) {
+	type User struct {
+		Name  string   `json:"name"`
+		Email string   `json:"email,omitempty"`
+		Tags  []string `json:"tags"`
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        User
+		wantErr     bool
+	}{
+		{
+			name:        "json patch",
+			contentType: JSONPatchMediaType,
+			body:        `[{"op":"replace","path":"/name","value":"bar"},{"op":"add","path":"/tags/-","value":"new"}]`,
+			want:        User{Name: "bar", Email: "foo@example.com", Tags: []string{"a", "new"}},
+		},
+		{
+			name:        "merge patch removes a field",
+			contentType: MergePatchMediaType + "; charset=utf-8",
+			body:        `{"email":null}`,
+			want:        User{Name: "foo", Tags: []string{"a"}},
+		},
+		{
+			name:        "unsupported media type",
+			contentType: "application/json",
+			body:        `{"name":"bar"}`,
+			want:        User{Name: "foo", Email: "foo@example.com", Tags: []string{"a"}},
+			wantErr:     true,
+		},
+		{
+			name:        "failing patch leaves the target unchanged",
+			contentType: JSONPatchMediaType,
+			body:        `[{"op":"replace","path":"/name","value":"bar"},{"op":"remove","path":"/missing"}]`,
+			want:        User{Name: "foo", Email: "foo@example.com", Tags: []string{"a"}},
+			wantErr:     true,
+		},
+		{
+			name:        "type mismatch",
+			contentType: MergePatchMediaType,
+			body:        `{"name":1}`,
+			want:        User{Name: "foo", Email: "foo@example.com", Tags: []string{"a"}},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PATCH", "/users/1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			user := User{Name: "foo", Email: "foo@example.com", Tags: []string{"a"}}
+			err := PatchBody(New(req, nil), &user)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PatchBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && Join(err) == nil {
+				t.Errorf("expected a binding error, got %T", err)
+			}
+			if diff := cmp.Diff(tt.want, user); diff != "" {
+				t.Errorf("PatchBody() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
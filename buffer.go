@@ -0,0 +1,49 @@
+package rakuda
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+)
+
+// bufferPool holds the buffers of buffered Responders (see Responder.Buffered).
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// maxPooledBufferSize is the capacity above which a buffer is dropped instead of pooled,
+// so that a single large response does not keep its memory alive.
+const maxPooledBufferSize = 64 << 10
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// NewContextWithChunked returns a new context in which the Responder sends responses with
+// chunked transfer encoding, without Content-Length, even if it is buffered: the header is
+// flushed before the body. It is meant for responses that are streamed or slow to produce,
+// so that the client gets the status as early as possible.
+func NewContextWithChunked(ctx context.Context) context.Context {
+	return context.WithValue(ctx, chunkedKey, true)
+}
+
+// chunkedFromContext reports whether chunked encoding is forced by NewContextWithChunked.
+func chunkedFromContext(ctx context.Context) bool {
+	chunked, _ := ctx.Value(chunkedKey).(bool)
+	return chunked
+}
+
+// flushHeader sends the header, so that net/http does not compute Content-Length
+// and uses chunked encoding for the body (HTTP/1.1).
+func flushHeader(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,99 @@
+package rakuda
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResponder_Buffered(t *testing.T) {
+	buffered := &Responder{Buffered: true}
+	tests := []struct {
+		name              string
+		handler           http.HandlerFunc
+		wantStatus        int
+		wantBody          string
+		wantContentLength int64 // -1 if unknown, i.e., chunked
+	}{
+		{
+			name: "json",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				buffered.JSON(w, r, http.StatusOK, map[string]int{"id": 1})
+			},
+			wantStatus:        http.StatusOK,
+			wantBody:          "{\"id\":1}\n",
+			wantContentLength: 9,
+		},
+		{
+			name: "html",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				buffered.HTML(w, r, http.StatusOK, []byte("<p>ok</p>"))
+			},
+			wantStatus:        http.StatusOK,
+			wantBody:          "<p>ok</p>",
+			wantContentLength: 9,
+		},
+		{
+			name: "encoding error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				buffered.JSON(w, r, http.StatusOK, map[string]any{"f": func() {}})
+			},
+			wantStatus:        http.StatusInternalServerError,
+			wantBody:          "{\"error\":\"Internal Server Error\"}\n",
+			wantContentLength: 34,
+		},
+		{
+			name: "chunked",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				r = r.WithContext(NewContextWithChunked(r.Context()))
+				buffered.JSON(w, r, http.StatusOK, map[string]int{"id": 1})
+			},
+			wantStatus:        http.StatusOK,
+			wantBody:          "{\"id\":1}\n",
+			wantContentLength: -1,
+		},
+		{
+			name: "chunked html",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				r = r.WithContext(NewContextWithChunked(r.Context()))
+				buffered.HTML(w, r, http.StatusOK, []byte("<p>ok</p>"))
+			},
+			wantStatus:        http.StatusOK,
+			wantBody:          "<p>ok</p>",
+			wantContentLength: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.handler(w, r.WithContext(NewContextWithLogger(r.Context(), slog.New(slog.DiscardHandler))))
+			}))
+			defer ts.Close()
+
+			res, err := http.Get(ts.URL)
+			if err != nil {
+				t.Fatalf("http.Get() failed: %v", err)
+			}
+			defer res.Body.Close()
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("io.ReadAll() failed: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.wantStatus, res.StatusCode); diff != "" {
+				t.Errorf("status mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantBody, string(body)); diff != "" {
+				t.Errorf("body mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantContentLength, res.ContentLength); diff != "" {
+				t.Errorf("content length mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
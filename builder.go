@@ -1,10 +1,17 @@
 package rakuda
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"os"
 	"path"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
 )
 
 // Middleware is a function that wraps an http.Handler.
@@ -17,14 +24,50 @@ type action interface {
 
 type middlewareAction struct {
 	middleware Middleware
+	source     string   // registration location (file:line)
+	methods    []string // set by UseFor; empty for all methods
 }
 
 func (middlewareAction) isAction() {}
 
+// appliesTo reports whether the middleware wraps routes of the method.
+// Method-agnostic routes ("") are wrapped, and the method is checked per request by wrap.
+func (ma middlewareAction) appliesTo(method string) bool {
+	return len(ma.methods) == 0 || method == "" || slices.Contains(ma.methods, method)
+}
+
+// wrap applies the middleware to the handler of a route with the method. For a middleware
+// added with UseFor and a method-agnostic handler, the middleware is skipped for requests
+// with other methods.
+func (ma middlewareAction) wrap(handler http.Handler, method string) http.Handler {
+	if len(ma.methods) == 0 || method != "" {
+		return ma.middleware(handler)
+	}
+	wrapped := ma.middleware(handler)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slices.Contains(ma.methods, r.Method) {
+			wrapped.ServeHTTP(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+type aliasAction struct {
+	pattern string // the alias, relative to the node
+	target  string // the pattern of the aliased routes, relative to the node
+	source  string // registration location (file:line)
+}
+
+func (aliasAction) isAction() {}
+
 type handlerAction struct {
 	method  string
 	pattern string
 	handler http.Handler
+	meta    Meta
+	source  string             // registration location (file:line)
+	inline  []middlewareAction // added by With, applied inside the node's middlewares
 }
 
 func (handlerAction) isAction() {}
@@ -34,6 +77,11 @@ type node struct {
 	pattern  string
 	actions  []action
 	children []*node
+	source   string       // registration location (file:line) of Route or Group
+	isRoute  bool         // true if created by Route, false if created by Group
+	host     string       // host constraint, set by Host
+	notFound http.Handler // NotFound handler of a merged Builder, for the requests under this node
+	disabled bool         // set by When with a false condition
 }
 
 // BuilderConfig holds the configuration for a Builder.
@@ -44,6 +92,44 @@ type BuilderConfig struct {
 	// to halt the build process. If it returns nil, the conflict is ignored and the
 	// duplicate route is not registered.
 	OnConflict func(b *Builder, routeKey string) error
+	// OnRouteConflict is like OnConflict, but receives both routes with their registration
+	// locations. If set, it is called instead of OnConflict. See WithOnRouteConflict.
+	OnRouteConflict func(b *Builder, conflict RouteConflict) error
+	// Debug enables diagnostics. When enabled, Build logs the fully resolved
+	// middleware chain of each route, and PrintRoutes shows it as well.
+	Debug bool
+	// Strict makes Build fail on suspicious configurations that are otherwise
+	// silently accepted. See WithStrict for the list of checks.
+	Strict bool
+	// AutoOptions answers OPTIONS requests for paths without an OPTIONS route.
+	// See WithAutoOptions.
+	AutoOptions bool
+	// PreMatch are middlewares that run before the request is matched against the routes,
+	// outermost first. See WithPreMatch.
+	PreMatch []Middleware
+	// AutoHead makes GET routes answer HEAD requests with the Content-Length of the GET response.
+	// See WithAutoHead.
+	AutoHead bool
+	// TrailingSlash decides how requests that match a route only with or without a trailing
+	// slash are handled. See WithTrailingSlashPolicy.
+	TrailingSlash TrailingSlashPolicy
+	// CaseInsensitivePaths matches paths case-insensitively against lowercase routes.
+	// See WithCaseInsensitivePaths.
+	CaseInsensitivePaths bool
+	// CanonicalCaseRedirect redirects case-insensitive matches to the canonical casing
+	// instead of rewriting them. See WithCanonicalCaseRedirect.
+	CanonicalCaseRedirect bool
+	// OnOverlap is called by Build for each pair of overlapping routes. See WithOverlapDetection.
+	OnOverlap func(RouteOverlap)
+	// NotFoundSuggestions is the maximum number of patterns suggested by the default
+	// NotFound handler. See WithNotFoundSuggestions.
+	NotFoundSuggestions int
+	// MiddlewareOnNotFound applies the root middlewares to the 404 and 405 responses.
+	// See WithMiddlewareOnNotFound.
+	MiddlewareOnNotFound bool
+	// NewMux returns the Mux that Build registers the routes to. Default is http.NewServeMux.
+	// See WithMux.
+	NewMux func() Mux
 }
 
 // WithLogger sets the logger for the Builder.
@@ -60,13 +146,103 @@ func WithOnConflict(onConflict func(b *Builder, routeKey string) error) func(*Bu
 	}
 }
 
+// WithOnRouteConflict sets the OnRouteConflict handler for the Builder, which receives
+// the registration locations of the conflicting routes:
+//
+//	rakuda.WithOnRouteConflict(func(b *rakuda.Builder, c rakuda.RouteConflict) error {
+//		return fmt.Errorf("route %s registered at %s and %s", c.Key, c.Existing.Source, c.Duplicate.Source)
+//	})
+func WithOnRouteConflict(onConflict func(b *Builder, conflict RouteConflict) error) func(*BuilderConfig) {
+	return func(c *BuilderConfig) {
+		c.OnRouteConflict = onConflict
+	}
+}
+
+// RouteConflict describes a route registered twice with the same method, host, and pattern.
+type RouteConflict struct {
+	// Key is the route key, e.g., "GET /users/{id}".
+	Key string
+	// Existing is the route registered first, which is kept.
+	Existing RouteInfo
+	// Duplicate is the route registered later, which is not registered unless OnRouteConflict fails the build.
+	Duplicate RouteInfo
+}
+
+// WithDebug enables or disables the debug mode of the Builder.
+func WithDebug(debug bool) func(*BuilderConfig) {
+	return func(c *BuilderConfig) {
+		c.Debug = debug
+	}
+}
+
+// WithStrict enables strict mode. In strict mode, Build returns an error for:
+//   - routes whose wildcards conflict with an earlier route (e.g., /users/{id} and /users/{name}),
+//   - groups registered with Route and an empty pattern (use Group instead).
+func WithStrict() func(*BuilderConfig) {
+	return func(c *BuilderConfig) {
+		c.Strict = true
+	}
+}
+
+// WithAutoOptions makes the router answer OPTIONS requests with 204 and an Allow header
+// listing the methods registered for the path, unless an OPTIONS route is registered for it.
+// The response passes through the root middlewares, so a CORS middleware added with Use
+// answers preflight requests with the methods of the matched routes.
+func WithAutoOptions() func(*BuilderConfig) {
+	return func(c *BuilderConfig) {
+		c.AutoOptions = true
+	}
+}
+
+// WithPreMatch adds middlewares that run before the request is matched against the routes,
+// e.g., to rewrite the path. Unlike the middlewares added with Use, they see every request,
+// including those answered with 404 or 405, but the route is not known yet:
+// r.Pattern is empty and RouteMetaFromContext reports false.
+//
+//	b := rakuda.NewBuilder(rakuda.WithPreMatch(rakuda.Normalize(nil)))
+func WithPreMatch(middlewares ...Middleware) func(*BuilderConfig) {
+	return func(c *BuilderConfig) {
+		c.PreMatch = append(c.PreMatch, middlewares...)
+	}
+}
+
+// WithAutoHead makes GET routes answer HEAD requests by running the GET handler
+// with a ResponseWriter that discards the body but reports its length in the
+// Content-Length header. Routes registered with Head take precedence.
+func WithAutoHead() func(*BuilderConfig) {
+	return func(c *BuilderConfig) {
+		c.AutoHead = true
+	}
+}
+
+// WithMiddlewareOnNotFound makes the middlewares added to the root builder with Use also wrap
+// the NotFound and MethodNotAllowed handlers, so that request logging, CORS, and recovery
+// see every request. By default, those handlers bypass the middleware chain.
+// Middlewares of groups still apply only to their routes.
+func WithMiddlewareOnNotFound(enabled bool) func(*BuilderConfig) {
+	return func(c *BuilderConfig) {
+		c.MiddlewareOnNotFound = enabled
+	}
+}
+
 // Builder is the configuration object for the router.
 // It is used to define routes and middlewares.
 // It does not implement http.Handler.
 type Builder struct {
-	node            *node
-	notFoundHandler http.Handler
-	config          *BuilderConfig
+	node                    *node
+	notFoundHandler         http.Handler
+	methodNotAllowedHandler http.Handler
+	spaFallback             Middleware // set by SPAFallback, wraps the NotFound handler
+	config                  *BuilderConfig
+	state                   *builderState      // shared by the root builder and all of its child builders
+	inline                  []middlewareAction // added by With, applied to each handler registered through this builder
+}
+
+// builderState holds the state shared across a routing tree.
+type builderState struct {
+	built        bool             // set by Build; the tree is frozen afterwards
+	fallbacks    []*http.ServeMux // absorbed muxes, consulted when no route matches
+	dependencies map[any]any      // set by Provide, keyed by providedKey[T]
 }
 
 // NewBuilder creates a new Builder instance with the given options.
@@ -84,12 +260,17 @@ func NewBuilder(options ...func(*BuilderConfig)) *Builder {
 	b := &Builder{
 		node:   &node{},
 		config: config,
+		state:  &builderState{},
 	}
 
-	// Set default OnConflict after options, so a custom logger is used if provided.
-	if config.OnConflict == nil {
-		config.OnConflict = func(b *Builder, routeKey string) error {
-			config.Logger.Warn("route conflict", "route", routeKey)
+	// Set default OnRouteConflict after options, so a custom logger is used if provided.
+	if config.OnConflict == nil && config.OnRouteConflict == nil {
+		config.OnRouteConflict = func(b *Builder, c RouteConflict) error {
+			config.Logger.Warn("route conflict",
+				"route", c.Key,
+				"source", c.Duplicate.Source,
+				"existing_source", c.Existing.Source,
+			)
 			return nil
 		}
 	}
@@ -100,10 +281,28 @@ func NewBuilder(options ...func(*BuilderConfig)) *Builder {
 // NotFound sets a custom handler for 404 Not Found responses.
 // If not set, a default JSON response is used.
 func (b *Builder) NotFound(handler http.Handler) {
+	b.checkFrozen(callerSource(2))
 	b.notFoundHandler = handler
 }
 
-func (b *Builder) registerHandler(method string, pattern string, handler http.Handler) {
+// MethodNotAllowed sets a custom handler for 405 Method Not Allowed responses,
+// which are served when the path matches a route but the method does not.
+// The Allow header listing the registered methods is set before the handler is called.
+// If not set, a default JSON response is used.
+func (b *Builder) MethodNotAllowed(handler http.Handler) {
+	b.checkFrozen(callerSource(2))
+	b.methodNotAllowedHandler = handler
+}
+
+// registerHandler registers a handler. It must be called directly from the
+// exported registration methods, so that the caller's location can be recorded.
+func (b *Builder) registerHandler(method string, pattern string, handler http.Handler, meta []Meta) {
+	b.addHandler(method, pattern, handler, callerSource(3), meta...)
+}
+
+// addHandler adds a handler action with an explicit registration location.
+func (b *Builder) addHandler(method string, pattern string, handler http.Handler, source string, meta ...Meta) {
+	b.checkFrozen(source)
 	// Use '{$}' to ensure the root path doesn't act as a catch-all.
 	if pattern == "/" {
 		pattern = "/{$}"
@@ -112,197 +311,835 @@ func (b *Builder) registerHandler(method string, pattern string, handler http.Ha
 		method:  method,
 		pattern: pattern,
 		handler: handler,
+		meta:    mergeMeta(meta),
+		source:  source,
+		inline:  b.inline,
 	})
 }
 
+// With returns a view of the builder that applies the given middlewares to the handlers
+// registered through it, in addition to the middlewares of the enclosing groups:
+//
+//	b.With(adminOnly, audit).Delete("/users/{id}", deleteUser)
+//
+// The view registers routes on the same node, so that Walk and Build see a single tree.
+// Use adds to the node and affects all of its routes, not only those registered through the view.
+func (b *Builder) With(middlewares ...Middleware) *Builder {
+	source := callerSource(2)
+	inline := slices.Clone(b.inline)
+	for _, mw := range middlewares {
+		inline = append(inline, middlewareAction{middleware: mw, source: source})
+	}
+	view := *b
+	view.inline = inline
+	return &view
+}
+
 // Use adds a middleware to the current builder's node.
 func (b *Builder) Use(middleware Middleware) {
-	b.node.actions = append(b.node.actions, middlewareAction{middleware: middleware})
+	source := callerSource(2)
+	b.checkFrozen(source)
+	b.node.actions = append(b.node.actions, middlewareAction{
+		middleware: middleware,
+		source:     source,
+	})
+}
+
+// UseFor is like Use, but the middleware wraps only the routes with one of the methods,
+// so that write-only middlewares (e.g., CSRF protection, body limits, idempotency keys)
+// can be scoped in a group that also has read routes:
+//
+//	b.Route("/orders", func(b *rakuda.Builder) {
+//		b.UseFor([]string{http.MethodPost, http.MethodPut, http.MethodPatch}, bodyLimit)
+//		b.Get("/", listOrders)    // not wrapped
+//		b.Post("/", createOrder)  // wrapped
+//	})
+//
+// Routes registered without a method (Handle) are wrapped, but the middleware runs only
+// for requests with one of the methods. It panics if methods is empty.
+func (b *Builder) UseFor(methods []string, middleware Middleware) {
+	source := callerSource(2)
+	b.checkFrozen(source)
+	if len(methods) == 0 {
+		panic(fmt.Sprintf("rakuda: UseFor is called without methods at %s", source))
+	}
+	b.node.actions = append(b.node.actions, middlewareAction{
+		middleware: middleware,
+		source:     source,
+		methods:    slices.Clone(methods),
+	})
+}
+
+// Alias makes the routes registered for the target pattern also serve the alias pattern,
+// with the same methods, middlewares, and metadata, e.g., to keep legacy paths working
+// during a migration:
+//
+//	b.Get("/users/{id}", getUser)
+//	b.Alias("/v1/users/{id}", "/users/{id}") // GET /v1/users/{id} is served by getUser
+//
+// Both patterns are relative to the builder, like those of Get, and the target may be
+// registered in a nested group. Walk and PrintRoutes list an alias after its route, and
+// RouteInfo.AliasOf tells the target. Overlap detection and strict mode do not report
+// an alias against its target; a duplicate of another route is still a conflict.
+// Build fails if no route is registered for the target, or if the wildcards of the alias
+// differ from those of the target, whose path values the handler reads.
+func (b *Builder) Alias(alias, target string) {
+	source := callerSource(2)
+	b.checkFrozen(source)
+	b.node.actions = append(b.node.actions, aliasAction{pattern: alias, target: target, source: source})
+}
+
+// Get registers a GET handler. Optional metadata can be attached to the route.
+func (b *Builder) Get(pattern string, handler http.Handler, meta ...Meta) {
+	b.registerHandler(http.MethodGet, pattern, handler, meta)
+}
+
+// Post registers a POST handler. Optional metadata can be attached to the route.
+func (b *Builder) Post(pattern string, handler http.Handler, meta ...Meta) {
+	b.registerHandler(http.MethodPost, pattern, handler, meta)
+}
+
+// Put registers a PUT handler. Optional metadata can be attached to the route.
+func (b *Builder) Put(pattern string, handler http.Handler, meta ...Meta) {
+	b.registerHandler(http.MethodPut, pattern, handler, meta)
+}
+
+// Delete registers a DELETE handler. Optional metadata can be attached to the route.
+func (b *Builder) Delete(pattern string, handler http.Handler, meta ...Meta) {
+	b.registerHandler(http.MethodDelete, pattern, handler, meta)
 }
 
-// Get registers a GET handler.
-func (b *Builder) Get(pattern string, handler http.Handler) {
-	b.registerHandler(http.MethodGet, pattern, handler)
+// Patch registers a PATCH handler. Optional metadata can be attached to the route.
+func (b *Builder) Patch(pattern string, handler http.Handler, meta ...Meta) {
+	b.registerHandler(http.MethodPatch, pattern, handler, meta)
 }
 
-// Post registers a POST handler.
-func (b *Builder) Post(pattern string, handler http.Handler) {
-	b.registerHandler(http.MethodPost, pattern, handler)
+// Head registers a HEAD handler. Optional metadata can be attached to the route.
+// Note that GET handlers already respond to HEAD requests.
+func (b *Builder) Head(pattern string, handler http.Handler, meta ...Meta) {
+	b.registerHandler(http.MethodHead, pattern, handler, meta)
 }
 
-// Put registers a PUT handler.
-func (b *Builder) Put(pattern string, handler http.Handler) {
-	b.registerHandler(http.MethodPut, pattern, handler)
+// Options registers an OPTIONS handler. Optional metadata can be attached to the route.
+func (b *Builder) Options(pattern string, handler http.Handler, meta ...Meta) {
+	b.registerHandler(http.MethodOptions, pattern, handler, meta)
 }
 
-// Delete registers a DELETE handler.
-func (b *Builder) Delete(pattern string, handler http.Handler) {
-	b.registerHandler(http.MethodDelete, pattern, handler)
+// Connect registers a CONNECT handler. Optional metadata can be attached to the route.
+func (b *Builder) Connect(pattern string, handler http.Handler, meta ...Meta) {
+	b.registerHandler(http.MethodConnect, pattern, handler, meta)
 }
 
-// Patch registers a PATCH handler.
-func (b *Builder) Patch(pattern string, handler http.Handler) {
-	b.registerHandler(http.MethodPatch, pattern, handler)
+// Trace registers a TRACE handler. Optional metadata can be attached to the route.
+func (b *Builder) Trace(pattern string, handler http.Handler, meta ...Meta) {
+	b.registerHandler(http.MethodTrace, pattern, handler, meta)
+}
+
+// Method registers a handler for an arbitrary method, including custom verbs
+// such as PROPFIND. Optional metadata can be attached to the route.
+func (b *Builder) Method(method string, pattern string, handler http.Handler, meta ...Meta) {
+	if method == "" {
+		panic("rakuda: Method requires a method; use Handle for method-agnostic routes")
+	}
+	b.registerHandler(strings.ToUpper(method), pattern, handler, meta)
+}
+
+// Handle registers a method-agnostic handler, which matches requests with any method
+// unless a more specific route is registered for the method.
+// Optional metadata can be attached to the route.
+func (b *Builder) Handle(pattern string, handler http.Handler, meta ...Meta) {
+	b.registerHandler("", pattern, handler, meta)
 }
 
 // Route creates a new routing group.
 func (b *Builder) Route(pattern string, fn func(b *Builder)) {
+	source := callerSource(2)
+	b.checkFrozen(source)
 	childNode := &node{
 		pattern: pattern,
+		source:  source,
+		isRoute: true,
+		actions: b.inlineActions(),
 	}
 	b.node.children = append(b.node.children, childNode)
-	childBuilder := &Builder{node: childNode, config: b.config}
+	childBuilder := &Builder{node: childNode, config: b.config, state: b.state}
 	fn(childBuilder)
 }
 
 // Group creates a new middleware-only group.
 func (b *Builder) Group(fn func(b *Builder)) {
-	childNode := &node{}
+	source := callerSource(2)
+	b.checkFrozen(source)
+	childNode := &node{
+		source:  source,
+		actions: b.inlineActions(),
+	}
 	b.node.children = append(b.node.children, childNode)
-	childBuilder := &Builder{node: childNode, config: b.config}
+	childBuilder := &Builder{node: childNode, config: b.config, state: b.state}
 	fn(childBuilder)
 }
 
+// When creates a new middleware-only group whose routes are registered only if enabled,
+// so that experimental endpoints can be compiled in and switched on by a flag:
+//
+//	b.When(cfg.EnableBeta, func(b *rakuda.Builder) {
+//		b.Get("/beta/search", searchHandler)
+//	})
+//
+// The routes of a disabled group are not built, and Walk and Routes skip them;
+// PrintRoutesWithOptions lists them with the Disabled option.
+func (b *Builder) When(enabled bool, fn func(b *Builder)) {
+	source := callerSource(2)
+	b.checkFrozen(source)
+	childNode := &node{
+		source:   source,
+		actions:  b.inlineActions(),
+		disabled: !enabled,
+	}
+	b.node.children = append(b.node.children, childNode)
+	childBuilder := &Builder{node: childNode, config: b.config, state: b.state}
+	fn(childBuilder)
+}
+
+// Host creates a new group whose routes match only requests for the given host,
+// e.g., "api.example.com". The host is matched against the Host header without the port,
+// as in ServeMux patterns. A Host group nested in another overrides its host.
+func (b *Builder) Host(host string, fn func(b *Builder)) {
+	if host == "" || strings.ContainsAny(host, "/ ") {
+		panic(fmt.Sprintf("rakuda: invalid host %q", host))
+	}
+	source := callerSource(2)
+	b.checkFrozen(source)
+	childNode := &node{
+		host:    host,
+		source:  source,
+		actions: b.inlineActions(),
+	}
+	b.node.children = append(b.node.children, childNode)
+	childBuilder := &Builder{node: childNode, config: b.config, state: b.state}
+	fn(childBuilder)
+}
+
+// checkFrozen panics if the routing tree has been built, because changes made after Build
+// would silently not affect the built handler. source is the location of the change.
+func (b *Builder) checkFrozen(source string) {
+	if b.state.built {
+		panic(fmt.Sprintf("rakuda: the Builder is modified after Build at %s; use Clone to derive a new Builder", source))
+	}
+}
+
+// Clone returns a copy of the routing tree of b, with its handlers, middlewares, and configuration,
+// that can be modified and built independently, even if b has been built:
+//
+//	handler, _ := b.Build()
+//	b2 := b.Clone()
+//	b2.Get("/debug/vars", expvar.Handler()) // does not affect handler or b
+//
+// Handlers and middlewares are shared, not copied. Called on a child builder (in Route or
+// Group), Clone copies its subtree as a new root builder.
+func (b *Builder) Clone() *Builder {
+	return &Builder{
+		node:                    b.node.clone(),
+		notFoundHandler:         b.notFoundHandler,
+		methodNotAllowedHandler: b.methodNotAllowedHandler,
+		spaFallback:             b.spaFallback,
+		config:                  b.config,
+		state:                   &builderState{fallbacks: slices.Clone(b.state.fallbacks), dependencies: maps.Clone(b.state.dependencies)},
+	}
+}
+
+// clone returns a deep copy of the node and its descendants.
+func (n *node) clone() *node {
+	c := *n
+	c.actions = slices.Clone(n.actions)
+	c.children = make([]*node, len(n.children))
+	for i, child := range n.children {
+		c.children[i] = child.clone()
+	}
+	return &c
+}
+
+// inlineActions returns the middlewares added by With as the first actions of a child node,
+// so that they wrap the middlewares of the group, as in chi.
+func (b *Builder) inlineActions() []action {
+	actions := make([]action, 0, len(b.inline))
+	for _, ma := range b.inline {
+		actions = append(actions, ma)
+	}
+	return actions
+}
+
 // Walk traverses the routing tree and calls the provided function for each registered handler.
-// The traversal is done in DFS order.
+// The traversal is done in DFS order. The method is empty for method-agnostic routes (see Mount).
+// The pattern of a route in a Host group starts with the host, e.g., "api.example.com/users".
 func (b *Builder) Walk(fn func(method string, pattern string)) {
-	var traverse func(*node, string, []Middleware)
-	traverse = func(n *node, prefix string, inheritedMiddlewares []Middleware) {
-		// Phase 1: Collect middlewares for the current node.
-		var nodeMiddlewares []Middleware
+	_ = b.walk(func(rt route) error {
+		fn(rt.method, rt.host+rt.pattern)
+		return nil
+	})
+}
+
+// WalkRoutes is like Walk, but passes the RouteInfo of each route, including its metadata,
+// e.g., to generate documentation or to check per-route policies.
+func (b *Builder) WalkRoutes(fn func(RouteInfo)) {
+	_ = b.walk(func(rt route) error {
+		fn(rt.info())
+		return nil
+	})
+}
+
+// route is a registered handler resolved against the routing tree.
+type route struct {
+	method      string
+	host        string // host constraint of the enclosing Host group, if any
+	prefix      string // path prefix of the enclosing groups
+	pattern     string // full pattern, including the prefixes of the enclosing groups
+	registered  string // pattern as passed to Get, Post, etc., before joining it with the prefix
+	handler     http.Handler
+	middlewares []middlewareAction // fully resolved chain, outermost first
+	meta        Meta
+	source      string
+	disabled    bool   // registered in a When group whose condition is false
+	aliasOf     string // full pattern of the route this one is an alias of (see Alias)
+}
+
+// walk traverses the routing tree in DFS order and calls fn for each registered handler,
+// together with its fully resolved middleware chain. It stops at the first error returned by fn.
+func (b *Builder) walk(fn func(route) error) error {
+	return b.walkAll(func(rt route) error {
+		if rt.disabled {
+			return nil
+		}
+		return fn(rt)
+	})
+}
+
+// walkAll is like walk, but also calls fn for the routes disabled by When.
+// The aliases of a route (see Alias) follow it.
+func (b *Builder) walkAll(fn func(route) error) error {
+	aliases := b.aliases()
+	if len(aliases) == 0 {
+		return b.walkRoutes(fn)
+	}
+	var routes []route
+	_ = b.walkRoutes(func(rt route) error {
+		routes = append(routes, rt)
+		return nil
+	})
+	for _, rt := range routes {
+		if err := fn(rt); err != nil {
+			return err
+		}
+		for _, a := range aliases {
+			if a.host != rt.host || a.target != rt.pattern {
+				continue
+			}
+			alias := rt
+			alias.prefix = a.prefix
+			alias.pattern = a.pattern
+			alias.registered = a.registered
+			alias.source = a.source
+			alias.disabled = rt.disabled || a.disabled
+			alias.aliasOf = rt.pattern
+			if err := fn(alias); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolvedAlias is an alias registered with Alias, with the full patterns.
+type resolvedAlias struct {
+	host       string
+	prefix     string
+	pattern    string
+	registered string // the alias as passed to Alias
+	target     string
+	source     string
+	disabled   bool
+}
+
+// aliases returns the aliases registered in the tree, in DFS order.
+func (b *Builder) aliases() []resolvedAlias {
+	var aliases []resolvedAlias
+	var traverse func(*node, string, string, bool)
+	traverse = func(n *node, host string, prefix string, disabled bool) {
+		if n.host != "" {
+			host = n.host
+		}
+		disabled = disabled || n.disabled
 		for _, a := range n.actions {
-			if ma, ok := a.(middlewareAction); ok {
-				nodeMiddlewares = append(nodeMiddlewares, ma.middleware)
+			if aa, ok := a.(aliasAction); ok {
+				aliases = append(aliases, resolvedAlias{
+					host:       host,
+					prefix:     prefix,
+					pattern:    path.Join(prefix, aa.pattern),
+					registered: aa.pattern,
+					target:     path.Join(prefix, aa.target),
+					source:     aa.source,
+					disabled:   disabled,
+				})
 			}
 		}
+		for _, child := range n.children {
+			traverse(child, host, path.Join(prefix, child.pattern), disabled)
+		}
+	}
+	traverse(b.node, "", "/", false)
+	return aliases
+}
+
+// walkRoutes calls fn for each registered handler, without the aliases.
+func (b *Builder) walkRoutes(fn func(route) error) error {
+	var traverse func(*node, string, string, []middlewareAction, bool) error
+	traverse = func(n *node, host string, prefix string, inheritedMiddlewares []middlewareAction, disabled bool) error {
+		if n.host != "" {
+			host = n.host
+		}
+		disabled = disabled || n.disabled
 
+		// Phase 1: Collect middlewares for the current node.
 		// Combine inherited middlewares with the current node's middlewares.
-		combinedMiddlewares := append([]Middleware{}, inheritedMiddlewares...)
-		combinedMiddlewares = append(combinedMiddlewares, nodeMiddlewares...)
+		combinedMiddlewares := append([]middlewareAction{}, inheritedMiddlewares...)
+		for _, a := range n.actions {
+			if ma, ok := a.(middlewareAction); ok {
+				combinedMiddlewares = append(combinedMiddlewares, ma)
+			}
+		}
 
 		// Phase 2: call fn for each handler.
 		for _, a := range n.actions {
 			if ha, ok := a.(handlerAction); ok {
-				fullPattern := path.Join(prefix, ha.pattern)
-				fn(ha.method, fullPattern)
+				rt := route{
+					method:      ha.method,
+					host:        host,
+					prefix:      prefix,
+					pattern:     path.Join(prefix, ha.pattern),
+					registered:  ha.pattern,
+					handler:     ha.handler,
+					middlewares: resolveMiddlewares(ha.method, combinedMiddlewares, ha.inline),
+					meta:        ha.meta,
+					source:      ha.source,
+					disabled:    disabled,
+				}
+				if err := fn(rt); err != nil {
+					return err
+				}
 			}
 		}
 
 		// Phase 3: Traverse children.
 		for _, child := range n.children {
 			newPrefix := path.Join(prefix, child.pattern)
-			traverse(child, newPrefix, combinedMiddlewares)
+			if err := traverse(child, host, newPrefix, combinedMiddlewares, disabled); err != nil {
+				return err
+			}
 		}
+		return nil
 	}
 
-	traverse(b.node, "/", []Middleware{})
+	return traverse(b.node, "", "/", nil, false)
+}
+
+// resolveMiddlewares returns the middlewares of the node and the inline ones that apply to the method.
+func resolveMiddlewares(method string, combined, inline []middlewareAction) []middlewareAction {
+	resolved := make([]middlewareAction, 0, len(combined)+len(inline))
+	for _, ma := range slices.Concat(combined, inline) {
+		if ma.appliesTo(method) {
+			resolved = append(resolved, ma)
+		}
+	}
+	return resolved
+}
+
+// key returns the ServeMux pattern of the route. Method-agnostic routes have no method.
+func (rt route) key() string {
+	if rt.method == "" {
+		return rt.host + rt.pattern
+	}
+	return rt.method + " " + rt.host + rt.pattern
+}
+
+// describe returns human-readable descriptions of the route's middleware chain,
+// in the order in which they are applied (outermost first).
+func (rt route) describe() []string {
+	chain := make([]string, 0, len(rt.middlewares))
+	for _, ma := range rt.middlewares {
+		if len(ma.methods) > 0 {
+			chain = append(chain, fmt.Sprintf("%s [%s] (%s)", funcName(ma.middleware), strings.Join(ma.methods, " "), ma.source))
+			continue
+		}
+		chain = append(chain, fmt.Sprintf("%s (%s)", funcName(ma.middleware), ma.source))
+	}
+	return chain
+}
+
+// callerSource returns the "file:line" of the caller at the given depth.
+func callerSource(depth int) string {
+	_, file, line, ok := runtime.Caller(depth)
+	if !ok {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+// funcName returns the name of the function value, resolved via runtime.FuncForPC.
+func funcName(fn any) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Sprintf("%T", fn)
+	}
+	if f := runtime.FuncForPC(v.Pointer()); f != nil {
+		return f.Name()
+	}
+	return "unknown"
 }
 
 // router is the internal http.Handler implementation created by the Builder.
 type router struct {
-	mux             *http.ServeMux
-	notFoundHandler http.Handler
+	mux                     Mux
+	fallbacks               []fallback
+	methods                 []string // registered methods, sorted
+	routes                  []RouteInfo
+	notFoundHandler         http.Handler
+	scopedNotFound          []scopedHandler // set by Merge, longest prefix first
+	methodNotAllowedHandler http.Handler
+	optionsHandler          http.Handler // set by WithAutoOptions
+	trailingSlash           TrailingSlashPolicy
+	caseInsensitive         bool
+	caseRedirect            bool
+	entry                   http.Handler // serve, wrapped with the PreMatch middlewares
 }
 
-// ServeHTTP handles incoming requests. If a route matches, it is served.
-// Otherwise, the configured notFoundHandler is invoked.
+// fallback is an absorbed mux, wrapped with the root middlewares.
+type fallback struct {
+	mux     *http.ServeMux
+	handler http.Handler
+}
+
+// ServeHTTP handles incoming requests, passing them through the PreMatch middlewares first.
 func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.entry.ServeHTTP(w, r)
+}
+
+// serve dispatches a request. If a route matches, it is served.
+// Otherwise, the configured methodNotAllowedHandler is invoked if the path matches
+// a route with another method, and the notFoundHandler is invoked if not.
+func (rt *router) serve(w http.ResponseWriter, r *http.Request) {
 	// Check if a handler exists for the given request. This requires Go 1.22+.
 	// We use mux.Handler() only to detect if a route exists. If it does,
 	// we must delegate to mux.ServeHTTP() to ensure that path values are
 	// correctly extracted and populated in the request context.
-	_, pattern := rt.mux.Handler(r)
+	h, pattern := rt.mux.Handler(r)
 	if pattern == "" {
-		// No matching pattern, so serve the 404 handler.
-		rt.notFoundHandler.ServeHTTP(w, r)
+		// No matching pattern, so try the absorbed muxes before serving the 404 handler.
+		for _, fb := range rt.fallbacks {
+			if _, pattern := fb.mux.Handler(r); pattern != "" {
+				fb.handler.ServeHTTP(w, r)
+				return
+			}
+		}
+		if rt.serveCaseInsensitive(w, r) || rt.serveTrailingSlash(w, r) {
+			return
+		}
+		if allow := rt.allowedMethods(r); len(allow) > 0 {
+			if rt.optionsHandler != nil && !slices.Contains(allow, http.MethodOptions) {
+				allow = append(allow, http.MethodOptions)
+				slices.Sort(allow)
+			}
+			w.Header().Set("Allow", strings.Join(allow, ", "))
+			if r.Method == http.MethodOptions && rt.optionsHandler != nil {
+				rt.optionsHandler.ServeHTTP(w, r)
+				return
+			}
+			rt.methodNotAllowedHandler.ServeHTTP(w, r)
+			return
+		}
+		rt.serveNotFound(w, r)
+		return
+	}
+	// The mux redirects "/users" to "/users/" by itself if only the latter matches.
+	if isMuxRedirect(h) && rt.serveTrailingSlash(w, r) {
 		return
 	}
 	// A handler was found, so let the mux handle the request.
 	rt.mux.ServeHTTP(w, r)
 }
 
+// serveNotFound serves the NotFound handler for r, scoped by Merge if any.
+func (rt *router) serveNotFound(w http.ResponseWriter, r *http.Request) {
+	for _, s := range rt.scopedNotFound {
+		if s.match(r) {
+			s.handler.ServeHTTP(w, r)
+			return
+		}
+	}
+	rt.notFoundHandler.ServeHTTP(w, r)
+}
+
+// ServeNotFound answers r with the NotFound handler of the router serving it, so that
+// a handler can decline a request matched by its pattern, e.g., when a constraint on a
+// path parameter does not hold. Outside of a router, it responds with a JSON 404.
+func ServeNotFound(w http.ResponseWriter, r *http.Request) {
+	if rc, ok := r.Context().Value(routeMetaKey).(*routeContext); ok && rc.router != nil {
+		if rt, ok := rc.router.Handler.(*router); ok {
+			rt.serveNotFound(w, r)
+			return
+		}
+	}
+	NewResponder().JSON(w, r, http.StatusNotFound, map[string]string{"error": "not found"})
+}
+
+// allowedMethods returns the registered methods that have a route matching the request's path.
+func (rt *router) allowedMethods(r *http.Request) []string {
+	var allow []string
+	probe := *r // shallow copy; only the method is changed
+	for _, method := range rt.methods {
+		probe.Method = method
+		if _, pattern := rt.mux.Handler(&probe); pattern != "" {
+			allow = append(allow, method)
+		}
+	}
+	return allow
+}
+
 // Build creates a new http.Handler from the configured routes.
-// The returned handler is immutable.
+// The returned handler is immutable. It implements RouteTable.
 func (b *Builder) Build() (http.Handler, error) {
-	mux := http.NewServeMux()
-	registered := make(map[string]struct{})
+	return b.build("")
+}
+
+// BuildGroup is like Build, but the handler serves only the routes under prefix
+// (e.g., "/api"), with their full patterns and all of their middlewares, including
+// those inherited from the enclosing groups. It is meant for embedding a part of the
+// routing tree into an existing server:
+//
+//	legacyMux.Handle("/api/", must(b.BuildGroup("/api")))
+//
+// Absorbed muxes (see AbsorbMux) are not included. It returns an error if no route is under prefix.
+func (b *Builder) BuildGroup(prefix string) (http.Handler, error) {
+	prefix = path.Join("/", prefix)
+	under := false
+	_ = b.walk(func(rt route) error {
+		under = under || underPrefix(rt.pattern, prefix)
+		return nil
+	})
+	if !under {
+		return nil, fmt.Errorf("rakuda: no route is registered under %q", prefix)
+	}
+	return b.build(prefix)
+}
+
+// underPrefix reports whether the path of pattern is prefix or under prefix.
+func underPrefix(pattern, prefix string) bool {
+	return prefix == "/" || pattern == prefix || strings.HasPrefix(pattern, prefix+"/")
+}
+
+// build builds the routes under prefix, or all routes if prefix is empty.
+func (b *Builder) build(prefix string) (http.Handler, error) {
+	if b.config.Strict {
+		if err := b.checkStrict(); err != nil {
+			return nil, err
+		}
+	}
+	if err := b.checkPatterns(prefix); err != nil {
+		return nil, err
+	}
+	if err := b.checkAliases(); err != nil {
+		return nil, err
+	}
+	if b.config.OnOverlap != nil {
+		for _, o := range b.Overlaps() {
+			b.config.OnOverlap(o)
+		}
+	}
 
-	// Middleware to inject the logger into the request context.
+	var mux Mux = http.NewServeMux()
+	if b.config.NewMux != nil {
+		mux = b.config.NewMux()
+	}
+	registered := make(map[string]RouteInfo)
+
+	// Middleware to inject the logger and the provided dependencies into the request context.
+	dependencies := maps.Clone(b.state.dependencies)
 	loggingMiddleware := func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
 			// If a logger is already in the context (e.g., from rakudatest), don't overwrite it.
-			if _, ok := r.Context().Value(loggerKey).(*slog.Logger); !ok {
+			if _, ok := ctx.Value(loggerKey).(*slog.Logger); !ok {
 				logger := b.config.Logger.With(
 					slog.String("method", r.Method),
 					slog.String("path", r.URL.Path),
 				)
-				ctx := NewContextWithLogger(r.Context(), logger)
+				ctx = NewContextWithLogger(ctx, logger)
+			}
+			if dependencies != nil {
+				ctx = context.WithValue(ctx, dependencyKey, dependencies)
+			}
+			if ctx != r.Context() {
 				r = r.WithContext(ctx)
 			}
 			next.ServeHTTP(w, r)
 		})
 	}
 
-	var traverse func(*node, string, []Middleware) error
-	traverse = func(n *node, prefix string, inheritedMiddlewares []Middleware) error {
-		// Phase 1: Collect middlewares for the current node.
-		var nodeMiddlewares []Middleware
-		for _, a := range n.actions {
-			if ma, ok := a.(middlewareAction); ok {
-				nodeMiddlewares = append(nodeMiddlewares, ma.middleware)
-			}
+	// Routes are guarded against running their actions only if a validation report endpoint can dispatch to them.
+	guardValidation := false
+	_ = b.walk(func(rt route) error {
+		if _, ok := rt.handler.(*validationReportHandler); ok {
+			guardValidation = true
 		}
+		return nil
+	})
 
-		// Combine inherited middlewares with the current node's middlewares.
-		combinedMiddlewares := append([]Middleware{}, inheritedMiddlewares...)
-		combinedMiddlewares = append(combinedMiddlewares, nodeMiddlewares...)
-
-		// Phase 2: Register handlers with the combined middleware chain.
-		for _, a := range n.actions {
-			if ha, ok := a.(handlerAction); ok {
-				fullPattern := path.Join(prefix, ha.pattern)
-				routeKey := ha.method + " " + fullPattern
-
-				if _, exists := registered[routeKey]; exists {
-					if err := b.config.OnConflict(b, routeKey); err != nil {
-						return err
-					}
-					continue // Skip registration
-				}
-				registered[routeKey] = struct{}{}
-
-				handler := ha.handler
-				for i := len(combinedMiddlewares) - 1; i >= 0; i-- {
-					handler = combinedMiddlewares[i](handler)
-				}
-				mux.Handle(routeKey, handler)
-			}
+	built := &routerRef{} // set once the router is built, for the routerAware handlers
+	var methods []string
+	var routes []RouteInfo
+	err := b.walk(func(rt route) error {
+		if prefix != "" && !underPrefix(rt.pattern, prefix) {
+			return nil
 		}
+		routeKey := rt.key()
 
-		// Phase 3: Traverse children.
-		for _, child := range n.children {
-			newPrefix := path.Join(prefix, child.pattern)
-			if err := traverse(child, newPrefix, combinedMiddlewares); err != nil {
+		if existing, exists := registered[routeKey]; exists {
+			var err error
+			if b.config.OnRouteConflict != nil {
+				err = b.config.OnRouteConflict(b, RouteConflict{Key: routeKey, Existing: existing, Duplicate: rt.info()})
+			} else {
+				err = b.config.OnConflict(b, routeKey)
+			}
+			if err != nil {
 				return err
 			}
+			return nil // Skip registration
+		}
+		registered[routeKey] = rt.info()
+		routes = append(routes, registered[routeKey])
+		if rt.method != "" && !slices.Contains(methods, rt.method) {
+			methods = append(methods, rt.method)
+			if rt.method == http.MethodGet && !slices.Contains(methods, http.MethodHead) {
+				methods = append(methods, http.MethodHead) // GET routes also serve HEAD
+			}
+		}
+
+		if b.config.Debug {
+			b.config.Logger.InfoContext(context.Background(), "route",
+				"route", routeKey,
+				"source", rt.source,
+				"middlewares", rt.describe(),
+			)
 		}
-		return nil
-	}
 
-	if err := traverse(b.node, "/", []Middleware{loggingMiddleware}); err != nil {
+		handler := rt.handler
+		if ra, ok := handler.(routerAware); ok {
+			handler = ra.bindRouter(built)
+		}
+		if guardValidation {
+			handler = validationGuard(routeKey, handler)
+		}
+		if len(rt.meta.Consumes) > 0 {
+			handler = consumesHandler(rt.meta.Consumes, handler)
+		}
+		if len(rt.meta.Produces) > 0 {
+			handler = producesHandler(rt.meta.Produces, handler)
+		}
+		if b.config.AutoHead && rt.method == http.MethodGet {
+			handler = headHandler(handler)
+		}
+		for i := len(rt.middlewares) - 1; i >= 0; i-- {
+			handler = rt.middlewares[i].wrap(handler, rt.method)
+		}
+		return handleRoute(mux, rt, withRouteMeta(rt.meta, built, withResponseState(loggingMiddleware(handler))))
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	notFoundHandler := b.notFoundHandler
-	if notFoundHandler == nil {
+	if notFoundHandler == nil && b.config.NotFoundSuggestions > 0 {
+		notFoundHandler = loggingMiddleware(notFoundWithSuggestions(routes, b.config.NotFoundSuggestions))
+	} else if notFoundHandler == nil {
 		responder := NewResponder()
 		notFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			responder.JSON(w, r, http.StatusNotFound, map[string]string{"error": "not found"})
 		})
 	}
+	if b.spaFallback != nil {
+		notFoundHandler = b.spaFallback(notFoundHandler)
+	}
+
+	methodNotAllowedHandler := b.methodNotAllowedHandler
+	if methodNotAllowedHandler == nil {
+		responder := NewResponder()
+		methodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			responder.JSON(w, r, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		})
+	}
+	slices.Sort(methods)
+	scopedNotFound := b.scopedNotFoundHandlers()
+	if b.config.MiddlewareOnNotFound {
+		notFoundHandler = loggingMiddleware(b.wrapRoot(notFoundHandler))
+		methodNotAllowedHandler = loggingMiddleware(b.wrapRoot(methodNotAllowedHandler))
+		for i, s := range scopedNotFound {
+			scopedNotFound[i].handler = loggingMiddleware(b.wrapRoot(s.handler))
+		}
+	}
+
+	rt := &router{
+		mux:                     mux,
+		methods:                 methods,
+		routes:                  routes,
+		notFoundHandler:         notFoundHandler,
+		scopedNotFound:          scopedNotFound,
+		methodNotAllowedHandler: methodNotAllowedHandler,
+		trailingSlash:           b.config.TrailingSlash,
+		caseInsensitive:         b.config.CaseInsensitivePaths || b.config.CanonicalCaseRedirect,
+		caseRedirect:            b.config.CanonicalCaseRedirect,
+	}
+	for _, fb := range b.state.fallbacks {
+		if prefix != "" {
+			break // absorbed muxes are outside of any group
+		}
+		// Only the root middlewares apply, because the mux's routes are not part of any group.
+		rt.fallbacks = append(rt.fallbacks, fallback{mux: fb, handler: loggingMiddleware(b.wrapRoot(fb))})
+	}
+	if b.config.AutoOptions {
+		// The Allow header is set by the router before the handler is called.
+		rt.optionsHandler = loggingMiddleware(b.wrapRoot(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})))
+	}
+	rt.entry = http.HandlerFunc(rt.serve)
+	for i := len(b.config.PreMatch) - 1; i >= 0; i-- {
+		rt.entry = b.config.PreMatch[i](rt.entry)
+	}
+	built.Handler = rt
+
+	b.state.built = true
+	return rt, nil
+}
+
+// wrapRoot wraps handler with the middlewares added to the root builder.
+func (b *Builder) wrapRoot(handler http.Handler) http.Handler {
+	for i := len(b.node.actions) - 1; i >= 0; i-- {
+		if ma, ok := b.node.actions[i].(middlewareAction); ok {
+			handler = ma.wrap(handler, "")
+		}
+	}
+	return handler
+}
+
+// routerAware is implemented by handlers that need the built router,
+// such as the batch handler, which dispatches sub-requests to it.
+// A builder can be built many times (e.g., after Clone, or with BuildGroup), so each
+// build registers its own copy of the handler, bound to the router of that build.
+type routerAware interface {
+	bindRouter(router *routerRef) http.Handler
+}
 
-	return &router{
-		mux:             mux,
-		notFoundHandler: notFoundHandler,
-	}, nil
+// routerRef refers to the router of a build. Handlers are registered before the router
+// exists, so it is set at the end of the build, before the router serves any request.
+type routerRef struct {
+	http.Handler
 }
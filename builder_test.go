@@ -2,6 +2,8 @@ package rakuda
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
@@ -35,6 +37,12 @@ func TestRegisterHandler(t *testing.T) {
 		{"Put", func(b *Builder) { b.Put(pattern, handler) }, http.MethodPut},
 		{"Delete", func(b *Builder) { b.Delete(pattern, handler) }, http.MethodDelete},
 		{"Patch", func(b *Builder) { b.Patch(pattern, handler) }, http.MethodPatch},
+		{"Head", func(b *Builder) { b.Head(pattern, handler) }, http.MethodHead},
+		{"Options", func(b *Builder) { b.Options(pattern, handler) }, http.MethodOptions},
+		{"Connect", func(b *Builder) { b.Connect(pattern, handler) }, http.MethodConnect},
+		{"Trace", func(b *Builder) { b.Trace(pattern, handler) }, http.MethodTrace},
+		{"Method", func(b *Builder) { b.Method("propfind", pattern, handler) }, "PROPFIND"},
+		{"Handle", func(b *Builder) { b.Handle(pattern, handler) }, ""},
 	}
 
 	for _, tt := range tests {
@@ -66,6 +74,43 @@ func TestRegisterHandler(t *testing.T) {
 	}
 }
 
+func TestHandle(t *testing.T) {
+	b := NewBuilder()
+	b.Handle("/files/{path...}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("any " + r.Method))
+	}))
+	b.Get("/files/{path...}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("get"))
+	}))
+	b.Method("PROPFIND", "/dav", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("propfind"))
+	}))
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/files/a", "get"},
+		{http.MethodDelete, "/files/a", "any DELETE"},
+		{"MKCOL", "/files/a", "any MKCOL"},
+		{"PROPFIND", "/dav", "propfind"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+			if got := rr.Body.String(); got != tt.want {
+				t.Errorf("body: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestOrderIndependence(t *testing.T) {
 	// Helper function to compare two recorders
 	assertRecordersEqual := func(t *testing.T, rr1, rr2 *httptest.ResponseRecorder) {
@@ -278,6 +323,46 @@ func TestConflictHandling(t *testing.T) {
 		}
 	})
 
+	t.Run("RouteConflictWithSources", func(t *testing.T) {
+		var got RouteConflict
+		b := NewBuilder(WithOnRouteConflict(func(b *Builder, c RouteConflict) error {
+			got = c
+			return fmt.Errorf("route %s registered at %s and %s", c.Key, c.Existing.Source, c.Duplicate.Source)
+		}))
+		b.Route("/api", func(b *Builder) {
+			b.Get("/users", handler1)
+		})
+		b.Get("/api/users", handler2)
+
+		if _, err := b.Build(); err == nil {
+			t.Fatal("Expected an error, but got nil")
+		}
+		if got.Key != "GET /api/users" {
+			t.Errorf("Key: got %q, want %q", got.Key, "GET /api/users")
+		}
+		if !strings.Contains(got.Existing.Source, "builder_test.go:") || !strings.Contains(got.Duplicate.Source, "builder_test.go:") || got.Existing.Source == got.Duplicate.Source {
+			t.Errorf("expected distinct registration sources, got %q and %q", got.Existing.Source, got.Duplicate.Source)
+		}
+		if got.Existing.Handler == got.Duplicate.Handler {
+			t.Errorf("expected distinct handlers, got %q", got.Existing.Handler)
+		}
+	})
+
+	t.Run("DefaultWarningWithSources", func(t *testing.T) {
+		var buf strings.Builder
+		b := NewBuilder(WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+		b.Get("/conflict", handler1)
+		b.Get("/conflict", handler2)
+
+		if _, err := b.Build(); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		out := buf.String()
+		if !strings.Contains(out, "route conflict") || strings.Count(out, "builder_test.go:") != 2 {
+			t.Errorf("expected a warning with both registration sources, got %q", out)
+		}
+	})
+
 	t.Run("ConflictInNestedRouteWithError", func(t *testing.T) {
 		b := NewBuilder(WithOnConflict(func(b *Builder, routeKey string) error {
 			return errors.New("nested conflict")
@@ -348,6 +433,118 @@ PUT   /v1/users/{id}
 	}
 }
 
+func TestWith(t *testing.T) {
+	trace := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Trace", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	b := NewBuilder()
+	b.Use(trace("root"))
+	b.Get("/plain", handler)
+	admin := b.With(trace("auth"))
+	admin.Get("/admin", handler)
+	admin.With(trace("audit")).Delete("/admin/users/{id}", handler)
+	admin.Route("/reports", func(b *Builder) {
+		b.Use(trace("reports"))
+		b.Get("/daily", handler)
+	})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		want   []string
+	}{
+		{http.MethodGet, "/plain", []string{"root"}},
+		{http.MethodGet, "/admin", []string{"root", "auth"}},
+		{http.MethodDelete, "/admin/users/1", []string{"root", "auth", "audit"}},
+		{http.MethodGet, "/reports/daily", []string{"root", "auth", "reports"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+			if diff := cmp.Diff(tt.want, rr.Header().Values("X-Trace")); diff != "" {
+				t.Errorf("middleware order mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("single tree", func(t *testing.T) {
+		var got []string
+		b.Walk(func(method, pattern string) { got = append(got, method+" "+pattern) })
+		want := []string{"GET /plain", "GET /admin", "DELETE /admin/users/{id}", "GET /reports/daily"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Walk() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestUseFor(t *testing.T) {
+	trace := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Trace", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	b := NewBuilder()
+	b.Use(trace("root"))
+	b.Route("/orders", func(b *Builder) {
+		b.UseFor([]string{http.MethodPost, http.MethodPut}, trace("write"))
+		b.Get("/", handler)
+		b.Post("/", handler)
+		b.With(trace("inline")).Put("/items/{id}", handler)
+		b.Handle("/legacy", handler)
+	})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		want   []string
+	}{
+		{http.MethodGet, "/orders/", []string{"root"}},
+		{http.MethodPost, "/orders/", []string{"root", "write"}},
+		{http.MethodPut, "/orders/items/1", []string{"root", "write", "inline"}},
+		{http.MethodGet, "/orders/legacy", []string{"root"}},
+		{http.MethodPost, "/orders/legacy", []string{"root", "write"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+			if diff := cmp.Diff(tt.want, rr.Header().Values("X-Trace")); diff != "" {
+				t.Errorf("middleware mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("route info", func(t *testing.T) {
+		got := map[string]int{}
+		b.WalkRoutes(func(r RouteInfo) { got[r.Method+" "+r.Pattern] = r.Middlewares })
+		want := map[string]int{"GET /orders/{$}": 1, "POST /orders/{$}": 2, "PUT /orders/items/{id}": 3, " /orders/legacy": 2}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("middlewares mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
 func TestGroup(t *testing.T) {
 	// Define handlers and middlewares
 	handler1 := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("handler1")) })
@@ -531,3 +728,632 @@ func TestNotFoundHandler(t *testing.T) {
 		}
 	})
 }
+
+func TestMethodNotAllowedHandler(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name       string
+		custom     http.Handler
+		method     string
+		path       string
+		wantStatus int
+		wantAllow  string
+		wantBody   string
+	}{
+		{
+			name:       "default",
+			method:     http.MethodPost,
+			path:       "/users/1",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantAllow:  "DELETE, GET, HEAD",
+			wantBody:   `{"error":"method not allowed"}` + "\n",
+		},
+		{
+			name: "custom",
+			custom: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				w.Write([]byte("custom"))
+			}),
+			method:     http.MethodPut,
+			path:       "/users",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantAllow:  "POST",
+			wantBody:   "custom",
+		},
+		{
+			name:       "unknown path is not found",
+			method:     http.MethodPost,
+			path:       "/unknown",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"not found"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuilder()
+			b.Post("/users", handler)
+			b.Get("/users/{id}", handler)
+			b.Delete("/users/{id}", handler)
+			if tt.custom != nil {
+				b.MethodNotAllowed(tt.custom)
+			}
+			router, err := b.Build()
+			if err != nil {
+				t.Fatalf("b.Build() failed: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if got := rr.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow: got %q, want %q", got, tt.wantAllow)
+			}
+			if diff := cmp.Diff(tt.wantBody, rr.Body.String()); diff != "" {
+				t.Errorf("body mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMiddlewareOnNotFound(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	mark := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Middleware", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	tests := []struct {
+		name    string
+		enabled bool
+		method  string
+		path    string
+		want    []string
+	}{
+		{name: "route", method: http.MethodGet, path: "/users", want: []string{"root", "group"}},
+		{name: "not found, default", method: http.MethodGet, path: "/unknown", want: nil},
+		{name: "not found", enabled: true, method: http.MethodGet, path: "/unknown", want: []string{"root"}},
+		{name: "method not allowed", enabled: true, method: http.MethodPost, path: "/users", want: []string{"root"}},
+		{name: "scoped not found", enabled: true, method: http.MethodGet, path: "/billing/unknown", want: []string{"root"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			billing := NewBuilder()
+			billing.Get("/invoices", handler)
+			billing.NotFound(http.NotFoundHandler())
+
+			b := NewBuilder(WithMiddlewareOnNotFound(tt.enabled))
+			b.Use(mark("root"))
+			b.Group(func(b *Builder) {
+				b.Use(mark("group"))
+				b.Get("/users", handler)
+			})
+			b.Merge("/billing", billing)
+			router, err := b.Build()
+			if err != nil {
+				t.Fatalf("b.Build() failed: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+			if diff := cmp.Diff(tt.want, rr.Header().Values("X-Middleware")); diff != "" {
+				t.Errorf("middlewares mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDebugMiddlewareChain(t *testing.T) {
+	nullHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	auth := func(next http.Handler) http.Handler { return next }
+	audit := func(next http.Handler) http.Handler { return next }
+
+	var buf strings.Builder
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	b := NewBuilder(WithLogger(logger), WithDebug(true))
+	b.Use(auth)
+	b.Get("/public", nullHandler)
+	b.Route("/admin", func(b *Builder) {
+		b.Use(audit)
+		b.Get("/stats", nullHandler)
+	})
+
+	t.Run("walk", func(t *testing.T) {
+		var chains [][]string
+		_ = b.walk(func(rt route) error {
+			chains = append(chains, rt.describe())
+			return nil
+		})
+		if len(chains) != 2 {
+			t.Fatalf("expected 2 routes, got %d", len(chains))
+		}
+		if got, want := len(chains[0]), 1; got != want {
+			t.Errorf("len(chain) of /public: got %d, want %d", got, want)
+		}
+		if got, want := len(chains[1]), 2; got != want {
+			t.Fatalf("len(chain) of /admin/stats: got %d, want %d", got, want)
+		}
+		for _, desc := range chains[1] {
+			if !strings.Contains(desc, "builder_test.go:") {
+				t.Errorf("expected description to include the registration location, got %q", desc)
+			}
+		}
+	})
+
+	t.Run("build logs chains", func(t *testing.T) {
+		if _, err := b.Build(); err != nil {
+			t.Fatalf("b.Build() failed: %v", err)
+		}
+		if !strings.Contains(buf.String(), "route=\"GET /admin/stats\"") {
+			t.Errorf("expected a log record for GET /admin/stats, got:\n%s", buf.String())
+		}
+	})
+
+	t.Run("print routes", func(t *testing.T) {
+		var out strings.Builder
+		PrintRoutes(&out, b)
+		if got, want := strings.Count(out.String(), "->"), 3; got != want {
+			t.Errorf("expected %d middleware lines, got %d:\n%s", want, got, out.String())
+		}
+	})
+}
+
+func TestAutoOptions(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	custom := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantAllow  string
+	}{
+		{name: "synthesized", method: http.MethodOptions, path: "/users/1", wantStatus: http.StatusNoContent, wantAllow: "DELETE, GET, HEAD, OPTIONS"},
+		{name: "registered OPTIONS route wins", method: http.MethodOptions, path: "/users", wantStatus: http.StatusOK},
+		{name: "405 lists OPTIONS", method: http.MethodPut, path: "/users/1", wantStatus: http.StatusMethodNotAllowed, wantAllow: "DELETE, GET, HEAD, OPTIONS"},
+		{name: "unknown path is not found", method: http.MethodOptions, path: "/unknown", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuilder(WithAutoOptions())
+			b.Post("/users", handler)
+			b.Options("/users", custom)
+			b.Get("/users/{id}", handler)
+			b.Delete("/users/{id}", handler)
+			router, err := b.Build()
+			if err != nil {
+				t.Fatalf("b.Build() failed: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if got := rr.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow header: got %q, want %q", got, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestHost(t *testing.T) {
+	respond := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(body)) })
+	}
+
+	b := NewBuilder()
+	b.Get("/", respond("default"))
+	b.Host("api.example.com", func(b *Builder) {
+		b.Get("/", respond("api"))
+		b.Route("/users", func(b *Builder) {
+			b.Get("/{id}", respond("api user"))
+		})
+	})
+	b.Host("admin.example.com", func(b *Builder) {
+		b.Get("/", respond("admin"))
+	})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		host       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "api host", host: "api.example.com", path: "/", wantStatus: http.StatusOK, wantBody: "api"},
+		{name: "port is ignored", host: "admin.example.com:8080", path: "/", wantStatus: http.StatusOK, wantBody: "admin"},
+		{name: "nested route", host: "api.example.com", path: "/users/1", wantStatus: http.StatusOK, wantBody: "api user"},
+		{name: "other host", host: "www.example.com", path: "/", wantStatus: http.StatusOK, wantBody: "default"},
+		{name: "route of another host", host: "www.example.com", path: "/users/1", wantStatus: http.StatusNotFound, wantBody: `{"error":"not found"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if got := rr.Body.String(); got != tt.wantBody {
+				t.Errorf("body: got %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("walk", func(t *testing.T) {
+		var got []string
+		b.Walk(func(method, pattern string) { got = append(got, method+" "+pattern) })
+		want := []string{"GET /{$}", "GET api.example.com/{$}", "GET api.example.com/users/{id}", "GET admin.example.com/{$}"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Walk() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestPrintRoutesWithOptions(t *testing.T) {
+	auth := func(next http.Handler) http.Handler { return next }
+
+	b := NewBuilder()
+	b.Get("/health", http.HandlerFunc(healthHandler))
+	b.Route("/admin", func(b *Builder) {
+		b.Use(auth)
+		b.Get("/stats", http.RedirectHandler("/", http.StatusFound))
+	})
+
+	var buf strings.Builder
+	PrintRoutesWithOptions(&buf, b, PrintRoutesOptions{Handler: true, Middlewares: true})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	if got, want := strings.Fields(lines[0]), []string{"GET", "/health", "github.com/podhmo/rakuda.healthHandler"}; !cmp.Equal(want, got) {
+		t.Errorf("line 1: got %q, want %q", got, want)
+	}
+	if got, want := strings.Fields(lines[1]), []string{"GET", "/admin/stats", "*http.redirectHandler"}; !cmp.Equal(want, got) {
+		t.Errorf("line 2: got %q, want %q", got, want)
+	}
+	if !strings.Contains(lines[2], "-> github.com/podhmo/rakuda.TestPrintRoutesWithOptions.func1 (") {
+		t.Errorf("line 3: expected the auth middleware, got %q", lines[2])
+	}
+}
+
+func TestFrozenAfterBuild(t *testing.T) {
+	noop := func(next http.Handler) http.Handler { return next }
+	tests := []struct {
+		name   string
+		modify func(b *Builder)
+	}{
+		{name: "Get", modify: func(b *Builder) { b.Get("/late", http.HandlerFunc(healthHandler)) }},
+		{name: "Use", modify: func(b *Builder) { b.Use(noop) }},
+		{name: "Route", modify: func(b *Builder) { b.Route("/late", func(b *Builder) {}) }},
+		{name: "NotFound", modify: func(b *Builder) { b.NotFound(http.NotFoundHandler()) }},
+		{name: "Group", modify: func(b *Builder) { b.Group(func(b *Builder) {}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuilder()
+			b.Get("/health", http.HandlerFunc(healthHandler))
+			if _, err := b.Build(); err != nil {
+				t.Fatalf("Build() failed: %v", err)
+			}
+
+			defer func() {
+				r := recover()
+				if r == nil {
+					t.Fatal("expected a panic, got none")
+				}
+				if msg := fmt.Sprint(r); !strings.Contains(msg, "modified after Build at") || !strings.Contains(msg, "builder_test.go") {
+					t.Errorf("unexpected panic message: %v", msg)
+				}
+			}()
+			tt.modify(b)
+		})
+	}
+
+	t.Run("child builder kept after Build", func(t *testing.T) {
+		b := NewBuilder()
+		var admin *Builder
+		b.Route("/admin", func(b *Builder) { admin = b })
+		if _, err := b.Build(); err != nil {
+			t.Fatalf("Build() failed: %v", err)
+		}
+		defer func() {
+			if recover() == nil {
+				t.Error("expected a panic, got none")
+			}
+		}()
+		admin.Get("/stats", http.HandlerFunc(healthHandler))
+	})
+}
+
+func TestClone(t *testing.T) {
+	b := NewBuilder()
+	b.Route("/api", func(b *Builder) {
+		b.Get("/users", http.HandlerFunc(healthHandler))
+	})
+	if _, err := b.Build(); err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+
+	b2 := b.Clone()
+	b2.Get("/debug", http.HandlerFunc(healthHandler))
+	b2.Route("/api", func(b *Builder) {
+		b.Get("/teams", http.HandlerFunc(healthHandler))
+	})
+	if _, err := b2.Build(); err != nil {
+		t.Fatalf("Build() of the clone failed: %v", err)
+	}
+
+	routes := func(b *Builder) []string {
+		var got []string
+		b.Walk(func(method, pattern string) { got = append(got, method+" "+pattern) })
+		return got
+	}
+	if diff := cmp.Diff([]string{"GET /api/users"}, routes(b)); diff != "" {
+		t.Errorf("original routes mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"GET /debug", "GET /api/users", "GET /api/teams"}, routes(b2)); diff != "" {
+		t.Errorf("cloned routes mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWhen(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	b := NewBuilder()
+	b.Get("/users", handler)
+	b.When(true, func(b *Builder) {
+		b.Get("/beta", handler)
+	})
+	b.When(false, func(b *Builder) {
+		b.Get("/experimental", handler)
+		b.Route("/labs", func(b *Builder) {
+			b.Get("/search", handler)
+		})
+	})
+
+	var got []string
+	b.Walk(func(method, pattern string) { got = append(got, method+" "+pattern) })
+	if diff := cmp.Diff([]string{"GET /users", "GET /beta"}, got); diff != "" {
+		t.Errorf("Walk() mismatch (-want +got):\n%s", diff)
+	}
+
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+	for path, want := range map[string]int{"/beta": http.StatusOK, "/experimental": http.StatusNotFound, "/labs/search": http.StatusNotFound} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != want {
+			t.Errorf("GET %s: got %d, want %d", path, rr.Code, want)
+		}
+	}
+
+	var buf strings.Builder
+	PrintRoutesWithOptions(&buf, b, PrintRoutesOptions{Disabled: true})
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		lines = append(lines, strings.Join(strings.Fields(line), " "))
+	}
+	want := []string{"GET /users", "GET /beta", "GET /experimental (disabled)", "GET /labs/search (disabled)"}
+	if diff := cmp.Diff(want, lines); diff != "" {
+		t.Errorf("PrintRoutesWithOptions() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBuildGroup(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Pattern))
+	})
+	mark := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Middleware", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	b := NewBuilder()
+	b.Use(mark("root"))
+	b.Get("/health", handler)
+	b.Route("/api", func(b *Builder) {
+		b.Use(mark("api"))
+		b.Get("/users", handler)
+		b.Route("/v2", func(b *Builder) {
+			b.Get("/users", handler)
+		})
+	})
+	b.Get("/apix", handler)
+
+	if _, err := b.BuildGroup("/admin"); err == nil {
+		t.Error("BuildGroup(\"/admin\"): expected an error, got nil")
+	}
+	h, err := b.BuildGroup("/api")
+	if err != nil {
+		t.Fatalf("BuildGroup() failed: %v", err)
+	}
+
+	tests := []struct {
+		path        string
+		wantStatus  int
+		middlewares []string
+	}{
+		{path: "/api/users", wantStatus: http.StatusOK, middlewares: []string{"root", "api"}},
+		{path: "/api/v2/users", wantStatus: http.StatusOK, middlewares: []string{"root", "api"}},
+		{path: "/health", wantStatus: http.StatusNotFound},
+		{path: "/apix", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.middlewares, rr.Header().Values("X-Middleware")); diff != "" {
+				t.Errorf("middlewares mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+	if got := len(h.(RouteTable).Routes()); got != 2 {
+		t.Errorf("len(Routes()): got %d, want 2", got)
+	}
+}
+
+func TestAlias(t *testing.T) {
+	trace := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Trace", "api")
+			next.ServeHTTP(w, r)
+		})
+	}
+	var overlaps []RouteOverlap
+	b := NewBuilder(WithOverlapDetection(func(o RouteOverlap) { overlaps = append(overlaps, o) }))
+	b.Alias("/v1/users/{id}", "/api/users/{id}")
+	b.Route("/api", func(b *Builder) {
+		b.Use(trace)
+		b.Get("/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("user " + r.PathValue("id")))
+		}), Meta{Name: "getUser"})
+		b.Delete("/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		b.Alias("/members/{id}", "/users/{id}")
+	})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{http.MethodGet, "/api/users/1", http.StatusOK, "user 1"},
+		{http.MethodGet, "/v1/users/1", http.StatusOK, "user 1"},
+		{http.MethodDelete, "/v1/users/1", http.StatusNoContent, ""},
+		{http.MethodGet, "/api/members/1", http.StatusOK, "user 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+			if diff := cmp.Diff(tt.wantStatus, rr.Code); diff != "" {
+				t.Errorf("status mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantBody, rr.Body.String()); diff != "" {
+				t.Errorf("body mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff("api", rr.Header().Get("X-Trace")); diff != "" {
+				t.Errorf("middleware mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("route info", func(t *testing.T) {
+		var got []string
+		b.WalkRoutes(func(r RouteInfo) {
+			got = append(got, r.Method+" "+r.Pattern+" "+r.AliasOf+" "+r.Meta.Name)
+		})
+		want := []string{
+			"GET /api/users/{id}  getUser",
+			"GET /v1/users/{id} /api/users/{id} getUser",
+			"GET /api/members/{id} /api/users/{id} getUser",
+			"DELETE /api/users/{id}  ",
+			"DELETE /v1/users/{id} /api/users/{id} ",
+			"DELETE /api/members/{id} /api/users/{id} ",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("WalkRoutes() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("print routes", func(t *testing.T) {
+		var buf strings.Builder
+		PrintRoutes(&buf, b)
+		if want := "GET     /v1/users/{id}     (alias of /api/users/{id})"; !strings.Contains(buf.String(), want) {
+			t.Errorf("PrintRoutes() output does not contain %q:\n%s", want, buf.String())
+		}
+	})
+
+	t.Run("no overlaps", func(t *testing.T) {
+		if len(overlaps) > 0 {
+			t.Errorf("unexpected overlaps: %v", overlaps)
+		}
+	})
+
+	t.Run("missing target", func(t *testing.T) {
+		b := NewBuilder()
+		b.Get("/users/{id}", http.HandlerFunc(healthHandler))
+		b.Alias("/people/{id}", "/person/{id}")
+		_, err := b.Build()
+		if err == nil || !strings.Contains(err.Error(), `alias "/people/{id}"`) || !strings.Contains(err.Error(), "builder_test.go:") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("wildcard mismatch", func(t *testing.T) {
+		for _, alias := range []string{"/v1/members/{uid}", "/users/me", "/v1/users/{id}/{extra}"} {
+			b := NewBuilder()
+			b.Get("/users/{id}", http.HandlerFunc(healthHandler))
+			b.Alias(alias, "/users/{id}")
+			_, err := b.Build()
+			if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("alias %q", alias)) || !strings.Contains(err.Error(), "but its target") {
+				t.Errorf("alias %q: unexpected error: %v", alias, err)
+			}
+		}
+	})
+}
+
+func TestServeNotFound(t *testing.T) {
+	decline := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ServeNotFound(w, r)
+	})
+
+	b := NewBuilder()
+	b.Get("/items/{id}", decline)
+	b.NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("custom"))
+	}))
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+	if rr.Code != http.StatusNotFound || rr.Body.String() != "custom" {
+		t.Errorf("got %d %q, want the NotFound handler of the builder", rr.Code, rr.Body.String())
+	}
+
+	// Outside of a router
+	rr = httptest.NewRecorder()
+	decline.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+	if rr.Code != http.StatusNotFound || rr.Body.String() != `{"error":"not found"}`+"\n" {
+		t.Errorf("got %d %q, want the default 404", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,89 @@
+package rakuda
+
+import (
+	"net/http"
+	"strings"
+)
+
+// WithCaseInsensitivePaths makes the router match unmatched requests again with the path
+// lowercased, e.g., "/About" against a "/about" route, so routes must be registered in
+// lowercase. The request is rewritten to the canonical path: literal segments take the
+// casing of the route, while path values keep the casing of the request ("/Users/AbC"
+// matches "/users/{id}" with the id "AbC"). r.RequestURI keeps the original request target.
+func WithCaseInsensitivePaths() func(*BuilderConfig) {
+	return func(c *BuilderConfig) {
+		c.CaseInsensitivePaths = true
+	}
+}
+
+// WithCanonicalCaseRedirect is like WithCaseInsensitivePaths, but redirects to the
+// canonical path instead of rewriting the request: with 301 for GET and HEAD, so that
+// search engines index a single URL, and with 308 for other methods.
+func WithCanonicalCaseRedirect() func(*BuilderConfig) {
+	return func(c *BuilderConfig) {
+		c.CanonicalCaseRedirect = true
+	}
+}
+
+// serveCaseInsensitive matches an unmatched request with its path lowercased,
+// and reports whether it served the request.
+func (rt *router) serveCaseInsensitive(w http.ResponseWriter, r *http.Request) bool {
+	if !rt.caseInsensitive {
+		return false
+	}
+	lower := strings.ToLower(r.URL.Path)
+	if lower == r.URL.Path {
+		return false
+	}
+
+	probe := *r // shallow copy; only the path is changed
+	u := *r.URL
+	u.Path = lower
+	u.RawPath = ""
+	probe.URL = &u
+	h, pattern := rt.mux.Handler(&probe)
+	if pattern == "" || isMuxRedirect(h) {
+		return false
+	}
+
+	u.Path = canonicalPath(pattern, r.URL.Path)
+	if rt.caseRedirect {
+		code := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, u.RequestURI(), code)
+		return true
+	}
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = &u
+	rt.mux.ServeHTTP(w, r2)
+	return true
+}
+
+// canonicalPath returns p with the literal segments replaced by those of the matched
+// ServeMux pattern (e.g., "GET /users/{id}"), keeping the segments matched by wildcards.
+func canonicalPath(pattern, p string) string {
+	if _, rest, ok := strings.Cut(pattern, " "); ok {
+		pattern = rest // drop the method
+	}
+	pattern = pattern[strings.Index(pattern, "/"):] // drop the host
+
+	patSegs := strings.Split(pattern[1:], "/")
+	pathSegs := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	out := make([]string, 0, len(pathSegs))
+	for i, seg := range patSegs {
+		switch {
+		case seg == "{$}":
+			out = append(out, "")
+		case seg == "" || strings.HasSuffix(seg, "...}"): // "/static/" or "{rest...}"
+			out = append(out, pathSegs[i:]...)
+		case strings.HasPrefix(seg, "{"):
+			out = append(out, pathSegs[i])
+		default:
+			out = append(out, seg)
+		}
+	}
+	return "/" + strings.Join(out, "/")
+}
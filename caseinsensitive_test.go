@@ -0,0 +1,66 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCaseInsensitivePaths(t *testing.T) {
+	newHandler := func(t *testing.T, options ...func(*BuilderConfig)) http.Handler {
+		b := NewBuilder(options...)
+		b.Get("/about", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("about " + r.URL.Path))
+		}))
+		b.Route("/users", func(b *Builder) {
+			b.Put("/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("user " + r.PathValue("id")))
+			}))
+		})
+		b.Get("/files/{path...}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("file " + r.PathValue("path")))
+		}))
+		h, err := b.Build()
+		if err != nil {
+			t.Fatalf("Build() failed: %v", err)
+		}
+		return h
+	}
+
+	tests := []struct {
+		name         string
+		options      []func(*BuilderConfig)
+		method       string
+		target       string
+		wantStatus   int
+		wantBody     string
+		wantLocation string
+	}{
+		{name: "disabled", method: http.MethodGet, target: "/About", wantStatus: http.StatusNotFound},
+		{name: "rewrite", options: []func(*BuilderConfig){WithCaseInsensitivePaths()}, method: http.MethodGet, target: "/About", wantStatus: http.StatusOK, wantBody: "about /about"},
+		{name: "path values keep their casing", options: []func(*BuilderConfig){WithCaseInsensitivePaths()}, method: http.MethodPut, target: "/USERS/AbC", wantStatus: http.StatusOK, wantBody: "user AbC"},
+		{name: "catch-all keeps its casing", options: []func(*BuilderConfig){WithCaseInsensitivePaths()}, method: http.MethodGet, target: "/Files/Docs/README.md", wantStatus: http.StatusOK, wantBody: "file Docs/README.md"},
+		{name: "no match", options: []func(*BuilderConfig){WithCaseInsensitivePaths()}, method: http.MethodGet, target: "/Missing", wantStatus: http.StatusNotFound},
+		{name: "redirect", options: []func(*BuilderConfig){WithCanonicalCaseRedirect()}, method: http.MethodGet, target: "/About?ref=ad", wantStatus: http.StatusMovedPermanently, wantLocation: "/about?ref=ad"},
+		{name: "redirect keeps the method", options: []func(*BuilderConfig){WithCanonicalCaseRedirect()}, method: http.MethodPut, target: "/Users/AbC", wantStatus: http.StatusPermanentRedirect, wantLocation: "/users/AbC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			newHandler(t, tt.options...).ServeHTTP(rr, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status code: got %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" {
+				if got := rr.Body.String(); got != tt.wantBody {
+					t.Errorf("body: got %q, want %q", got, tt.wantBody)
+				}
+			}
+			if got := rr.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location: got %q, want %q", got, tt.wantLocation)
+			}
+		})
+	}
+}
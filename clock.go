@@ -0,0 +1,53 @@
+package rakuda
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is a source of the current time. Handlers and middlewares call Now(ctx) instead of
+// time.Now, so that tests can install a fixed clock with NewContextWithClock
+// (or rakudamiddleware.Clock) and get deterministic Retry-After, expiry, and timestamp values.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to the Clock interface.
+type ClockFunc func() time.Time
+
+// Now implements Clock.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is the Clock that returns time.Now. It is used when no clock is in the context.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// FixedClock returns a Clock that always returns t, for deterministic tests.
+func FixedClock(t time.Time) Clock {
+	return ClockFunc(func() time.Time { return t })
+}
+
+// ClockIn returns a Clock that returns the time of clock in loc, e.g., so that dates are
+// rendered in the time zone of a tenant. Durations and comparisons are not affected.
+func ClockIn(clock Clock, loc *time.Location) Clock {
+	return ClockFunc(func() time.Time { return clock.Now().In(loc) })
+}
+
+// NewContextWithClock returns a new context with the provided Clock.
+func NewContextWithClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockKey, clock)
+}
+
+// ClockFromContext retrieves the Clock from the context, or SystemClock if it is not found.
+func ClockFromContext(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(clockKey).(Clock); ok {
+		return clock
+	}
+	return SystemClock
+}
+
+// Now returns the current time of the Clock in the context.
+func Now(ctx context.Context) time.Time {
+	return ClockFromContext(ctx).Now()
+}
@@ -0,0 +1,39 @@
+package rakuda
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClock(t *testing.T) {
+	t.Run("system clock by default", func(t *testing.T) {
+		before := time.Now()
+		got := Now(context.Background())
+		if got.Before(before) || got.After(time.Now()) {
+			t.Errorf("Now(): got %v, want the current time", got)
+		}
+	})
+
+	t.Run("fixed clock", func(t *testing.T) {
+		want := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		ctx := NewContextWithClock(context.Background(), FixedClock(want))
+		if got := Now(ctx); !got.Equal(want) {
+			t.Errorf("Now(): got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("time zone", func(t *testing.T) {
+		base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		tokyo := time.FixedZone("JST", 9*60*60)
+		ctx := NewContextWithClock(context.Background(), ClockIn(FixedClock(base), tokyo))
+
+		got := Now(ctx)
+		if !got.Equal(base) {
+			t.Errorf("Now(): got %v, want the same instant as %v", got, base)
+		}
+		if got, want := got.Format(time.RFC3339), "2024-05-01T21:00:00+09:00"; got != want {
+			t.Errorf("Format(): got %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package rakuda
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Code is a canonical error code, compatible with the gRPC status codes.
+// It allows services sharing an error taxonomy between gRPC and HTTP surfaces
+// to map errors to HTTP statuses consistently.
+type Code int
+
+// Canonical error codes. The numeric values match the gRPC status codes.
+const (
+	CodeOK                 Code = 0
+	CodeCanceled           Code = 1
+	CodeUnknown            Code = 2
+	CodeInvalidArgument    Code = 3
+	CodeDeadlineExceeded   Code = 4
+	CodeNotFound           Code = 5
+	CodeAlreadyExists      Code = 6
+	CodePermissionDenied   Code = 7
+	CodeResourceExhausted  Code = 8
+	CodeFailedPrecondition Code = 9
+	CodeAborted            Code = 10
+	CodeOutOfRange         Code = 11
+	CodeUnimplemented      Code = 12
+	CodeInternal           Code = 13
+	CodeUnavailable        Code = 14
+	CodeDataLoss           Code = 15
+	CodeUnauthenticated    Code = 16
+)
+
+// StatusClientClosedRequest is the non-standard status code used for canceled requests.
+const StatusClientClosedRequest = 499
+
+// codeTable maps each canonical code to its name and HTTP status.
+// The mapping follows the one used by grpc-gateway.
+var codeTable = map[Code]struct {
+	name   string
+	status int
+}{
+	CodeOK:                 {"OK", http.StatusOK},
+	CodeCanceled:           {"CANCELED", StatusClientClosedRequest},
+	CodeUnknown:            {"UNKNOWN", http.StatusInternalServerError},
+	CodeInvalidArgument:    {"INVALID_ARGUMENT", http.StatusBadRequest},
+	CodeDeadlineExceeded:   {"DEADLINE_EXCEEDED", http.StatusGatewayTimeout},
+	CodeNotFound:           {"NOT_FOUND", http.StatusNotFound},
+	CodeAlreadyExists:      {"ALREADY_EXISTS", http.StatusConflict},
+	CodePermissionDenied:   {"PERMISSION_DENIED", http.StatusForbidden},
+	CodeResourceExhausted:  {"RESOURCE_EXHAUSTED", http.StatusTooManyRequests},
+	CodeFailedPrecondition: {"FAILED_PRECONDITION", http.StatusBadRequest},
+	CodeAborted:            {"ABORTED", http.StatusConflict},
+	CodeOutOfRange:         {"OUT_OF_RANGE", http.StatusBadRequest},
+	CodeUnimplemented:      {"UNIMPLEMENTED", http.StatusNotImplemented},
+	CodeInternal:           {"INTERNAL", http.StatusInternalServerError},
+	CodeUnavailable:        {"UNAVAILABLE", http.StatusServiceUnavailable},
+	CodeDataLoss:           {"DATA_LOSS", http.StatusInternalServerError},
+	CodeUnauthenticated:    {"UNAUTHENTICATED", http.StatusUnauthorized},
+}
+
+// String returns the canonical name of the code (e.g., "NOT_FOUND").
+func (c Code) String() string {
+	if entry, ok := codeTable[c]; ok {
+		return entry.name
+	}
+	return fmt.Sprintf("Code(%d)", int(c))
+}
+
+// HTTPStatus returns the HTTP status code corresponding to the code.
+// Unknown codes map to 500 Internal Server Error.
+func (c Code) HTTPStatus() int {
+	if entry, ok := codeTable[c]; ok {
+		return entry.status
+	}
+	return http.StatusInternalServerError
+}
+
+// CodeFromHTTPStatus returns the canonical code that best corresponds to the HTTP status.
+func CodeFromHTTPStatus(status int) Code {
+	switch status {
+	case http.StatusOK:
+		return CodeOK
+	case StatusClientClosedRequest:
+		return CodeCanceled
+	case http.StatusBadRequest:
+		return CodeInvalidArgument
+	case http.StatusUnauthorized:
+		return CodeUnauthenticated
+	case http.StatusForbidden:
+		return CodePermissionDenied
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeAborted
+	case http.StatusTooManyRequests:
+		return CodeResourceExhausted
+	case http.StatusNotImplemented:
+		return CodeUnimplemented
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeDeadlineExceeded
+	}
+	switch {
+	case status >= 200 && status < 300:
+		return CodeOK
+	case status >= 400 && status < 500:
+		return CodeFailedPrecondition
+	case status >= 500:
+		return CodeInternal
+	}
+	return CodeUnknown
+}
+
+// CodeError creates a new APIError from a canonical code, capturing the caller's position.
+// The HTTP status of the error is derived from the code.
+func CodeError(code Code, err error) *APIError {
+	apiErr := NewAPIErrorWithDepth(code.HTTPStatus(), err, 2)
+	apiErr.code = code
+	return apiErr
+}
+
+// CodeOf returns the canonical code of the error.
+// For nil, it returns CodeOK. For errors created with CodeError, it returns the original code.
+// For other errors with a StatusCode() int method, the code is derived from the status.
+// Otherwise, it returns CodeUnknown.
+func CodeOf(err error) Code {
+	if err == nil {
+		return CodeOK
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code()
+	}
+	var sc interface{ StatusCode() int }
+	if errors.As(err, &sc) {
+		return CodeFromHTTPStatus(sc.StatusCode())
+	}
+	return CodeUnknown
+}
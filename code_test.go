@@ -0,0 +1,61 @@
+package rakuda
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCodeError(t *testing.T) {
+	tests := []struct {
+		code       Code
+		wantStatus int
+		wantName   string
+	}{
+		{CodeNotFound, http.StatusNotFound, "NOT_FOUND"},
+		{CodePermissionDenied, http.StatusForbidden, "PERMISSION_DENIED"},
+		{CodeResourceExhausted, http.StatusTooManyRequests, "RESOURCE_EXHAUSTED"},
+		{CodeUnauthenticated, http.StatusUnauthorized, "UNAUTHENTICATED"},
+		{CodeCanceled, StatusClientClosedRequest, "CANCELED"},
+		{Code(99), http.StatusInternalServerError, "Code(99)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantName, func(t *testing.T) {
+			err := CodeError(tt.code, errors.New("boom"))
+			if got := err.StatusCode(); got != tt.wantStatus {
+				t.Errorf("StatusCode(): got %d, want %d", got, tt.wantStatus)
+			}
+			if got := tt.code.String(); got != tt.wantName {
+				t.Errorf("String(): got %q, want %q", got, tt.wantName)
+			}
+			if got := CodeOf(fmt.Errorf("wrapped: %w", err)); got != tt.code {
+				t.Errorf("CodeOf(): got %v, want %v", got, tt.code)
+			}
+			if err.PC() == 0 {
+				t.Error("expected the caller's position to be captured")
+			}
+		})
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"nil", nil, CodeOK},
+		{"plain error", errors.New("boom"), CodeUnknown},
+		{"api error", NewAPIError(http.StatusNotFound, errors.New("boom")), CodeNotFound},
+		{"status coder", NewErrors(http.StatusUnprocessableEntity, errors.New("boom")), CodeFailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOf(tt.err); got != tt.want {
+				t.Errorf("CodeOf(): got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
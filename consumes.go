@@ -0,0 +1,107 @@
+package rakuda
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// UnsupportedMediaTypeError reports that the Content-Type of a request is not accepted
+// by the route (415 Unsupported Media Type).
+type UnsupportedMediaTypeError struct {
+	// ContentType is the media type sent by the client, without parameters.
+	ContentType string
+	// Supported are the media types accepted by the route.
+	Supported []string
+}
+
+// Error implements the error interface.
+func (e *UnsupportedMediaTypeError) Error() string {
+	return fmt.Sprintf("unsupported media type %q, supported: %s", e.ContentType, strings.Join(e.Supported, ", "))
+}
+
+// StatusCode returns 415 Unsupported Media Type, allowing it to work with the lift handler.
+func (e *UnsupportedMediaTypeError) StatusCode() int {
+	return http.StatusUnsupportedMediaType
+}
+
+// MarshalJSON renders the error with the supported media types, so that clients can correct the request.
+func (e *UnsupportedMediaTypeError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"error":     "unsupported media type",
+		"supported": e.Supported,
+	})
+}
+
+// Consumes returns route metadata that restricts the Content-Type of request bodies
+// to the given media types. Patterns such as "application/*" are allowed.
+// Build wraps the route with a check that responds with 415 and an
+// *UnsupportedMediaTypeError for other types, and fails if a media type is malformed.
+// Requests without a body are not checked.
+//
+//	b.Post("/users", createUser, rakuda.Consumes("application/json"))
+//
+// For a whole group, use RequireContentType as a middleware instead.
+func Consumes(mediaTypes ...string) Meta {
+	return Meta{Consumes: mediaTypes}
+}
+
+// RequireContentType returns a middleware that enforces the same check as Consumes,
+// for use with Use on a group. It panics if a media type is malformed.
+func RequireContentType(mediaTypes ...string) Middleware {
+	for _, mediaType := range mediaTypes {
+		if !validMediaType(mediaType) {
+			panic(fmt.Sprintf("rakuda: RequireContentType: invalid media type %q", mediaType))
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return consumesHandler(mediaTypes, next)
+	}
+}
+
+// consumesHandler responds with 415 to requests whose body is not one of mediaTypes.
+func consumesHandler(mediaTypes []string, next http.Handler) http.Handler {
+	responder := NewResponder()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength == 0 && (r.Body == nil || r.Body == http.NoBody) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		for _, mediaType := range mediaTypes {
+			if matchMediaType(mediaType, contentType) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		responder.Error(w, r, http.StatusUnsupportedMediaType, &UnsupportedMediaTypeError{ContentType: contentType, Supported: mediaTypes})
+	})
+}
+
+// validMediaType reports whether mediaType is a media type without parameters, such as
+// "application/json", or a pattern "type/*" or "*/*".
+func validMediaType(mediaType string) bool {
+	typ, subtype, ok := strings.Cut(mediaType, "/")
+	if !ok || typ == "" || subtype == "" || (typ == "*" && subtype != "*") {
+		return false
+	}
+	parsed, params, err := mime.ParseMediaType(mediaType)
+	return err == nil && len(params) == 0 && strings.EqualFold(parsed, mediaType)
+}
+
+// matchMediaType reports whether mediaType matches pattern, which may be "*/*" or "type/*".
+func matchMediaType(pattern, mediaType string) bool {
+	if mediaType == "" {
+		return false
+	}
+	if pattern == "*/*" || strings.EqualFold(pattern, mediaType) {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		typ, _, _ := strings.Cut(mediaType, "/")
+		return strings.EqualFold(prefix, typ)
+	}
+	return false
+}
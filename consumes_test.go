@@ -0,0 +1,62 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConsumes(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	b := NewBuilder()
+	b.Post("/users", handler, Consumes("application/json"))
+	b.Post("/uploads", handler, Consumes("image/*"))
+	b.Route("/forms", func(b *Builder) {
+		b.Use(RequireContentType("application/x-www-form-urlencoded"))
+		b.Post("/", handler)
+	})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantBody    string
+	}{
+		{name: "accepted", path: "/users", contentType: "application/json; charset=utf-8", body: "{}", wantStatus: http.StatusCreated},
+		{name: "rejected", path: "/users", contentType: "text/plain", body: "hello", wantStatus: http.StatusUnsupportedMediaType, wantBody: `{"error":"unsupported media type","supported":["application/json"]}` + "\n"},
+		{name: "missing content type", path: "/users", body: "{}", wantStatus: http.StatusUnsupportedMediaType, wantBody: `{"error":"unsupported media type","supported":["application/json"]}` + "\n"},
+		{name: "no body is not checked", path: "/users", wantStatus: http.StatusCreated},
+		{name: "wildcard", path: "/uploads", contentType: "image/png", body: "png", wantStatus: http.StatusCreated},
+		{name: "group middleware", path: "/forms/", contentType: "application/json", body: "{}", wantStatus: http.StatusUnsupportedMediaType, wantBody: `{"error":"unsupported media type","supported":["application/x-www-form-urlencoded"]}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			if tt.body == "" {
+				req = httptest.NewRequest(http.MethodPost, tt.path, nil)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+				t.Errorf("body: got %q, want %q", rr.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
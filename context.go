@@ -11,7 +11,25 @@ type contextKey string
 
 // Keys for context values.
 const (
-	loggerKey = contextKey("logger")
+	loggerKey        = contextKey("logger")
+	valuesKey        = contextKey("values")
+	routeMetaKey     = contextKey("routeMeta")
+	batchKey         = contextKey("batch")
+	principalKey     = contextKey("principal")
+	flashKey         = contextKey("flash")
+	fieldsKey        = contextKey("fields")
+	variantKey       = contextKey("variant")
+	tenantKey        = contextKey("tenant")
+	timingsKey       = contextKey("timings")
+	negotiatedKey    = contextKey("negotiated")
+	clockKey         = contextKey("clock")
+	dependencyKey    = contextKey("dependency")
+	responderKey     = contextKey("responder")
+	chunkedKey       = contextKey("chunked")
+	flagsKey         = contextKey("flags")
+	localeKey        = contextKey("locale")
+	probeKey         = contextKey("validationProbe")
+	responseStateKey = contextKey("responseState")
 )
 
 var logFallbackOnce sync.Once
@@ -0,0 +1,64 @@
+package rakuda
+
+import "net/http"
+
+// Controller is implemented by types that group the handlers of a domain,
+// so that larger applications can organize handlers per package with uniform wiring.
+type Controller interface {
+	// Routes registers the controller's routes, relative to the mount prefix.
+	Routes(b *Builder)
+}
+
+// Conventional handler methods. If a controller implements any of them,
+// MountController registers them as REST routes (see Builder.Resource).
+type (
+	indexer interface {
+		Index(http.ResponseWriter, *http.Request)
+	}
+	shower interface {
+		Show(http.ResponseWriter, *http.Request)
+	}
+	creator interface {
+		Create(http.ResponseWriter, *http.Request)
+	}
+	updater interface {
+		Update(http.ResponseWriter, *http.Request)
+	}
+	deleter interface {
+		Delete(http.ResponseWriter, *http.Request)
+	}
+)
+
+// MountController mounts a controller under the given prefix.
+//
+// Conventional methods (Index, Show, Create, Update, Delete with the
+// http.HandlerFunc signature) are registered as REST routes at the prefix,
+// then ctrl.Routes is called with a builder scoped to the prefix, so that
+// the controller can register additional routes and middlewares.
+// Middlewares registered in Routes do not apply to the conventional routes.
+func MountController(b *Builder, prefix string, ctrl Controller) {
+	source := callerSource(2)
+
+	var res Resource
+	if c, ok := ctrl.(indexer); ok {
+		res.Index = http.HandlerFunc(c.Index)
+	}
+	if c, ok := ctrl.(shower); ok {
+		res.Show = http.HandlerFunc(c.Show)
+	}
+	if c, ok := ctrl.(creator); ok {
+		res.Create = http.HandlerFunc(c.Create)
+	}
+	if c, ok := ctrl.(updater); ok {
+		res.Update = http.HandlerFunc(c.Update)
+	}
+	if c, ok := ctrl.(deleter); ok {
+		res.Delete = http.HandlerFunc(c.Delete)
+	}
+	for _, r := range resourceRoutes(prefix, res) {
+		b.addHandler(r.method, r.pattern, r.handler, source)
+	}
+
+	b.Route(prefix, ctrl.Routes)
+	b.node.children[len(b.node.children)-1].source = source
+}
@@ -0,0 +1,54 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type userController struct{}
+
+func (userController) Index(w http.ResponseWriter, r *http.Request) { w.Write([]byte("index")) }
+func (userController) Show(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("show:" + r.PathValue("id")))
+}
+
+func (userController) Routes(b *Builder) {
+	b.Get("/me", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("me")) }))
+}
+
+func TestMountController(t *testing.T) {
+	b := NewBuilder()
+	MountController(b, "/users", userController{})
+
+	var got [][2]string
+	b.Walk(func(method, pattern string) {
+		got = append(got, [2]string{method, pattern})
+	})
+	want := [][2]string{
+		{http.MethodGet, "/users"},
+		{http.MethodGet, "/users/{id}"},
+		{http.MethodGet, "/users/me"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Walk() mismatch (-want +got):\n%s", diff)
+	}
+
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+	for path, wantBody := range map[string]string{
+		"/users":    "index",
+		"/users/42": "show:42",
+		"/users/me": "me",
+	} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Body.String() != wantBody {
+			t.Errorf("GET %s: got %q, want %q", path, rr.Body.String(), wantBody)
+		}
+	}
+}
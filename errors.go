@@ -0,0 +1,89 @@
+package rakuda
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Errors collects multiple errors that are reported together, such as
+// business-rule validation failures outside the binding package.
+// The Responder renders it as a list of messages with an overall status code.
+type Errors struct {
+	Status int
+	Errors []error
+}
+
+// NewErrors collects errors into a single *Errors with the given status code.
+// It filters out nil errors. If no errors are found, it returns nil.
+// Errors created with errors.Join are flattened into the list.
+func NewErrors(statusCode int, errs ...error) error {
+	var collected []error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			collected = append(collected, joined.Unwrap()...)
+			continue
+		}
+		collected = append(collected, err)
+	}
+
+	if len(collected) == 0 {
+		return nil
+	}
+	return &Errors{Status: statusCode, Errors: collected}
+}
+
+// Error implements the error interface.
+func (e *Errors) Error() string {
+	var b strings.Builder
+	for i, err := range e.Errors {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// StatusCode returns the overall status code, allowing it to work with the lift handler.
+// If no status is set, it defaults to 400 Bad Request.
+func (e *Errors) StatusCode() int {
+	if e.Status == 0 {
+		return http.StatusBadRequest
+	}
+	return e.Status
+}
+
+// Unwrap supports errors.Is and errors.As.
+func (e *Errors) Unwrap() []error {
+	return e.Errors
+}
+
+// MarshalJSON renders the errors as {"errors": [{"message": "..."}, ...]}.
+// If an item has its own StatusCode() int method, it is included as "status".
+func (e *Errors) MarshalJSON() ([]byte, error) {
+	return json.Marshal(errorList(e.Errors))
+}
+
+// errorItem is the JSON representation of a single error in an error list.
+type errorItem struct {
+	Message string `json:"message"`
+	Status  int    `json:"status,omitempty"`
+}
+
+func errorList(errs []error) map[string][]errorItem {
+	items := make([]errorItem, 0, len(errs))
+	for _, err := range errs {
+		item := errorItem{Message: err.Error()}
+		var sc interface{ StatusCode() int }
+		if errors.As(err, &sc) {
+			item.Status = sc.StatusCode()
+		}
+		items = append(items, item)
+	}
+	return map[string][]errorItem{"errors": items}
+}
@@ -0,0 +1,82 @@
+package rakuda
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewErrors(t *testing.T) {
+	t.Run("nil when empty", func(t *testing.T) {
+		if err := NewErrors(http.StatusUnprocessableEntity, nil, nil); err != nil {
+			t.Errorf("expected nil, got %v", err)
+		}
+	})
+
+	t.Run("flattens errors.Join", func(t *testing.T) {
+		err := NewErrors(0, errors.New("a"), errors.Join(errors.New("b"), errors.New("c")))
+		var errs *Errors
+		if !errors.As(err, &errs) {
+			t.Fatalf("expected *Errors, got %T", err)
+		}
+		if got, want := len(errs.Errors), 3; got != want {
+			t.Errorf("len(Errors): got %d, want %d", got, want)
+		}
+		if got, want := errs.StatusCode(), http.StatusBadRequest; got != want {
+			t.Errorf("StatusCode(): got %d, want %d", got, want)
+		}
+		if got, want := errs.Error(), "a, b, c"; got != want {
+			t.Errorf("Error(): got %q, want %q", got, want)
+		}
+	})
+}
+
+func TestResponder_Error_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		err        error
+		wantBody   string
+	}{
+		{
+			name:       "rakuda.Errors",
+			statusCode: http.StatusUnprocessableEntity,
+			err: NewErrors(http.StatusUnprocessableEntity,
+				errors.New("name is taken"),
+				NewAPIError(http.StatusConflict, errors.New("email is taken")),
+			),
+			wantBody: `{"errors":[{"message":"name is taken"},{"message":"email is taken","status":409}]}` + "\n",
+		},
+		{
+			name:       "errors.Join",
+			statusCode: http.StatusBadRequest,
+			err:        errors.Join(errors.New("a"), errors.New("b")),
+			wantBody:   `{"errors":[{"message":"a"},{"message":"b"}]}` + "\n",
+		},
+		{
+			name:       "5xx is not exposed",
+			statusCode: http.StatusInternalServerError,
+			err:        NewErrors(http.StatusInternalServerError, errors.New("db down"), errors.New("cache down")),
+			wantBody:   `{"error":"Internal Server Error"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rr := httptest.NewRecorder()
+
+			NewResponder().Error(rr, req, tt.statusCode, tt.err)
+
+			if rr.Code != tt.statusCode {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.statusCode)
+			}
+			if diff := cmp.Diff(tt.wantBody, rr.Body.String()); diff != "" {
+				t.Errorf("body mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package rakuda
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// PreconditionFailedError reports that the If-Match precondition of a request
+// did not match the current version of the resource (412 Precondition Failed).
+type PreconditionFailedError struct {
+	// Expected are the entity tags sent by the client.
+	Expected []string
+	// Current is the entity tag of the current version of the resource.
+	Current string
+}
+
+// Error implements the error interface.
+func (e *PreconditionFailedError) Error() string {
+	return fmt.Sprintf("precondition failed: expected %s, current %s", strings.Join(e.Expected, ", "), e.Current)
+}
+
+// StatusCode returns 412 Precondition Failed, allowing it to work with the lift handler.
+func (e *PreconditionFailedError) StatusCode() int {
+	return http.StatusPreconditionFailed
+}
+
+// MarshalJSON renders the error with the current entity tag, so that clients can refetch and retry.
+func (e *PreconditionFailedError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{
+		"error": "precondition failed",
+		"etag":  e.Current,
+	})
+}
+
+// CheckIfMatch compares the entity tags of an If-Match header (see binding.IfMatch)
+// with the current entity tag of the resource, for optimistic concurrency control
+// on PUT/PATCH endpoints. It returns nil if there is no precondition or if it matches;
+// otherwise, it returns a *PreconditionFailedError.
+//
+// As required for If-Match, the comparison is strong: weak tags never match.
+// "*" matches any existing resource (current is not empty).
+func CheckIfMatch(expected []string, current string) error {
+	if len(expected) == 0 {
+		return nil
+	}
+	for _, tag := range expected {
+		if tag == "*" && current != "" {
+			return nil
+		}
+		if tag == current && !strings.HasPrefix(tag, "W/") {
+			return nil
+		}
+	}
+	return &PreconditionFailedError{Expected: expected, Current: current}
+}
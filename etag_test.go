@@ -0,0 +1,64 @@
+package rakuda
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/podhmo/rakuda/binding"
+)
+
+func TestCheckIfMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected []string
+		current  string
+		wantErr  bool
+	}{
+		{name: "no precondition", expected: nil, current: `"v1"`},
+		{name: "match", expected: []string{`"v0"`, `"v1"`}, current: `"v1"`},
+		{name: "mismatch", expected: []string{`"v0"`}, current: `"v1"`, wantErr: true},
+		{name: "weak never matches", expected: []string{`W/"v1"`}, current: `W/"v1"`, wantErr: true},
+		{name: "wildcard", expected: []string{"*"}, current: `"v1"`},
+		{name: "wildcard without resource", expected: []string{"*"}, current: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckIfMatch(tt.expected, tt.current)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckIfMatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var pErr *PreconditionFailedError
+			if err != nil && !errors.As(err, &pErr) {
+				t.Errorf("expected *PreconditionFailedError, got %T", err)
+			}
+		})
+	}
+}
+
+func TestCheckIfMatch_Lift(t *testing.T) {
+	current := `"v2"`
+	handler := Lift(NewResponder(), func(r *http.Request) (map[string]string, error) {
+		var expected []string
+		if err := binding.Join(binding.IfMatch(binding.New(r, r.PathValue), &expected, binding.Optional)); err != nil {
+			return nil, err
+		}
+		if err := CheckIfMatch(expected, current); err != nil {
+			return nil, err
+		}
+		return map[string]string{"status": "updated"}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/items/1", nil)
+	req.Header.Set("If-Match", `"v1"`)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("status code: got %d, want %d", rr.Code, http.StatusPreconditionFailed)
+	}
+	if got, want := rr.Body.String(), `{"error":"precondition failed","etag":"\"v2\""}`+"\n"; got != want {
+		t.Errorf("body: got %q, want %q", got, want)
+	}
+}
@@ -14,16 +14,17 @@ import (
 	"github.com/podhmo/rakuda/binding"
 )
 
-var responder = rakuda.NewResponder()
-
 // handleRoot is a simple handler that returns a JSON response.
+// The responder is provided to the builder in newRouter (see rakuda.Provide).
 func handleRoot(w http.ResponseWriter, r *http.Request) {
+	responder := rakuda.Inject[*rakuda.Responder](r)
 	responder.JSON(w, r, http.StatusOK, map[string]string{"message": "hello world"})
 }
 
 // handleHello is a handler that uses a path parameter.
 func handleHello(w http.ResponseWriter, r *http.Request) {
 	name := r.PathValue("name")
+	responder := rakuda.Inject[*rakuda.Responder](r)
 	responder.JSON(w, r, http.StatusOK, map[string]string{"message": fmt.Sprintf("hello %s", name)})
 }
 
@@ -70,6 +71,8 @@ func actionGist(r *http.Request) (Gist, error) {
 
 func newRouter() *rakuda.Builder {
 	builder := rakuda.NewBuilder()
+	responder := rakuda.NewResponder()
+	rakuda.Provide(builder, responder)
 
 	// 1. A simple handler that returns a JSON response.
 	builder.Get("/", http.HandlerFunc(handleRoot))
@@ -95,12 +98,16 @@ func main() {
 func run() error {
 	var (
 		proutes = flag.Bool("proutes", false, "print routes")
+		format  = flag.String("format", "text", "format of -proutes: text, json, or yaml")
 		port    = flag.Int("port", 8080, "port")
 	)
 	flag.Parse()
 
 	builder := newRouter()
 	if *proutes {
+		if *format != "text" {
+			return rakuda.ExportRoutes(os.Stdout, builder, *format)
+		}
 		rakuda.PrintRoutes(os.Stdout, builder)
 		return nil
 	}
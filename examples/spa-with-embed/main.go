@@ -46,19 +46,11 @@ func newRouter() *rakuda.Builder {
 	if err != nil {
 		log.Fatalf("failed to create sub filesystem: %v", err)
 	}
-	fileServer := http.FileServer(http.FS(staticFS))
-	builder.Get("/static/{path...}", http.StripPrefix("/static/", fileServer))
-
-	// Serve index.html at root
-	builder.Get("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		data, err := staticFiles.ReadFile("static/index.html")
-		if err != nil {
-			http.Error(w, "Not Found", http.StatusNotFound)
-			return
-		}
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		w.Write(data)
-	}))
+	// Assets are served with FileServer, which serves precompressed siblings (e.g., app.js.gz) when they are embedded.
+	builder.Static("/static", staticFS)
+	// index.html is served to browsers at the root and for client-side routes (e.g., /dashboard),
+	// while unknown API paths still return JSON 404s.
+	builder.SPAFallback(staticFS, "index.html")
 
 	// API routes
 	builder.Route("/api", func(api *rakuda.Builder) {
@@ -242,12 +234,16 @@ func main() {
 func run() error {
 	var (
 		proutes = flag.Bool("proutes", false, "print routes")
+		format  = flag.String("format", "text", "format of -proutes: text, json, or yaml")
 		port    = flag.Int("port", 8080, "port")
 	)
 	flag.Parse()
 
 	builder := newRouter()
 	if *proutes {
+		if *format != "text" {
+			return rakuda.ExportRoutes(os.Stdout, builder, *format)
+		}
 		rakuda.PrintRoutes(os.Stdout, builder)
 		return nil
 	}
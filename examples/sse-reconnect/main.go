@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/podhmo/rakuda"
+	"github.com/podhmo/rakuda/rakudamiddleware"
+)
+
+const page = `<!DOCTYPE html>
+<html>
+<body>
+<ul id="events"></ul>
+<script>
+// EventSource reconnects automatically and sends the last received id as Last-Event-ID,
+// so the server replays the ticks published while the connection was down.
+const source = new EventSource("/events");
+source.addEventListener("tick", (e) => {
+  const li = document.createElement("li");
+  li.textContent = e.lastEventId + ": " + e.data;
+  document.getElementById("events").appendChild(li);
+});
+</script>
+</body>
+</html>`
+
+func newRouter(hub *rakuda.SSEHub) http.Handler {
+	builder := rakuda.NewBuilder()
+	builder.Use(rakudamiddleware.Recovery)
+
+	builder.Get("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, page)
+	}))
+	builder.Get("/events", hub)
+
+	handler, err := builder.Build()
+	if err != nil {
+		panic(err) // In a real app, you'd handle this more gracefully.
+	}
+	return handler
+}
+
+func main() {
+	hub := rakuda.NewSSEHub(rakuda.NewMemoryEventStore(100))
+	handler := newRouter(hub)
+	port := 8080
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	go func() {
+		for t := range time.Tick(time.Second) {
+			if err := hub.Publish(context.Background(), "tick", map[string]string{"time": t.Format(time.RFC3339)}); err != nil {
+				logger.ErrorContext(context.Background(), "publish failed", "error", err)
+			}
+		}
+	}()
+
+	logger.InfoContext(context.Background(), "server starting", "port", port)
+	logger.InfoContext(context.Background(), "Open http://localhost:8080/ and restart the connection (e.g., toggle offline mode) to see missed ticks replayed")
+
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", port), handler); err != nil {
+		logger.ErrorContext(context.Background(), "server failed", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,132 @@
+package rakuda
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"net/http"
+	"strings"
+)
+
+// Variant names assigned by an experiment.
+const (
+	VariantControl = "control"
+	VariantTest    = "variant"
+)
+
+// ExperimentCookieName is the cookie that keeps anonymous users in the same variant
+// when no Split.KeyFunc is given.
+const ExperimentCookieName = "rakuda_experiment"
+
+// Split configures an A/B experiment between two handlers.
+type Split struct {
+	// Name identifies the experiment in logs. Default is the route pattern.
+	// Changing the name reshuffles the assignment.
+	Name string
+	// Control serves the majority of the traffic.
+	Control http.Handler
+	// Variant serves Fraction of the traffic.
+	Variant http.Handler
+	// Fraction is the share of keys routed to Variant, between 0 and 1.
+	Fraction float64
+	// KeyFunc returns the key that determines the assignment (e.g., a user ID).
+	// Requests with an empty key are served by Control.
+	// Default is the ID of the principal (see PrincipalFromContext) or a random key
+	// kept in the ExperimentCookieName cookie.
+	KeyFunc func(*http.Request) string
+}
+
+// Variant is the assignment of a request in an experiment.
+type Variant struct {
+	Experiment string `json:"experiment"`
+	Name       string `json:"name"` // VariantControl or VariantTest
+}
+
+// Experiment registers a handler that deterministically routes a fraction of the traffic
+// to an alternate handler, for gradual rollouts. The same key always gets the same variant.
+// The pattern may start with a method (e.g., "GET /checkout"); otherwise, the
+// experiment is registered for all common methods.
+//
+// The assignment is available via VariantFromContext, and the HTTPLog middleware
+// in rakudamiddleware logs it when the Values middleware is installed.
+func (b *Builder) Experiment(pattern string, split Split) {
+	methods := []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	if method, rest, ok := strings.Cut(pattern, " "); ok {
+		methods = []string{method}
+		pattern = strings.TrimSpace(rest)
+	}
+	if split.Name == "" {
+		split.Name = pattern
+	}
+	h := &experimentHandler{split: split}
+	source := callerSource(2)
+	for _, method := range methods {
+		b.addHandler(method, pattern, h, source)
+	}
+}
+
+type experimentHandler struct {
+	split Split
+}
+
+func (h *experimentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var key string
+	if h.split.KeyFunc != nil {
+		key = h.split.KeyFunc(r)
+	} else {
+		key = defaultExperimentKey(w, r)
+	}
+
+	variant := Variant{Experiment: h.split.Name, Name: VariantControl}
+	handler := h.split.Control
+	if key != "" && bucket(h.split.Name, key) < h.split.Fraction {
+		variant.Name = VariantTest
+		handler = h.split.Variant
+	}
+
+	ctx := context.WithValue(r.Context(), variantKey, variant)
+	if Values(ctx) != nil {
+		// Expose the assignment to outer middlewares, such as access logging.
+		Values(ctx).Set(variantKey, variant)
+	}
+	logger := LoggerFromContext(ctx).With("experiment", variant.Experiment, "variant", variant.Name)
+	ctx = NewContextWithLogger(ctx, logger)
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// VariantFromContext retrieves the experiment assignment of the request.
+func VariantFromContext(ctx context.Context) (Variant, bool) {
+	if v, ok := ctx.Value(variantKey).(Variant); ok {
+		return v, true
+	}
+	if v, ok := Values(ctx).Get(variantKey); ok {
+		variant, ok := v.(Variant)
+		return variant, ok
+	}
+	return Variant{}, false
+}
+
+// bucket maps an experiment and a key to a stable number in [0, 1).
+func bucket(experiment, key string) float64 {
+	sum := sha256.Sum256([]byte(experiment + "\x00" + key))
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53)
+}
+
+func defaultExperimentKey(w http.ResponseWriter, r *http.Request) string {
+	if p, ok := PrincipalFromContext(r.Context()); ok && p.ID != "" {
+		return p.ID
+	}
+	if c, err := r.Cookie(ExperimentCookieName); err == nil && c.Value != "" {
+		return c.Value
+	}
+	key := NewID(16)
+	http.SetCookie(w, &http.Cookie{
+		Name:     ExperimentCookieName,
+		Value:    key,
+		Path:     "/",
+		MaxAge:   365 * 24 * 60 * 60,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return key
+}
@@ -0,0 +1,111 @@
+package rakuda
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExperiment(t *testing.T) {
+	write := func(s string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			v, _ := VariantFromContext(r.Context())
+			fmt.Fprintf(w, "%s:%s", s, v.Name)
+		})
+	}
+
+	b := NewBuilder()
+	b.Experiment("GET /checkout", Split{
+		Name:     "new-checkout",
+		Control:  write("old"),
+		Variant:  write("new"),
+		Fraction: 0.3,
+		KeyFunc:  func(r *http.Request) string { return r.Header.Get("X-User") },
+	})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	serve := func(user string) string {
+		req := httptest.NewRequest(http.MethodGet, "/checkout", nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Body.String()
+	}
+
+	t.Run("deterministic", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			user := fmt.Sprintf("user-%d", i)
+			if first, second := serve(user), serve(user); first != second {
+				t.Errorf("%s: got %q and then %q", user, first, second)
+			}
+		}
+	})
+
+	t.Run("fraction", func(t *testing.T) {
+		variants := 0
+		const n = 2000
+		for i := 0; i < n; i++ {
+			if serve(fmt.Sprintf("user-%d", i)) == "new:variant" {
+				variants++
+			}
+		}
+		if got := float64(variants) / n; got < 0.25 || got > 0.35 {
+			t.Errorf("variant share: got %.3f, want about 0.3", got)
+		}
+	})
+
+	t.Run("empty key is control", func(t *testing.T) {
+		if got, want := serve(""), "old:control"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("method", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/checkout", nil))
+		if rr.Code == http.StatusOK {
+			t.Errorf("expected POST not to be routed to the experiment")
+		}
+	})
+}
+
+func TestExperiment_DefaultKey(t *testing.T) {
+	b := NewBuilder()
+	b.Experiment("/", Split{
+		Control:  http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("control")) }),
+		Variant:  http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("variant")) }),
+		Fraction: 0.5,
+	})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != ExperimentCookieName {
+		t.Fatalf("expected an experiment cookie, got %v", cookies)
+	}
+	first := rr.Body.String()
+
+	// The cookie keeps the user in the same variant.
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(cookies[0])
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Body.String() != first {
+			t.Errorf("got %q, want %q", rr.Body.String(), first)
+		}
+		if len(rr.Result().Cookies()) != 0 {
+			t.Errorf("expected no new cookie")
+		}
+	}
+}
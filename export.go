@@ -0,0 +1,99 @@
+package rakuda
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Export formats supported by ExportRoutes.
+const (
+	ExportJSON = "json"
+	ExportYAML = "yaml"
+)
+
+// ExportedRoute is the serialized form of a route, written by ExportRoutes.
+type ExportedRoute struct {
+	Method      string         `json:"method,omitempty"` // empty for method-agnostic routes
+	Pattern     string         `json:"pattern"`
+	Group       string         `json:"group"` // path prefix of the enclosing groups
+	Handler     string         `json:"handler"`
+	Name        string         `json:"name,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Scopes      []string       `json:"scopes,omitempty"`
+	Consumes    []string       `json:"consumes,omitempty"`
+	Produces    []string       `json:"produces,omitempty"`
+	Extra       map[string]any `json:"extra,omitempty"`
+}
+
+// ExportRoutes writes the routes of b, with their groups and metadata, in the given
+// format (ExportJSON or ExportYAML), for API gateways, documentation pipelines, and
+// route-diff checks in CI. The output is a list of ExportedRoute, in DFS order.
+// Registration sources are left out, so that the output is stable across refactorings.
+func ExportRoutes(w io.Writer, b *Builder, format string) error {
+	routes := b.Routes()
+	exported := make([]ExportedRoute, len(routes))
+	for i, r := range routes {
+		exported[i] = ExportedRoute{
+			Method:      r.Method,
+			Pattern:     r.Pattern,
+			Group:       r.Prefix,
+			Handler:     r.Handler,
+			Name:        r.Meta.Name,
+			Description: r.Meta.Description,
+			Tags:        r.Meta.Tags,
+			Scopes:      r.Meta.Scopes,
+			Consumes:    r.Meta.Consumes,
+			Produces:    r.Meta.Produces,
+			Extra:       r.Meta.Extra,
+		}
+	}
+
+	switch format {
+	case ExportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(exported)
+	case ExportYAML:
+		return writeRoutesYAML(w, exported)
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// writeRoutesYAML writes routes as a YAML sequence of mappings. Scalars and collections
+// are written as JSON, which is valid YAML, so no YAML library is needed.
+func writeRoutesYAML(w io.Writer, routes []ExportedRoute) error {
+	bw := bufio.NewWriter(w)
+	if len(routes) == 0 {
+		bw.WriteString("[]\n")
+	}
+	for _, r := range routes {
+		// Marshal through JSON to reuse the field names and omitempty rules.
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(b, &fields); err != nil {
+			return err
+		}
+		prefix := "- "
+		for _, key := range exportedRouteKeys {
+			value, ok := fields[key]
+			if !ok {
+				continue
+			}
+			if _, err := fmt.Fprintf(bw, "%s%s: %s\n", prefix, key, value); err != nil {
+				return err
+			}
+			prefix = "  "
+		}
+	}
+	return bw.Flush()
+}
+
+// exportedRouteKeys are the JSON keys of ExportedRoute, in field order.
+var exportedRouteKeys = []string{"method", "pattern", "group", "handler", "name", "description", "tags", "scopes", "consumes", "produces", "extra"}
@@ -0,0 +1,65 @@
+package rakuda
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExportRoutes(t *testing.T) {
+	b := NewBuilder()
+	b.Get("/health", http.HandlerFunc(healthHandler), Meta{Tags: []string{"public"}})
+	b.Route("/users", func(b *Builder) {
+		b.Post("/", http.HandlerFunc(healthHandler), Meta{Name: "createUser", Extra: map[string]any{"rate": 10}}, Consumes("application/json"))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := ExportRoutes(&buf, b, ExportJSON); err != nil {
+			t.Fatalf("ExportRoutes() failed: %v", err)
+		}
+		var got []ExportedRoute
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("failed to unmarshal the export: %v", err)
+		}
+		want := []ExportedRoute{
+			{Method: "GET", Pattern: "/health", Group: "/", Handler: "github.com/podhmo/rakuda.healthHandler", Tags: []string{"public"}},
+			{Method: "POST", Pattern: "/users/{$}", Group: "/users", Handler: "github.com/podhmo/rakuda.healthHandler", Name: "createUser", Consumes: []string{"application/json"}, Extra: map[string]any{"rate": float64(10)}},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ExportRoutes() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := ExportRoutes(&buf, b, ExportYAML); err != nil {
+			t.Fatalf("ExportRoutes() failed: %v", err)
+		}
+		want := `- method: "GET"
+  pattern: "/health"
+  group: "/"
+  handler: "github.com/podhmo/rakuda.healthHandler"
+  tags: ["public"]
+- method: "POST"
+  pattern: "/users/{$}"
+  group: "/users"
+  handler: "github.com/podhmo/rakuda.healthHandler"
+  name: "createUser"
+  consumes: ["application/json"]
+  extra: {"rate":10}
+`
+		if diff := cmp.Diff(want, buf.String()); diff != "" {
+			t.Errorf("ExportRoutes() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		if err := ExportRoutes(&bytes.Buffer{}, b, "xml"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
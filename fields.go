@@ -0,0 +1,102 @@
+package rakuda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// ParseFields parses a field selection such as "id,name,author.name" into paths.
+// Empty entries are ignored.
+func ParseFields(s string) [][]string {
+	var fields [][]string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		fields = append(fields, strings.Split(f, "."))
+	}
+	return fields
+}
+
+// NewContextWithFields returns a new context with a field selection (see ParseFields).
+// Responder.JSON filters successful responses to the selected fields.
+// It is typically called by the rakudamiddleware.Fields middleware.
+func NewContextWithFields(ctx context.Context, fields [][]string) context.Context {
+	return context.WithValue(ctx, fieldsKey, fields)
+}
+
+// fieldsFromContext retrieves the field selection from the context.
+func fieldsFromContext(ctx context.Context) [][]string {
+	fields, _ := ctx.Value(fieldsKey).([][]string)
+	return fields
+}
+
+// FilterFields returns a copy of data, as generic JSON values, that keeps only the
+// selected fields. Numbers are kept as json.Number, so that large integers such as
+// int64 IDs are not rounded through float64. Paths are applied to each element of arrays, so selecting
+// "items.id" works for {"items": [{"id": 1, ...}, ...]} and "id" works for a top-level array.
+func FilterFields(data any, fields [][]string) (any, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return filterFields(v, buildFieldTree(fields)), nil
+}
+
+// fieldTree is a set of selected paths. A nil subtree selects the whole value.
+type fieldTree map[string]fieldTree
+
+func buildFieldTree(fields [][]string) fieldTree {
+	tree := fieldTree{}
+	for _, path := range fields {
+		node := tree
+		for i, name := range path {
+			child, ok := node[name]
+			if ok && child == nil {
+				break // the whole value is already selected
+			}
+			if i == len(path)-1 {
+				node[name] = nil
+				break
+			}
+			if !ok {
+				child = fieldTree{}
+				node[name] = child
+			}
+			node = child
+		}
+	}
+	return tree
+}
+
+func filterFields(v any, tree fieldTree) any {
+	if tree == nil {
+		return v
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		filtered := make(map[string]any, len(tree))
+		for name, subtree := range tree {
+			if child, ok := v[name]; ok {
+				filtered[name] = filterFields(child, subtree)
+			}
+		}
+		return filtered
+	case []any:
+		filtered := make([]any, len(v))
+		for i, item := range v {
+			filtered[i] = filterFields(item, tree)
+		}
+		return filtered
+	}
+	return v
+}
@@ -0,0 +1,105 @@
+package rakuda
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilterFields(t *testing.T) {
+	type author struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	type article struct {
+		ID     int      `json:"id"`
+		Title  string   `json:"title"`
+		Body   string   `json:"body"`
+		Author author   `json:"author"`
+		Tags   []string `json:"tags"`
+	}
+	a := article{ID: 1, Title: "hello", Body: "long text", Author: author{ID: 2, Name: "foo"}, Tags: []string{"go"}}
+
+	tests := []struct {
+		name   string
+		data   any
+		fields string
+		want   any
+	}{
+		{
+			name:   "top-level",
+			data:   a,
+			fields: "id,title",
+			want:   map[string]any{"id": json.Number("1"), "title": "hello"},
+		},
+		{
+			name:   "dotted path",
+			data:   a,
+			fields: "id, author.name",
+			want:   map[string]any{"id": json.Number("1"), "author": map[string]any{"name": "foo"}},
+		},
+		{
+			name:   "whole value wins over a sub path",
+			data:   a,
+			fields: "author,author.name",
+			want:   map[string]any{"author": map[string]any{"id": json.Number("2"), "name": "foo"}},
+		},
+		{
+			name:   "top-level array",
+			data:   []article{a, a},
+			fields: "id",
+			want:   []any{map[string]any{"id": json.Number("1")}, map[string]any{"id": json.Number("1")}},
+		},
+		{
+			name:   "nested array",
+			data:   map[string]any{"items": []article{a}, "next": "cursor"},
+			fields: "items.title,missing",
+			want:   map[string]any{"items": []any{map[string]any{"title": "hello"}}},
+		},
+		{
+			name:   "large integer",
+			data:   map[string]int64{"id": math.MaxInt64, "other": 1},
+			fields: "id",
+			want:   map[string]any{"id": json.Number("9223372036854775807")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FilterFields(tt.data, ParseFields(tt.fields))
+			if err != nil {
+				t.Fatalf("FilterFields() failed: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("FilterFields() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResponder_JSON_Fields(t *testing.T) {
+	data := map[string]any{"id": 1, "name": "foo", "email": "foo@example.com"}
+	ctx := NewContextWithFields(context.Background(), ParseFields("id,name"))
+
+	t.Run("success is filtered", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		rr := httptest.NewRecorder()
+		NewResponder().JSON(rr, req, http.StatusOK, data)
+		if got, want := rr.Body.String(), `{"id":1,"name":"foo"}`+"\n"; got != want {
+			t.Errorf("body: got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("errors are not filtered", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		rr := httptest.NewRecorder()
+		NewResponder().Error(rr, req, http.StatusNotFound, NewAPIError(http.StatusNotFound, context.Canceled))
+		if got, want := rr.Body.String(), `{"error":"context canceled"}`+"\n"; got != want {
+			t.Errorf("body: got %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package rakuda
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"sync"
+)
+
+// FlagSubject is the caller for whom feature flags are evaluated.
+type FlagSubject struct {
+	// Principal is the authenticated caller, or nil (see PrincipalFromContext).
+	Principal *Principal
+	// Tenant is the tenant of the request, or empty (see TenantFromContext).
+	Tenant TenantID
+}
+
+// Flags evaluates feature flags, e.g., with a flag service or a configuration file.
+type Flags interface {
+	// Enabled reports whether the flag is on for the subject.
+	Enabled(ctx context.Context, flag string, subject FlagSubject) (bool, error)
+}
+
+// FlagsFunc adapts a function to the Flags interface.
+type FlagsFunc func(ctx context.Context, flag string, subject FlagSubject) (bool, error)
+
+// Enabled implements Flags.
+func (f FlagsFunc) Enabled(ctx context.Context, flag string, subject FlagSubject) (bool, error) {
+	return f(ctx, flag, subject)
+}
+
+// flagSet caches the flags evaluated for a request.
+type flagSet struct {
+	flags Flags
+
+	mu        sync.Mutex
+	evaluated map[string]bool
+}
+
+// NewContextWithFlags returns a new context in which FlagEnabled evaluates flags with flags,
+// at most once per flag and request, so that a request sees consistent values.
+// If the context has a ValueBag (see the Values middleware in rakudamiddleware), the
+// evaluated flags are also visible to outer middlewares, such as access logging.
+// It is typically called by the rakudamiddleware.FeatureFlags middleware.
+func NewContextWithFlags(ctx context.Context, flags Flags) context.Context {
+	set := &flagSet{flags: flags, evaluated: map[string]bool{}}
+	if bag := Values(ctx); bag != nil {
+		bag.Set(flagsKey, set)
+	}
+	return context.WithValue(ctx, flagsKey, set)
+}
+
+// FlagEnabled reports whether the feature flag is on for the principal and the tenant of the
+// request. The first evaluation of each flag is cached for the rest of the request.
+// It reports false if no Flags is in the context or if the evaluation fails; the error is
+// logged at warn level.
+//
+//	if rakuda.FlagEnabled(r, "new-checkout") {
+//		...
+//	}
+func FlagEnabled(r *http.Request, flag string) bool {
+	ctx := r.Context()
+	set, ok := ctx.Value(flagsKey).(*flagSet)
+	if !ok {
+		return false
+	}
+
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	if enabled, ok := set.evaluated[flag]; ok {
+		return enabled
+	}
+	var subject FlagSubject
+	subject.Principal, _ = PrincipalFromContext(ctx)
+	subject.Tenant, _ = TenantFromContext(ctx)
+	enabled, err := set.flags.Enabled(ctx, flag, subject)
+	if err != nil {
+		LoggerFromContext(ctx).WarnContext(ctx, "failed to evaluate feature flag", "flag", flag, "error", err)
+		enabled = false
+	}
+	set.evaluated[flag] = enabled
+	return enabled
+}
+
+// EvaluatedFlagsFromContext returns the flags evaluated so far in the request, for logging.
+func EvaluatedFlagsFromContext(ctx context.Context) (map[string]bool, bool) {
+	set, ok := ctx.Value(flagsKey).(*flagSet)
+	if !ok {
+		v, found := Values(ctx).Get(flagsKey)
+		if set, ok = v.(*flagSet); !found || !ok {
+			return nil, false
+		}
+	}
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	return maps.Clone(set.evaluated), true
+}
@@ -0,0 +1,64 @@
+package rakuda
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFlagEnabled(t *testing.T) {
+	calls := 0
+	flags := FlagsFunc(func(ctx context.Context, flag string, subject FlagSubject) (bool, error) {
+		calls++
+		switch flag {
+		case "beta":
+			return subject.Principal != nil && subject.Principal.ID == "alice", nil
+		case "tenant":
+			return subject.Tenant == "acme", nil
+		default:
+			return true, errors.New("flag service is down")
+		}
+	})
+
+	t.Run("without flags", func(t *testing.T) {
+		if FlagEnabled(httptest.NewRequest("GET", "/", nil), "beta") {
+			t.Error("FlagEnabled() = true, want false")
+		}
+	})
+
+	t.Run("evaluated once per request", func(t *testing.T) {
+		calls = 0
+		req := httptest.NewRequest("GET", "/", nil)
+		ctx := NewContextWithLogger(req.Context(), slog.New(slog.DiscardHandler))
+		ctx = NewContextWithValues(ctx)
+		outer := ctx // as seen by an outer middleware, such as HTTPLog
+		ctx = NewContextWithPrincipal(ctx, &Principal{ID: "alice"})
+		ctx = NewContextWithTenant(ctx, "other")
+		ctx = NewContextWithFlags(ctx, flags)
+		req = req.WithContext(ctx)
+
+		got := map[string]bool{}
+		for _, flag := range []string{"beta", "tenant", "broken", "beta"} {
+			got[flag] = FlagEnabled(req, flag)
+		}
+		want := map[string]bool{"beta": true, "tenant": false, "broken": false}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("FlagEnabled() mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(3, calls); diff != "" {
+			t.Errorf("calls mismatch (-want +got):\n%s", diff)
+		}
+
+		evaluated, ok := EvaluatedFlagsFromContext(outer)
+		if !ok {
+			t.Fatal("EvaluatedFlagsFromContext() reported false")
+		}
+		if diff := cmp.Diff(want, evaluated); diff != "" {
+			t.Errorf("EvaluatedFlagsFromContext() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package rakuda
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/podhmo/rakuda/internal/signedcookie"
+)
+
+// FlashCookieName is the name of the cookie that carries flash messages to the next request.
+const FlashCookieName = "rakuda_flash"
+
+// Flash is a one-time message shown on the page after a redirect,
+// as in the post/redirect/get pattern.
+type Flash struct {
+	// Kind classifies the message (e.g., "success", "error").
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// RedirectWithFlash stores flash messages in a cookie signed with FlashSecret and performs
+// an HTTP redirect. The messages are read once in the next request by ReadFlashes
+// (or the rakudamiddleware.Flash middleware) with the same secret.
+// Without FlashSecret, the messages are dropped and an error is logged. The cookie is
+// Secure for requests over TLS, or if FlashSecureCookie is set.
+func (r *Responder) RedirectWithFlash(w http.ResponseWriter, req *http.Request, url string, code int, flashes ...Flash) {
+	if len(flashes) > 0 {
+		value, err := signedcookie.Encode(r.FlashSecret, flashPurpose, flashes)
+		if err != nil {
+			logger := LoggerFromContext(req.Context())
+			logger.ErrorContext(req.Context(), "failed to encode flash messages", "error", err)
+		} else {
+			http.SetCookie(w, &http.Cookie{
+				Name:     FlashCookieName,
+				Value:    value,
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   r.FlashSecureCookie || req.TLS != nil,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+	}
+	r.Redirect(w, req, url, code)
+}
+
+// ReadFlashes returns the flash messages of the request and clears the cookie,
+// so that they are shown only once. Cookies that are malformed or not signed with
+// secret are ignored. Still, render the messages escaped, as html/template does.
+func ReadFlashes(w http.ResponseWriter, req *http.Request, secret []byte) []Flash {
+	cookie, err := req.Cookie(FlashCookieName)
+	if err != nil {
+		return nil
+	}
+	http.SetCookie(w, &http.Cookie{Name: FlashCookieName, Path: "/", MaxAge: -1})
+
+	var flashes []Flash
+	if err := signedcookie.Decode(secret, flashPurpose, cookie.Value, &flashes); err != nil {
+		return nil
+	}
+	return flashes
+}
+
+// flashPurpose is bound into the signature of the flash cookie, so that the value of
+// another cookie signed with the same secret cannot be replayed as flash messages.
+const flashPurpose = "rakuda.flash"
+
+// NewContextWithFlashes returns a new context with the provided flash messages.
+func NewContextWithFlashes(ctx context.Context, flashes []Flash) context.Context {
+	return context.WithValue(ctx, flashKey, flashes)
+}
+
+// FlashesFromContext retrieves the flash messages of the request from the context.
+func FlashesFromContext(ctx context.Context) []Flash {
+	flashes, _ := ctx.Value(flashKey).([]Flash)
+	return flashes
+}
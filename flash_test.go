@@ -0,0 +1,90 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda/internal/signedcookie"
+)
+
+func TestRedirectWithFlash(t *testing.T) {
+	secret := []byte("s3cret")
+	responder := &Responder{FlashSecret: secret}
+	want := []Flash{{Kind: "success", Message: "Saved <b>item</b>"}}
+
+	// POST: redirect with a flash message
+	req := httptest.NewRequest(http.MethodPost, "/items", nil)
+	rr := httptest.NewRecorder()
+	responder.RedirectWithFlash(rr, req, "/items/1", http.StatusSeeOther, want...)
+
+	if rr.Code != http.StatusSeeOther {
+		t.Errorf("status code: got %d, want %d", rr.Code, http.StatusSeeOther)
+	}
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != FlashCookieName || cookies[0].Secure {
+		t.Fatalf("expected a flash cookie, not Secure over plain HTTP, got %v", cookies)
+	}
+
+	// GET: read the flash message once
+	req = httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.AddCookie(cookies[0])
+	rr = httptest.NewRecorder()
+	got := ReadFlashes(rr, req, secret)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("flashes mismatch (-want +got):\n%s", diff)
+	}
+	cleared := rr.Result().Cookies()
+	if len(cleared) != 1 || cleared[0].MaxAge >= 0 {
+		t.Errorf("expected the flash cookie to be cleared, got %v", cleared)
+	}
+
+	// no cookie
+	req = httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	if got := ReadFlashes(httptest.NewRecorder(), req, secret); got != nil {
+		t.Errorf("expected no flashes, got %v", got)
+	}
+
+	// forged cookies are ignored
+	payload, _, _ := strings.Cut(cookies[0].Value, ".")
+	forged, err := signedcookie.Encode([]byte("other"), flashPurpose, []Flash{{Kind: "success", Message: "forged"}})
+	if err != nil {
+		t.Fatalf("signedcookie.Encode() failed: %v", err)
+	}
+	otherPurpose, err := signedcookie.Encode(secret, "other", []Flash{{Kind: "success", Message: "replayed"}})
+	if err != nil {
+		t.Fatalf("signedcookie.Encode() failed: %v", err)
+	}
+	for _, value := range []string{payload, payload + ".", forged, otherPurpose} {
+		req = httptest.NewRequest(http.MethodGet, "/items/1", nil)
+		req.AddCookie(&http.Cookie{Name: FlashCookieName, Value: value})
+		if got := ReadFlashes(httptest.NewRecorder(), req, secret); got != nil {
+			t.Errorf("expected no flashes for %q, got %v", value, got)
+		}
+	}
+
+	// Secure over TLS, or if configured
+	for _, tt := range []struct {
+		name      string
+		responder *Responder
+		target    string
+	}{
+		{"tls", responder, "https://example.com/items"},
+		{"configured", &Responder{FlashSecret: secret, FlashSecureCookie: true}, "/items"},
+	} {
+		rr = httptest.NewRecorder()
+		tt.responder.RedirectWithFlash(rr, httptest.NewRequest(http.MethodPost, tt.target, nil), "/items/1", http.StatusSeeOther, want...)
+		if cookies := rr.Result().Cookies(); len(cookies) != 1 || !cookies[0].Secure {
+			t.Errorf("%s: expected a Secure flash cookie, got %v", tt.name, cookies)
+		}
+	}
+
+	// without FlashSecret, no cookie is set
+	rr = httptest.NewRecorder()
+	NewResponder().RedirectWithFlash(rr, httptest.NewRequest(http.MethodPost, "/items", nil), "/items/1", http.StatusSeeOther, want...)
+	if rr.Code != http.StatusSeeOther || len(rr.Result().Cookies()) != 0 {
+		t.Errorf("expected a redirect without a cookie, got %d and %v", rr.Code, rr.Result().Cookies())
+	}
+}
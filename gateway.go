@@ -0,0 +1,103 @@
+package rakuda
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Gateway mounts a transcoding handler, such as a grpc-gateway ServeMux or a connect-go
+// handler, under prefix for all common methods. The prefix is stripped from the request
+// path before it is passed to the handler. Middleware of the enclosing group is applied.
+//
+// Error responses in the google.rpc.Status JSON shape ({"code": 5, "message": "..."})
+// are translated into the rakuda JSON error shape ({"error": "..."}), keeping the status
+// code, so that clients see a single error format across REST and gRPC-backed routes.
+func (b *Builder) Gateway(prefix string, handler http.Handler) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	h := &gatewayHandler{prefix: prefix, handler: handler, responder: NewResponder()}
+	source := callerSource(2)
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		b.addHandler(method, prefix+"/{path...}", h, source)
+	}
+}
+
+type gatewayHandler struct {
+	prefix    string
+	handler   http.Handler
+	responder *Responder
+}
+
+func (h *gatewayHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The prefix is relative to the enclosing groups, so strip it by the matched wildcard.
+	rest := "/" + r.PathValue("path")
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = rest
+	r2.URL.RawPath = ""
+
+	tw := &gatewayResponseWriter{ResponseWriter: w}
+	h.handler.ServeHTTP(tw, r2)
+	if !tw.translating {
+		return
+	}
+
+	var status struct {
+		Code    *int   `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(tw.body.Bytes(), &status); err != nil || status.Code == nil {
+		// Not a google.rpc.Status; pass the response through.
+		w.WriteHeader(tw.status)
+		w.Write(tw.body.Bytes())
+		return
+	}
+	w.Header().Del("Content-Length")
+	err := CodeError(Code(*status.Code), errors.New(status.Message))
+	h.responder.Error(w, r, tw.status, err)
+}
+
+// gatewayResponseWriter buffers JSON error responses so that they can be translated.
+type gatewayResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	translating bool
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *gatewayResponseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if statusCode >= http.StatusBadRequest && mediaType == "application/json" {
+		w.status = statusCode
+		w.translating = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *gatewayResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.translating {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush supports streaming responses (e.g., server-streaming RPCs).
+func (w *gatewayResponseWriter) Flush() {
+	if w.translating {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
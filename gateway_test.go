@@ -0,0 +1,77 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGateway(t *testing.T) {
+	// gw imitates a grpc-gateway ServeMux.
+	gw := http.NewServeMux()
+	gw.HandleFunc("GET /v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.PathValue("id") != "1" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":5,"message":"user not found","details":[]}`))
+			return
+		}
+		w.Write([]byte(`{"id":"1"}`))
+	})
+	gw.HandleFunc("POST /v1/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"code":13,"message":"db connection refused"}`))
+	})
+	gw.HandleFunc("DELETE /v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"reason":"custom"}`))
+	})
+
+	var middlewareCalled bool
+	b := NewBuilder()
+	b.Route("/api", func(b *Builder) {
+		b.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				middlewareCalled = true
+				next.ServeHTTP(w, r)
+			})
+		})
+		b.Gateway("/grpc", gw)
+	})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{"ok", http.MethodGet, "/api/grpc/v1/users/1", http.StatusOK, `{"id":"1"}`},
+		{"translated error", http.MethodGet, "/api/grpc/v1/users/2", http.StatusNotFound, `{"error":"user not found"}`},
+		{"internal error is hidden", http.MethodPost, "/api/grpc/v1/users", http.StatusInternalServerError, `{"error":"Internal Server Error"}`},
+		{"other error shape passes through", http.MethodDelete, "/api/grpc/v1/users/1", http.StatusForbidden, `{"reason":"custom"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			middlewareCalled = false
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.target, nil))
+			if rr.Code != tt.wantCode {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantCode)
+			}
+			if got := strings.TrimSpace(rr.Body.String()); got != tt.wantBody {
+				t.Errorf("body: got %q, want %q", got, tt.wantBody)
+			}
+			if !middlewareCalled {
+				t.Error("expected the group middleware to be applied")
+			}
+		})
+	}
+}
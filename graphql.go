@@ -0,0 +1,368 @@
+package rakuda
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// GraphQLRequest is a GraphQL operation received over HTTP.
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	Extensions    map[string]any `json:"extensions,omitempty"`
+}
+
+// GraphQLError is a single error in a GraphQL response.
+type GraphQLError struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// GraphQLResponse is the result of executing a GraphQL operation.
+type GraphQLResponse struct {
+	Data       any            `json:"data,omitempty"`
+	Errors     []GraphQLError `json:"errors,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// Executor executes GraphQL operations. Adapt a GraphQL library (e.g., gqlgen or
+// graphql-go) to this interface to mount it with Builder.GraphQL.
+type Executor interface {
+	Execute(ctx context.Context, req *GraphQLRequest) *GraphQLResponse
+}
+
+// ExecutorFunc is an adapter to allow the use of ordinary functions as an Executor.
+type ExecutorFunc func(ctx context.Context, req *GraphQLRequest) *GraphQLResponse
+
+// Execute calls f(ctx, req).
+func (f ExecutorFunc) Execute(ctx context.Context, req *GraphQLRequest) *GraphQLResponse {
+	return f(ctx, req)
+}
+
+// PersistedQueryStore stores queries by their SHA-256 hash, for automatic persisted queries.
+type PersistedQueryStore interface {
+	Get(ctx context.Context, hash string) (query string, ok bool)
+	Put(ctx context.Context, hash string, query string)
+}
+
+// GraphQLConfig holds the configuration for a GraphQL endpoint.
+type GraphQLConfig struct {
+	// GraphiQL serves the GraphiQL UI for GET requests from browsers. Enable it only in development.
+	GraphiQL bool
+	// PersistedQueries enables automatic persisted queries (the "persistedQuery" extension).
+	// If nil, persisted queries are not supported.
+	PersistedQueries PersistedQueryStore
+	// MaxBodySize is the maximum size of a request body. Default is 1 MB.
+	MaxBodySize int64
+}
+
+// GraphQL registers GET and POST handlers serving GraphQL over HTTP at pattern,
+// so that a GraphQL layer can coexist with REST routes under the same builder and middleware.
+// POST accepts "application/json" and "application/graphql" bodies; GET accepts the
+// operation in the query string, but not mutations.
+func (b *Builder) GraphQL(pattern string, executor Executor, config *GraphQLConfig) {
+	if config == nil {
+		config = &GraphQLConfig{}
+	}
+	h := &graphqlHandler{
+		executor:    executor,
+		config:      config,
+		maxBodySize: config.MaxBodySize,
+		responder:   NewResponder(),
+	}
+	if h.maxBodySize <= 0 {
+		h.maxBodySize = 1 << 20
+	}
+	source := callerSource(2)
+	b.addHandler(http.MethodGet, pattern, h, source)
+	b.addHandler(http.MethodPost, pattern, h, source)
+}
+
+type graphqlHandler struct {
+	executor    Executor
+	config      *GraphQLConfig
+	maxBodySize int64
+	responder   *Responder
+}
+
+func (h *graphqlHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req *GraphQLRequest
+	var err error
+	if r.Method == http.MethodGet {
+		if h.config.GraphiQL && !r.URL.Query().Has("query") && strings.Contains(r.Header.Get("Accept"), "text/html") {
+			h.responder.HTML(w, r, http.StatusOK, graphiQLPage)
+			return
+		}
+		req, err = graphqlRequestFromQuery(r)
+	} else {
+		req, err = h.graphqlRequestFromBody(w, r)
+	}
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			h.responder.Error(w, r, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		var sc interface{ StatusCode() int }
+		if errors.As(err, &sc) {
+			h.responder.Error(w, r, sc.StatusCode(), err)
+			return
+		}
+		h.responder.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if res := h.resolvePersistedQuery(r.Context(), req); res != nil {
+		h.responder.JSON(w, r, http.StatusOK, res)
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		h.responder.Error(w, r, http.StatusBadRequest, errors.New("query is required"))
+		return
+	}
+	// Checked after resolving persisted queries, whose text is not in the request.
+	if r.Method == http.MethodGet && isMutation(req.Query, req.OperationName) {
+		w.Header().Set("Allow", http.MethodPost)
+		h.responder.Error(w, r, http.StatusMethodNotAllowed, errors.New("mutations must be sent with POST"))
+		return
+	}
+
+	res := h.executor.Execute(r.Context(), req)
+	if res == nil {
+		res = &GraphQLResponse{}
+	}
+	h.responder.JSON(w, r, http.StatusOK, res)
+}
+
+// resolvePersistedQuery handles the "persistedQuery" extension. It fills in the query
+// from the store, or stores it. If the query is unknown, it returns the error response.
+func (h *graphqlHandler) resolvePersistedQuery(ctx context.Context, req *GraphQLRequest) *GraphQLResponse {
+	ext, ok := req.Extensions["persistedQuery"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	if h.config.PersistedQueries == nil {
+		return graphqlErrorResponse("PersistedQueryNotSupported", "PERSISTED_QUERY_NOT_SUPPORTED")
+	}
+	hash, _ := ext["sha256Hash"].(string)
+	if req.Query == "" {
+		query, ok := h.config.PersistedQueries.Get(ctx, hash)
+		if !ok {
+			return graphqlErrorResponse("PersistedQueryNotFound", "PERSISTED_QUERY_NOT_FOUND")
+		}
+		req.Query = query
+		return nil
+	}
+	sum := sha256.Sum256([]byte(req.Query))
+	if hex.EncodeToString(sum[:]) != hash {
+		return graphqlErrorResponse("provided sha does not match query", "PERSISTED_QUERY_HASH_MISMATCH")
+	}
+	h.config.PersistedQueries.Put(ctx, hash, req.Query)
+	return nil
+}
+
+func graphqlErrorResponse(message, code string) *GraphQLResponse {
+	return &GraphQLResponse{Errors: []GraphQLError{{Message: message, Extensions: map[string]any{"code": code}}}}
+}
+
+func graphqlRequestFromQuery(r *http.Request) (*GraphQLRequest, error) {
+	q := r.URL.Query()
+	req := &GraphQLRequest{Query: q.Get("query"), OperationName: q.Get("operationName")}
+	if v := q.Get("variables"); v != "" {
+		if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+			return nil, fmt.Errorf("invalid variables: %w", err)
+		}
+	}
+	if v := q.Get("extensions"); v != "" {
+		if err := json.Unmarshal([]byte(v), &req.Extensions); err != nil {
+			return nil, fmt.Errorf("invalid extensions: %w", err)
+		}
+	}
+	return req, nil
+}
+
+func (h *graphqlHandler) graphqlRequestFromBody(w http.ResponseWriter, r *http.Request) (*GraphQLRequest, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	body := http.MaxBytesReader(w, r.Body, h.maxBodySize)
+	switch mediaType {
+	case "application/json", "":
+		var req GraphQLRequest
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			return nil, fmt.Errorf("invalid request body: %w", err)
+		}
+		return &req, nil
+	case "application/graphql":
+		b, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request body: %w", err)
+		}
+		return &GraphQLRequest{Query: string(b)}, nil
+	}
+	return nil, NewAPIErrorf(http.StatusUnsupportedMediaType, "unsupported media type %q", mediaType)
+}
+
+// isMutation reports whether the operation of the document selected by operationName is
+// a mutation. If no operation is selected by name, it reports whether the document has any
+// mutation, so that a multi-operation document cannot smuggle a mutation into a GET request.
+func isMutation(query, operationName string) bool {
+	ops := graphqlOperations(query)
+	selected := false
+	for _, op := range ops {
+		if operationName != "" && op.name == operationName {
+			if op.kind == "mutation" {
+				return true
+			}
+			selected = true
+		}
+	}
+	return !selected && slices.ContainsFunc(ops, func(op graphqlOperation) bool { return op.kind == "mutation" })
+}
+
+// graphqlOperation is an operation definition of a GraphQL document.
+type graphqlOperation struct {
+	kind string // "query", "mutation", or "subscription"
+	name string // empty for anonymous operations
+}
+
+// graphqlOperations lists the operations of a GraphQL document. It scans only the top level
+// of the document, skipping comments, strings, and everything inside brackets, which is
+// enough to find the type and name of each definition without a full parser.
+func graphqlOperations(query string) []graphqlOperation {
+	var ops []graphqlOperation
+	depth := 0
+	expectDefinition, expectName := true, false
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			i = skipGraphQLString(query, i)
+			expectName = false
+		case c == '{' || c == '(' || c == '[':
+			if depth == 0 && expectDefinition && c == '{' {
+				ops = append(ops, graphqlOperation{kind: "query"}) // the query shorthand
+			}
+			depth++
+			i++
+			expectDefinition, expectName = false, false
+		case c == '}' || c == ')' || c == ']':
+			depth--
+			i++
+			if depth == 0 && c == '}' {
+				expectDefinition = true // the selection set of a definition ended
+			}
+		case c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z'):
+			j := i
+			for j < len(query) && (query[j] == '_' || ('a' <= query[j] && query[j] <= 'z') || ('A' <= query[j] && query[j] <= 'Z') || ('0' <= query[j] && query[j] <= '9')) {
+				j++
+			}
+			word := query[i:j]
+			i = j
+			if depth != 0 {
+				continue
+			}
+			switch {
+			case expectDefinition:
+				expectDefinition = false
+				if word == "query" || word == "mutation" || word == "subscription" {
+					ops = append(ops, graphqlOperation{kind: word})
+					expectName = true
+				}
+			case expectName:
+				ops[len(ops)-1].name = word
+				expectName = false
+			}
+		default:
+			if c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != ',' {
+				expectName = false // e.g., a directive (@) or variables come before the name
+			}
+			i++
+		}
+	}
+	return ops
+}
+
+// skipGraphQLString returns the index after the string or block string starting at i.
+func skipGraphQLString(query string, i int) int {
+	if strings.HasPrefix(query[i:], `"""`) {
+		for j := i + 3; j < len(query); j++ {
+			if query[j] == '\\' && strings.HasPrefix(query[j+1:], `"""`) {
+				j += 3
+				continue
+			}
+			if strings.HasPrefix(query[j:], `"""`) {
+				return j + 3
+			}
+		}
+		return len(query)
+	}
+	for j := i + 1; j < len(query); j++ {
+		switch query[j] {
+		case '\\':
+			j++
+		case '"', '\n':
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+// MemoryPersistedQueryStore is an in-memory PersistedQueryStore.
+type MemoryPersistedQueryStore struct {
+	queries sync.Map
+}
+
+// Get implements PersistedQueryStore.
+func (s *MemoryPersistedQueryStore) Get(ctx context.Context, hash string) (string, bool) {
+	v, ok := s.queries.Load(hash)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// Put implements PersistedQueryStore.
+func (s *MemoryPersistedQueryStore) Put(ctx context.Context, hash string, query string) {
+	s.queries.Store(hash, query)
+}
+
+// graphiQLPage is the GraphiQL UI, loaded from a CDN. The versions are pinned, because
+// React 19 ships no UMD builds, and the integrity hashes make sure the code does not change.
+var graphiQLPage = []byte(`<!DOCTYPE html>
+<html>
+<head>
+  <title>GraphiQL</title>
+  <style>body { margin: 0; height: 100vh; } #graphiql { height: 100vh; }</style>
+  <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/graphiql@3.0.6/graphiql.min.css"
+    integrity="sha256-wTzfn13a+pLMB5rMeysPPR1hO7x0SwSeQI+cnw7VdbE=" crossorigin="anonymous" />
+</head>
+<body>
+  <div id="graphiql">Loading...</div>
+  <script src="https://cdn.jsdelivr.net/npm/react@18.2.0/umd/react.production.min.js"
+    integrity="sha256-S0lp+k7zWUMk2ixteM6HZvu8L9Eh//OVrt+ZfbCpmgY=" crossorigin="anonymous"></script>
+  <script src="https://cdn.jsdelivr.net/npm/react-dom@18.2.0/umd/react-dom.production.min.js"
+    integrity="sha256-IXWO0ITNDjfnNXIu5POVfqlgYoop36bDzhodR6LW5Pc=" crossorigin="anonymous"></script>
+  <script src="https://cdn.jsdelivr.net/npm/graphiql@3.0.6/graphiql.min.js"
+    integrity="sha256-eNxH+Ah7Z9up9aJYTQycgyNuy953zYZwE9Rqf5rH+r4=" crossorigin="anonymous"></script>
+  <script>
+    const fetcher = GraphiQL.createFetcher({ url: window.location.pathname });
+    ReactDOM.render(React.createElement(GraphiQL, { fetcher }), document.getElementById('graphiql'));
+  </script>
+</body>
+</html>
+`)
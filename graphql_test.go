@@ -0,0 +1,213 @@
+package rakuda
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGraphQL(t *testing.T) {
+	// echo executor: returns the received operation as data
+	executor := ExecutorFunc(func(ctx context.Context, req *GraphQLRequest) *GraphQLResponse {
+		return &GraphQLResponse{Data: map[string]any{"query": req.Query, "variables": req.Variables}}
+	})
+
+	const query = "{ me { id } }"
+	sum := sha256.Sum256([]byte(query))
+	hash := hex.EncodeToString(sum[:])
+
+	b := NewBuilder()
+	b.GraphQL("/graphql", executor, &GraphQLConfig{GraphiQL: true, PersistedQueries: &MemoryPersistedQueryStore{}})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		accept      string
+		body        string
+		wantCode    int
+		wantBody    string
+	}{
+		{
+			name:        "post json",
+			method:      http.MethodPost,
+			target:      "/graphql",
+			contentType: "application/json",
+			body:        `{"query":"query($id: ID!) { user(id: $id) { name } }","variables":{"id":"1"}}`,
+			wantCode:    http.StatusOK,
+			wantBody:    `{"data":{"query":"query($id: ID!) { user(id: $id) { name } }","variables":{"id":"1"}}}`,
+		},
+		{
+			name:        "post graphql",
+			method:      http.MethodPost,
+			target:      "/graphql",
+			contentType: "application/graphql",
+			body:        query,
+			wantCode:    http.StatusOK,
+			wantBody:    `{"data":{"query":"{ me { id } }","variables":null}}`,
+		},
+		{
+			name:     "get",
+			method:   http.MethodGet,
+			target:   "/graphql?query=" + url.QueryEscape(query) + "&variables=" + url.QueryEscape(`{"x":1}`),
+			wantCode: http.StatusOK,
+			wantBody: `{"data":{"query":"{ me { id } }","variables":{"x":1}}}`,
+		},
+		{
+			name:     "get mutation",
+			method:   http.MethodGet,
+			target:   "/graphql?query=" + url.QueryEscape("mutation { logout }"),
+			wantCode: http.StatusMethodNotAllowed,
+			wantBody: `{"error":"mutations must be sent with POST"}`,
+		},
+		{
+			name:     "get mutation selected by operation name",
+			method:   http.MethodGet,
+			target:   "/graphql?query=" + url.QueryEscape("query A { a }\nmutation B { b }") + "&operationName=B",
+			wantCode: http.StatusMethodNotAllowed,
+			wantBody: `{"error":"mutations must be sent with POST"}`,
+		},
+		{
+			name:        "unsupported media type",
+			method:      http.MethodPost,
+			target:      "/graphql",
+			contentType: "text/plain",
+			body:        query,
+			wantCode:    http.StatusUnsupportedMediaType,
+			wantBody:    `{"error":"unsupported media type \"text/plain\""}`,
+		},
+		{
+			name:     "missing query",
+			method:   http.MethodGet,
+			target:   "/graphql",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"error":"query is required"}`,
+		},
+		{
+			name:     "graphiql",
+			method:   http.MethodGet,
+			target:   "/graphql",
+			accept:   "text/html",
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("status code: got %d, want %d, body: %s", rr.Code, tt.wantCode, rr.Body.String())
+			}
+			if tt.wantBody != "" {
+				if diff := cmp.Diff(tt.wantBody, strings.TrimSpace(rr.Body.String())); diff != "" {
+					t.Errorf("body mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+
+	t.Run("persisted query", func(t *testing.T) {
+		do := func(body string) string {
+			req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return strings.TrimSpace(rr.Body.String())
+		}
+		ext := `"extensions":{"persistedQuery":{"version":1,"sha256Hash":"` + hash + `"}}`
+
+		if got, want := do(`{`+ext+`}`), `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`; got != want {
+			t.Errorf("unknown hash: got %s, want %s", got, want)
+		}
+		do(`{"query":"` + query + `",` + ext + `}`) // register
+		if got, want := do(`{`+ext+`}`), `{"data":{"query":"{ me { id } }","variables":null}}`; got != want {
+			t.Errorf("known hash: got %s, want %s", got, want)
+		}
+	})
+}
+
+func TestGraphiQLPage_PinnedAssets(t *testing.T) {
+	// Every asset loaded from the CDN must have a pinned version and an integrity hash.
+	assets := regexp.MustCompile(`(?:src|href)="(https://[^"]+)"\s+integrity="sha256-[^"]+"`).FindAllStringSubmatch(string(graphiQLPage), -1)
+	if got, want := len(assets), strings.Count(string(graphiQLPage), "https://"); got != want {
+		t.Fatalf("assets with integrity: got %d, want %d", got, want)
+	}
+	for _, m := range assets {
+		if !regexp.MustCompile(`@\d+\.\d+\.\d+/`).MatchString(m[1]) {
+			t.Errorf("asset %s has no pinned version", m[1])
+		}
+	}
+}
+
+func TestIsMutation(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		operationName string
+		want          bool
+	}{
+		{name: "shorthand", query: "{ me { id } }"},
+		{name: "query", query: "query Me { me { id } }"},
+		{name: "mutation", query: "# comment\nmutation Logout { logout }", want: true},
+		{name: "query selected", query: "query A { a }\nmutation B { b }", operationName: "A"},
+		{name: "mutation selected", query: "query A { a }\nmutation B { b }", operationName: "B", want: true},
+		{name: "no operation selected", query: "query A { a }\nmutation B { b }", want: true},
+		{name: "unknown operation", query: "query A { a }\nmutation B { b }", operationName: "C", want: true},
+		{name: "operation named mutation", query: "query mutation { a }", operationName: "mutation"},
+		{name: "directive is not a name", query: "query @B { a }\nmutation B { b }", operationName: "B", want: true},
+		{name: "keywords in strings and arguments", query: `query A($m: String = "} mutation X {") { a(q: "{") }`},
+		{name: "fragment", query: "fragment F on User { id }\nquery A { me { ...F } }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isMutation(tt.query, tt.operationName); got != tt.want {
+				t.Errorf("isMutation(%q, %q) = %v, want %v", tt.query, tt.operationName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphQL_PersistedMutationOverGet(t *testing.T) {
+	executor := ExecutorFunc(func(ctx context.Context, req *GraphQLRequest) *GraphQLResponse {
+		return &GraphQLResponse{Data: map[string]any{"ok": true}}
+	})
+	const mutation = "mutation { logout }"
+	sum := sha256.Sum256([]byte(mutation))
+	extensions := `{"persistedQuery":{"version":1,"sha256Hash":"` + hex.EncodeToString(sum[:]) + `"}}`
+
+	store := &MemoryPersistedQueryStore{}
+	store.Put(context.Background(), hex.EncodeToString(sum[:]), mutation)
+	b := NewBuilder()
+	b.GraphQL("/graphql", executor, &GraphQLConfig{PersistedQueries: store})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/graphql?extensions="+url.QueryEscape(extensions), nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status code: got %d, want %d, body: %s", rr.Code, http.StatusMethodNotAllowed, rr.Body.String())
+	}
+}
@@ -0,0 +1,59 @@
+package rakuda
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// headHandler serves HEAD requests with next, discarding the body but setting
+// Content-Length to its size. Other requests are passed through.
+func headHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		hw := &headWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(hw, r)
+		hw.finish()
+	})
+}
+
+// headWriter counts the bytes of the body instead of writing them.
+// The header is held back until the handler returns, so that Content-Length can be set.
+type headWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+}
+
+func (hw *headWriter) WriteHeader(statusCode int) {
+	if hw.wroteHeader {
+		return
+	}
+	hw.wroteHeader = true
+	hw.status = statusCode
+}
+
+func (hw *headWriter) Write(b []byte) (int, error) {
+	if !hw.wroteHeader {
+		if hw.Header().Get("Content-Type") == "" {
+			hw.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		hw.WriteHeader(http.StatusOK)
+	}
+	hw.size += len(b)
+	return len(b), nil
+}
+
+// Flush implements http.Flusher. It does nothing, because nothing is sent until the handler returns.
+func (hw *headWriter) Flush() {}
+
+func (hw *headWriter) finish() {
+	h := hw.Header()
+	if h.Get("Content-Length") == "" && hw.size > 0 {
+		h.Set("Content-Length", strconv.Itoa(hw.size))
+	}
+	hw.ResponseWriter.WriteHeader(hw.status)
+}
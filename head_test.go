@@ -0,0 +1,57 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAutoHead(t *testing.T) {
+	get := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("hello, "))
+		w.Write([]byte("world"))
+	})
+	head := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Custom", "yes")
+	})
+
+	tests := []struct {
+		name              string
+		method            string
+		path              string
+		wantStatus        int
+		wantContentLength string
+		wantBody          string
+	}{
+		{name: "GET", method: http.MethodGet, path: "/greeting", wantStatus: http.StatusOK, wantBody: "hello, world"},
+		{name: "HEAD reports the GET size", method: http.MethodHead, path: "/greeting", wantStatus: http.StatusOK, wantContentLength: "12"},
+		{name: "registered HEAD route wins", method: http.MethodHead, path: "/custom", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuilder(WithAutoHead())
+			b.Get("/greeting", get)
+			b.Get("/custom", get)
+			b.Head("/custom", head)
+			router, err := b.Build()
+			if err != nil {
+				t.Fatalf("b.Build() failed: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if got := rr.Header().Get("Content-Length"); got != tt.wantContentLength {
+				t.Errorf("Content-Length: got %q, want %q", got, tt.wantContentLength)
+			}
+			if got := rr.Body.String(); got != tt.wantBody {
+				t.Errorf("body: got %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package rakuda
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"sync"
+)
+
+var (
+	idMu     sync.Mutex
+	idSource io.Reader = rand.Reader
+)
+
+// NewID returns a hex-encoded identifier of n random bytes (2n characters). It is used for
+// generated values such as recording IDs, experiment keys, and upload IDs. The bytes come
+// from crypto/rand unless a test replaces the source with SetIDSource (see rakudatest.SeedIDs),
+// so that golden-file snapshots are stable.
+func NewID(n int) string {
+	b := make([]byte, n)
+	idMu.Lock()
+	_, err := io.ReadFull(idSource, b)
+	idMu.Unlock()
+	if err != nil {
+		panic("rakuda: failed to read the ID source: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// SetIDSource replaces the source of the random bytes of NewID and returns a function that
+// restores the previous source. It is a test hook; the source is global, so tests that use it
+// must not run in parallel.
+func SetIDSource(r io.Reader) (restore func()) {
+	idMu.Lock()
+	defer idMu.Unlock()
+	prev := idSource
+	idSource = r
+	return func() {
+		idMu.Lock()
+		defer idMu.Unlock()
+		idSource = prev
+	}
+}
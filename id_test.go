@@ -0,0 +1,19 @@
+package rakuda
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewID(t *testing.T) {
+	restore := SetIDSource(bytes.NewReader([]byte{0x01, 0x02, 0x03, 0xff}))
+	got := NewID(4)
+	restore()
+
+	if want := "010203ff"; got != want {
+		t.Errorf("NewID(4): got %q, want %q", got, want)
+	}
+	if got := NewID(4); len(got) != 8 || got == "010203ff" {
+		t.Errorf("NewID(4) after restore: got %q, want a random ID", got)
+	}
+}
@@ -0,0 +1,219 @@
+package rakuda
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader is the request header carrying the idempotency key of a request.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Errors returned by actions wrapped with Idempotent, wrapped in *APIError.
+var (
+	// ErrIdempotencyInProgress is returned (409 Conflict) while another request with the same key is running.
+	ErrIdempotencyInProgress = errors.New("a request with the same idempotency key is in progress")
+	// ErrIdempotencyKeyReused is returned (422 Unprocessable Entity) when a key is reused for a different request.
+	ErrIdempotencyKeyReused = errors.New("the idempotency key was used for a different request")
+)
+
+// IdempotencyRecord is the stored result of an idempotent action.
+type IdempotencyRecord struct {
+	// Fingerprint identifies the request (method, path, query, and body) that produced the result.
+	Fingerprint string
+	// Body is the JSON-encoded result of the action.
+	Body json.RawMessage
+}
+
+// IdempotencyStore keeps the results of actions wrapped with Idempotent, by idempotency key.
+// Implementations backed by a shared database make retries safe across processes.
+type IdempotencyStore interface {
+	// Reserve claims key for a request with the given fingerprint. It returns (nil, nil)
+	// if the key was claimed, the stored record if the action has already completed for key,
+	// or ErrIdempotencyInProgress if the key is claimed by another request.
+	Reserve(ctx context.Context, key, fingerprint string) (*IdempotencyRecord, error)
+	// Save stores the result for a claimed key.
+	Save(ctx context.Context, key string, record IdempotencyRecord) error
+	// Release drops the claim of a key whose action failed, so that the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// Idempotent wraps a Lift action so that retried requests with the same Idempotency-Key
+// header run the action only once: the first successful result is stored in store and
+// replayed for the retries, decoded from its JSON form. Requests without the header run
+// the action as usual.
+//
+//	store := rakuda.NewMemoryIdempotencyStore(24 * time.Hour)
+//	b.Post("/orders", rakuda.Lift(responder, rakuda.Idempotent(store, createOrder)))
+//
+// Keys are scoped by the principal of the request (see PrincipalFromContext), so that
+// a caller can never replay the result stored for another caller with the same key.
+//
+// A failed or panicking action releases the key, so that the client can retry it. While the first
+// request is running, retries fail with 409 Conflict; a key reused for a request with
+// a different method, path, query, or body fails with 422 Unprocessable Entity.
+// Bodies of requests with the header are buffered to compute the fingerprint, so they are
+// limited to 1 MB; larger ones fail with 413 Content Too Large.
+func Idempotent[O any](store IdempotencyStore, action func(*http.Request) (O, error)) func(*http.Request) (O, error) {
+	return func(r *http.Request) (O, error) {
+		var zero O
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			return action(r)
+		}
+		ctx := r.Context()
+		if p, ok := PrincipalFromContext(ctx); ok && p != nil {
+			key = p.Kind + "\x00" + p.ID + "\x00" + key
+		}
+
+		fingerprint, err := idempotencyFingerprint(r)
+		if err != nil {
+			if errors.Is(err, errIdempotentBodyTooLarge) {
+				return zero, NewAPIError(http.StatusRequestEntityTooLarge, err)
+			}
+			return zero, NewAPIError(http.StatusBadRequest, err)
+		}
+		record, err := store.Reserve(ctx, key, fingerprint)
+		if err != nil {
+			if errors.Is(err, ErrIdempotencyInProgress) {
+				return zero, NewAPIError(http.StatusConflict, err)
+			}
+			return zero, err
+		}
+		if record != nil {
+			if record.Fingerprint != fingerprint {
+				return zero, NewAPIError(http.StatusUnprocessableEntity, ErrIdempotencyKeyReused)
+			}
+			var replayed O
+			if err := json.Unmarshal(record.Body, &replayed); err != nil {
+				return zero, err
+			}
+			return replayed, nil
+		}
+
+		release := func() {
+			if rerr := store.Release(ctx, key); rerr != nil {
+				LoggerFromContext(ctx).ErrorContext(ctx, "failed to release idempotency key", "key", key, "error", rerr)
+			}
+		}
+		defer func() {
+			if rec := recover(); rec != nil {
+				release() // otherwise, retries would fail with 409 until the key expires
+				panic(rec)
+			}
+		}()
+		result, err := action(r)
+		if err != nil {
+			release()
+			return zero, err
+		}
+		body, err := json.Marshal(result)
+		if err == nil {
+			err = store.Save(ctx, key, IdempotencyRecord{Fingerprint: fingerprint, Body: body})
+		}
+		if err != nil {
+			// The effect has happened, so return the result; retries will run the action again.
+			LoggerFromContext(ctx).ErrorContext(ctx, "failed to save idempotent result", "key", key, "error", err)
+			release()
+		}
+		return result, nil
+	}
+}
+
+// idempotentMaxBodySize is the maximum size of the body of a request with an idempotency key.
+const idempotentMaxBodySize = 1 << 20
+
+var errIdempotentBodyTooLarge = fmt.Errorf("request body with an idempotency key exceeds %d bytes", idempotentMaxBodySize)
+
+// idempotencyFingerprint hashes the method, path, query, and body of r. The body is restored for the action.
+func idempotencyFingerprint(r *http.Request) (string, error) {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+"\n")
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(io.LimitReader(r.Body, idempotentMaxBodySize+1))
+		if err != nil {
+			return "", err
+		}
+		if len(body) > idempotentMaxBodySize {
+			return "", errIdempotentBodyTooLarge
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		h.Write(body)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore that keeps results for a fixed TTL,
+// measured with the clock of the request context (see Now). Results are not shared across processes.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*idempotencyEntry
+	sweep   time.Time
+}
+
+type idempotencyEntry struct {
+	record  *IdempotencyRecord // nil while in progress
+	expires time.Time
+}
+
+var _ IdempotencyStore = (*MemoryIdempotencyStore)(nil)
+
+// NewMemoryIdempotencyStore creates a new MemoryIdempotencyStore that keeps results for ttl.
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	if ttl <= 0 {
+		panic("rakuda: MemoryIdempotencyStore ttl must be positive")
+	}
+	return &MemoryIdempotencyStore{ttl: ttl, entries: map[string]*idempotencyEntry{}}
+}
+
+// Reserve implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, key, fingerprint string) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := Now(ctx)
+	if now.Sub(s.sweep) > time.Minute {
+		for k, e := range s.entries {
+			if !now.Before(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.sweep = now
+	}
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		if e.record == nil {
+			return nil, ErrIdempotencyInProgress
+		}
+		return e.record, nil
+	}
+	s.entries[key] = &idempotencyEntry{expires: now.Add(s.ttl)}
+	return nil, nil
+}
+
+// Save implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Save(ctx context.Context, key string, record IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = &idempotencyEntry{record: &record, expires: Now(ctx).Add(s.ttl)}
+	return nil
+}
+
+// Release implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
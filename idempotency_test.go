@@ -0,0 +1,190 @@
+package rakuda
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIdempotent(t *testing.T) {
+	type order struct {
+		ID   int    `json:"id"`
+		Item string `json:"item"`
+	}
+
+	newHandler := func(fail *bool) (http.Handler, *int) {
+		calls := 0
+		store := NewMemoryIdempotencyStore(time.Hour)
+		action := func(r *http.Request) (*order, error) {
+			if *fail {
+				return nil, errors.New("database is down")
+			}
+			calls++
+			return &order{ID: calls, Item: "book"}, nil
+		}
+		return Lift(NewResponder(), Idempotent(store, action)), &calls
+	}
+	do := func(h http.Handler, key, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("retries replay the first result", func(t *testing.T) {
+		fail := false
+		h, calls := newHandler(&fail)
+		first := do(h, "k1", "/orders", `{"item":"book"}`)
+		second := do(h, "k1", "/orders", `{"item":"book"}`)
+		if diff := cmp.Diff(first.Body.String(), second.Body.String()); diff != "" {
+			t.Errorf("replayed body mismatch (-first +second):\n%s", diff)
+		}
+		if *calls != 1 {
+			t.Errorf("calls: got %d, want 1", *calls)
+		}
+		do(h, "k2", "/orders", `{"item":"book"}`)
+		do(h, "", "/orders", `{"item":"book"}`)
+		if *calls != 3 {
+			t.Errorf("calls: got %d, want 3", *calls)
+		}
+	})
+
+	t.Run("reused key", func(t *testing.T) {
+		fail := false
+		h, _ := newHandler(&fail)
+		do(h, "k1", "/orders", `{"item":"book"}`)
+		if got := do(h, "k1", "/orders", `{"item":"pen"}`).Code; got != http.StatusUnprocessableEntity {
+			t.Errorf("status code: got %d, want %d", got, http.StatusUnprocessableEntity)
+		}
+	})
+
+	t.Run("query is part of the fingerprint", func(t *testing.T) {
+		fail := false
+		h, calls := newHandler(&fail)
+		do(h, "k1", "/orders?dry_run=true", `{"item":"book"}`)
+		if got := do(h, "k1", "/orders", `{"item":"book"}`).Code; got != http.StatusUnprocessableEntity {
+			t.Errorf("status code: got %d, want %d", got, http.StatusUnprocessableEntity)
+		}
+		if *calls != 1 {
+			t.Errorf("calls: got %d, want 1", *calls)
+		}
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		fail := false
+		h, calls := newHandler(&fail)
+		if got := do(h, "k1", "/orders", strings.Repeat("x", idempotentMaxBodySize+1)).Code; got != http.StatusRequestEntityTooLarge {
+			t.Errorf("status code: got %d, want %d", got, http.StatusRequestEntityTooLarge)
+		}
+		if *calls != 0 {
+			t.Errorf("calls: got %d, want 0", *calls)
+		}
+	})
+
+	t.Run("failures release the key", func(t *testing.T) {
+		fail := true
+		h, calls := newHandler(&fail)
+		if got := do(h, "k1", "/orders", `{}`).Code; got != http.StatusInternalServerError {
+			t.Errorf("status code: got %d, want %d", got, http.StatusInternalServerError)
+		}
+		fail = false
+		if got := do(h, "k1", "/orders", `{}`).Code; got != http.StatusOK {
+			t.Errorf("status code: got %d, want %d", got, http.StatusOK)
+		}
+		if *calls != 1 {
+			t.Errorf("calls: got %d, want 1", *calls)
+		}
+	})
+
+	t.Run("panics release the key", func(t *testing.T) {
+		store := NewMemoryIdempotencyStore(time.Hour)
+		panicking := true
+		h := Lift(NewResponder(), Idempotent(store, func(r *http.Request) (*order, error) {
+			if panicking {
+				panic("boom")
+			}
+			return &order{ID: 1}, nil
+		}))
+		func() {
+			defer func() {
+				if rec := recover(); rec != "boom" {
+					t.Errorf("expected the panic to propagate, got %v", rec)
+				}
+			}()
+			do(h, "k1", "/orders", `{}`)
+		}()
+		panicking = false
+		if got := do(h, "k1", "/orders", `{}`).Code; got != http.StatusOK {
+			t.Errorf("status code: got %d, want %d", got, http.StatusOK)
+		}
+	})
+
+	t.Run("keys are scoped by principal", func(t *testing.T) {
+		fail := false
+		h, calls := newHandler(&fail)
+		as := func(id string) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				h.ServeHTTP(w, r.WithContext(NewContextWithPrincipal(r.Context(), &Principal{ID: id, Kind: "user"})))
+			})
+		}
+		alice := do(as("alice"), "k1", "/orders", `{}`)
+		bob := do(as("bob"), "k1", "/orders", `{}`)
+		if alice.Body.String() == bob.Body.String() {
+			t.Errorf("bob replayed the result of alice: %s", bob.Body.String())
+		}
+		if *calls != 2 {
+			t.Errorf("calls: got %d, want 2", *calls)
+		}
+	})
+
+	t.Run("in progress", func(t *testing.T) {
+		store := NewMemoryIdempotencyStore(time.Hour)
+		release := make(chan struct{})
+		started := make(chan struct{})
+		action := func(r *http.Request) (*order, error) {
+			close(started)
+			<-release
+			return &order{ID: 1}, nil
+		}
+		h := Lift(NewResponder(), Idempotent(store, action))
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			do(h, "k1", "/orders", `{}`)
+		}()
+		<-started
+		if got := do(h, "k1", "/orders", `{}`).Code; got != http.StatusConflict {
+			t.Errorf("status code: got %d, want %d", got, http.StatusConflict)
+		}
+		close(release)
+		<-done
+	})
+}
+
+func TestMemoryIdempotencyStoreExpiry(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := NewContextWithClock(t.Context(), ClockFunc(func() time.Time { return now }))
+	store := NewMemoryIdempotencyStore(time.Hour)
+
+	if _, err := store.Reserve(ctx, "k1", "f"); err != nil {
+		t.Fatalf("Reserve() failed: %v", err)
+	}
+	if err := store.Save(ctx, "k1", IdempotencyRecord{Fingerprint: "f", Body: []byte(`1`)}); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	if got, _ := store.Reserve(ctx, "k1", "f"); got == nil {
+		t.Errorf("Reserve() before expiry: got nil, want the stored record")
+	}
+	now = now.Add(2 * time.Hour)
+	if got, _ := store.Reserve(ctx, "k1", "f"); got != nil {
+		t.Errorf("Reserve() after expiry: got %v, want nil", got)
+	}
+}
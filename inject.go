@@ -0,0 +1,49 @@
+package rakuda
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// providedKey is the key of a dependency of type T. Being a distinct comparable type for
+// each T, it lets Inject look dependencies up without reflection.
+type providedKey[T any] struct{}
+
+// Provide binds a dependency of type T (e.g., *sql.DB or an API client) to the routing tree
+// of b, so that handlers can receive it with Inject instead of reading a global variable:
+//
+//	rakuda.Provide(b, db)
+//	rakuda.Provide(b, rakuda.NewResponder())
+//
+//	func listUsers(w http.ResponseWriter, r *http.Request) {
+//		db := rakuda.Inject[*sql.DB](r)
+//		...
+//	}
+//
+// Dependencies are bound at Build time and shared by all routes of the tree, including
+// those of child builders. Providing the same type again replaces the value.
+func Provide[T any](b *Builder, value T) {
+	b.checkFrozen(callerSource(2))
+	if b.state.dependencies == nil {
+		b.state.dependencies = map[any]any{}
+	}
+	b.state.dependencies[providedKey[T]{}] = value
+}
+
+// Inject returns the dependency of type T provided with Provide to the builder of the route
+// serving r. A missing dependency is a programming error, so it panics; use InjectOK to check.
+func Inject[T any](r *http.Request) T {
+	v, ok := InjectOK[T](r)
+	if !ok {
+		panic(fmt.Sprintf("rakuda: no dependency of type %s is provided", strings.TrimPrefix(fmt.Sprintf("%T", (*T)(nil)), "*")))
+	}
+	return v
+}
+
+// InjectOK returns the dependency of type T provided with Provide, reporting whether it was found.
+func InjectOK[T any](r *http.Request) (T, bool) {
+	dependencies, _ := r.Context().Value(dependencyKey).(map[any]any)
+	v, ok := dependencies[providedKey[T]{}].(T)
+	return v, ok
+}
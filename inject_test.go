@@ -0,0 +1,63 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProvideAndInject(t *testing.T) {
+	type greeter struct{ greeting string }
+	type repository interface{ Name() string }
+
+	b := NewBuilder()
+	Provide(b, &greeter{greeting: "hello"})
+	b.Route("/api", func(b *Builder) {
+		b.Get("/greet", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			g := Inject[*greeter](r)
+			if _, ok := InjectOK[repository](r); ok {
+				t.Error("InjectOK[repository]: expected false for a type that is not provided")
+			}
+			w.Write([]byte(g.greeting))
+		}))
+	})
+	b.Get("/missing", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if msg, _ := recover().(string); !strings.Contains(msg, "no dependency of type rakuda.repository") {
+				t.Errorf("unexpected panic: %q", msg)
+			}
+		}()
+		Inject[repository](r)
+	}))
+	h, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/greet", nil))
+	if got, want := rr.Body.String(), "hello"; got != want {
+		t.Errorf("body: got %q, want %q", got, want)
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	t.Run("clone", func(t *testing.T) {
+		b2 := b.Clone()
+		Provide(b2, &greeter{greeting: "hi"})
+		h2, err := b2.Build()
+		if err != nil {
+			t.Fatalf("Build() failed: %v", err)
+		}
+		rr := httptest.NewRecorder()
+		h2.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/greet", nil))
+		if got, want := rr.Body.String(), "hi"; got != want {
+			t.Errorf("body: got %q, want %q", got, want)
+		}
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/greet", nil))
+		if got, want := rr.Body.String(), "hello"; got != want {
+			t.Errorf("body of the original: got %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package signedcookie
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	secret := []byte("s3cret")
+	type data struct {
+		Name string `json:"name"`
+	}
+	value, err := Encode(secret, "session", data{Name: "foo"})
+	if err != nil {
+		t.Fatalf("Encode() failed: %v", err)
+	}
+
+	var got data
+	if err := Decode(secret, "session", value, &got); err != nil {
+		t.Fatalf("Decode() failed: %v", err)
+	}
+	if got.Name != "foo" {
+		t.Errorf("name: got %q, want %q", got.Name, "foo")
+	}
+
+	payload, _, _ := strings.Cut(value, ".")
+	tests := []struct {
+		name    string
+		secret  []byte
+		purpose string
+		value   string
+	}{
+		{"other purpose", secret, "login", value},
+		{"other secret", []byte("other"), "session", value},
+		{"empty secret", nil, "session", value},
+		{"unsigned", secret, "session", payload},
+		{"empty signature", secret, "session", payload + "."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v data
+			if err := Decode(tt.secret, tt.purpose, tt.value, &v); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Decode() error: got %v, want ErrInvalid", err)
+			}
+		})
+	}
+
+	if _, err := Encode(nil, "session", data{}); err == nil {
+		t.Error("Encode() with an empty secret: expected an error")
+	}
+}
@@ -12,6 +12,7 @@ import (
 type APIError struct {
 	err    error
 	status int
+	code   Code    // canonical code, if created with CodeError
 	pc     uintptr // program counter
 }
 
@@ -45,6 +46,15 @@ func (e *APIError) StatusCode() int {
 	return e.status
 }
 
+// Code returns the canonical code of the error.
+// If the error was not created with CodeError, the code is derived from the status code.
+func (e *APIError) Code() Code {
+	if e.code != CodeOK {
+		return e.code
+	}
+	return CodeFromHTTPStatus(e.status)
+}
+
 // PC returns the program counter where the error was created.
 func (e *APIError) PC() uintptr {
 	return e.pc
@@ -87,8 +97,18 @@ func (e *RedirectError) Error() string {
 //   - For `nil` maps, it returns `200 OK` with an empty JSON object `{}`.
 //   - For `nil` slices, it returns `200 OK` with an empty JSON array `[]`.
 //   - For other nillable types (e.g., pointers), it returns `204 No Content`.
+//
+// If a Responder is set in the request context (see Builder.UseResponder), it is used
+// instead of responder. responder may be nil, in which case a default one is used.
 func Lift[O any](responder *Responder, action func(*http.Request) (O, error)) http.Handler {
+	if responder == nil {
+		responder = NewResponder()
+	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		responder := responder
+		if scoped, ok := ResponderFromContext(r.Context()); ok {
+			responder = scoped
+		}
 		data, err := action(r)
 		if err != nil {
 			var redirectErr *RedirectError
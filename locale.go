@@ -0,0 +1,191 @@
+package rakuda
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DisplayQueryParam is the query parameter with which clients request localized display
+// strings (e.g., "?display" or "?display=true"). See DisplayTime and DisplayNumber.
+const DisplayQueryParam = "display"
+
+// Locale is the locale negotiated for a request.
+type Locale struct {
+	// Tag is the language tag, e.g., "en" or "de-AT", one of the supported tags.
+	Tag string
+	// Display reports whether the client requested display strings with DisplayQueryParam.
+	Display bool
+}
+
+// LocaleFormat is how numbers and dates are displayed in a locale.
+type LocaleFormat struct {
+	// Decimal and Group are the decimal and digit group separators, e.g., "." and "," in English.
+	Decimal, Group string
+	// DateTime is the time.Format layout of dates with times.
+	DateTime string
+}
+
+// LocaleFormats are the formats by language tag (lowercase). A tag without a format uses that of
+// its base language (e.g., "de" for "de-at"), and then that of "en". Add entries at initialization.
+var LocaleFormats = map[string]LocaleFormat{
+	"en":    {Decimal: ".", Group: ",", DateTime: "Jan 2, 2006, 3:04 PM"},
+	"en-gb": {Decimal: ".", Group: ",", DateTime: "02/01/2006 15:04"},
+	"de":    {Decimal: ",", Group: ".", DateTime: "02.01.2006 15:04"},
+	"es":    {Decimal: ",", Group: ".", DateTime: "02/01/2006 15:04"},
+	"fr":    {Decimal: ",", Group: " ", DateTime: "02/01/2006 15:04"},
+	"ja":    {Decimal: ".", Group: ",", DateTime: "2006/01/02 15:04"},
+	"zh":    {Decimal: ".", Group: ",", DateTime: "2006/01/02 15:04"},
+}
+
+// Localize returns a middleware that negotiates the locale of each request from the
+// Accept-Language header among the supported language tags, the first of which is the
+// default, and stores it in the context (see LocaleFromContext). It sets the Content-Language
+// and Vary headers of the response.
+//
+//	b.Use(rakuda.Localize("en", "de", "ja"))
+func Localize(supported ...string) Middleware {
+	if len(supported) == 0 {
+		panic("rakuda: Localize requires at least one supported language tag")
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			locale := NegotiateLocale(r, supported...)
+			w.Header().Set("Content-Language", locale.Tag)
+			w.Header().Add("Vary", "Accept-Language")
+			next.ServeHTTP(w, r.WithContext(NewContextWithLocale(r.Context(), locale)))
+		})
+	}
+}
+
+// NegotiateLocale returns the supported language tag with the highest quality in the
+// Accept-Language header of the request, matching a tag or its base language (e.g., "de-AT"
+// is served by "de"), or the first supported tag if none matches.
+func NegotiateLocale(r *http.Request, supported ...string) Locale {
+	_, display := r.URL.Query()[DisplayQueryParam]
+	if display {
+		if v := r.URL.Query().Get(DisplayQueryParam); v != "" {
+			display, _ = strconv.ParseBool(v)
+		}
+	}
+	locale := Locale{Display: display}
+	if len(supported) > 0 {
+		locale.Tag = supported[0]
+	}
+
+	bestQ := 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		q := 1.0
+		if name, value, _ := strings.Cut(strings.TrimSpace(params), "="); strings.EqualFold(name, "q") {
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				q = v
+			}
+		}
+		if tag == "" || tag == "*" || q <= bestQ {
+			continue
+		}
+		if match, ok := matchLanguage(tag, supported); ok {
+			locale.Tag, bestQ = match, q
+		}
+	}
+	return locale
+}
+
+// matchLanguage returns the supported tag equal to tag, or else the one equal to its base language.
+func matchLanguage(tag string, supported []string) (string, bool) {
+	base, _, _ := strings.Cut(tag, "-")
+	for _, want := range []string{tag, base} {
+		for _, s := range supported {
+			if strings.EqualFold(s, want) {
+				return s, true
+			}
+		}
+	}
+	return "", false
+}
+
+// NewContextWithLocale returns a new context with the provided Locale.
+func NewContextWithLocale(ctx context.Context, locale Locale) context.Context {
+	return context.WithValue(ctx, localeKey, locale)
+}
+
+// LocaleFromContext retrieves the Locale from the context.
+func LocaleFromContext(ctx context.Context) (Locale, bool) {
+	locale, ok := ctx.Value(localeKey).(Locale)
+	return locale, ok
+}
+
+// DisplayTime returns t formatted for the locale of the request, if the client requested
+// display strings (see DisplayQueryParam), and "" otherwise. Responses keep the time in
+// RFC 3339 and add the display string next to it, for UI layers:
+//
+//	type Order struct {
+//		CreatedAt        time.Time `json:"createdAt"`
+//		CreatedAtDisplay string    `json:"createdAtDisplay,omitempty"`
+//	}
+//
+//	order.CreatedAtDisplay = rakuda.DisplayTime(r.Context(), order.CreatedAt)
+func DisplayTime(ctx context.Context, t time.Time) string {
+	locale, ok := LocaleFromContext(ctx)
+	if !ok || !locale.Display {
+		return ""
+	}
+	return FormatTime(locale.Tag, t)
+}
+
+// DisplayNumber is like DisplayTime, but for a number with the given number of decimals.
+func DisplayNumber(ctx context.Context, n float64, decimals int) string {
+	locale, ok := LocaleFromContext(ctx)
+	if !ok || !locale.Display {
+		return ""
+	}
+	return FormatNumber(locale.Tag, n, decimals)
+}
+
+// FormatTime formats t, in its location, for the language tag. See LocaleFormats.
+func FormatTime(tag string, t time.Time) string {
+	return t.Format(localeFormat(tag).DateTime)
+}
+
+// FormatNumber formats n with the given number of decimals and the separators of
+// the language tag, e.g., "1,234.50" in English and "1.234,50" in German. See LocaleFormats.
+func FormatNumber(tag string, n float64, decimals int) string {
+	format := localeFormat(tag)
+	s := strconv.FormatFloat(n, 'f', decimals, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	integer, fraction, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, c := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(format.Group)
+		}
+		b.WriteRune(c)
+	}
+	if fraction != "" {
+		b.WriteString(format.Decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// localeFormat returns the format of the tag, its base language, or English.
+func localeFormat(tag string) LocaleFormat {
+	tag = strings.ToLower(tag)
+	if format, ok := LocaleFormats[tag]; ok {
+		return format
+	}
+	base, _, _ := strings.Cut(tag, "-")
+	if format, ok := LocaleFormats[base]; ok {
+		return format
+	}
+	return LocaleFormats["en"]
+}
@@ -0,0 +1,102 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNegotiateLocale(t *testing.T) {
+	supported := []string{"en", "de", "ja"}
+	tests := []struct {
+		acceptLanguage string
+		query          string
+		want           Locale
+	}{
+		{acceptLanguage: "", want: Locale{Tag: "en"}},
+		{acceptLanguage: "de", want: Locale{Tag: "de"}},
+		{acceptLanguage: "de-AT", want: Locale{Tag: "de"}},
+		{acceptLanguage: "fr, ja;q=0.8, de;q=0.5", want: Locale{Tag: "ja"}},
+		{acceptLanguage: "*", want: Locale{Tag: "en"}},
+		{acceptLanguage: "ja", query: "?display", want: Locale{Tag: "ja", Display: true}},
+		{acceptLanguage: "ja", query: "?display=false", want: Locale{Tag: "ja"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage+tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			if diff := cmp.Diff(tt.want, NegotiateLocale(req, supported...)); diff != "" {
+				t.Errorf("NegotiateLocale() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		tag      string
+		n        float64
+		decimals int
+		want     string
+	}{
+		{tag: "en", n: 1234.5, decimals: 2, want: "1,234.50"},
+		{tag: "de", n: 1234.5, decimals: 2, want: "1.234,50"},
+		{tag: "de-AT", n: -1234567, decimals: 0, want: "-1.234.567"},
+		{tag: "xx", n: 999, decimals: 1, want: "999.0"},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, FormatNumber(tt.tag, tt.n, tt.decimals)); diff != "" {
+			t.Errorf("FormatNumber(%q, %v, %d) mismatch (-want +got):\n%s", tt.tag, tt.n, tt.decimals, diff)
+		}
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	createdAt := time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC)
+	type order struct {
+		CreatedAt        time.Time `json:"createdAt"`
+		CreatedAtDisplay string    `json:"createdAtDisplay,omitempty"`
+		Total            float64   `json:"total"`
+		TotalDisplay     string    `json:"totalDisplay,omitempty"`
+	}
+
+	b := NewBuilder()
+	b.Use(Localize("en", "de"))
+	b.Get("/order", Lift(NewResponder(), func(r *http.Request) (order, error) {
+		return order{
+			CreatedAt:        createdAt,
+			CreatedAtDisplay: DisplayTime(r.Context(), createdAt),
+			Total:            1234.5,
+			TotalDisplay:     DisplayNumber(r.Context(), 1234.5, 2),
+		}, nil
+	}))
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/order", want: `{"createdAt":"2024-03-05T14:30:00Z","total":1234.5}` + "\n"},
+		{path: "/order?display", want: `{"createdAt":"2024-03-05T14:30:00Z","createdAtDisplay":"05.03.2024 14:30","total":1234.5,"totalDisplay":"1.234,50"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept-Language", "de-DE,de;q=0.9,en;q=0.5")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if diff := cmp.Diff(tt.want, rr.Body.String()); diff != "" {
+				t.Errorf("body mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff("de", rr.Header().Get("Content-Language")); diff != "" {
+				t.Errorf("Content-Language mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
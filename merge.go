@@ -0,0 +1,70 @@
+package rakuda
+
+import (
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Merge grafts the routing tree of another Builder under prefix, so that packages
+// can build their routes independently and be composed at the root:
+//
+//	root.Merge("/billing", billing.NewBuilder())
+//
+// The routes of other keep their middlewares, which apply only to them, and its NotFound
+// handler serves the unmatched requests under prefix. Its configuration (logger, conflict
+// handling, and so on) and absorbed muxes are not carried over; those of b apply.
+// The tree is shared, not copied, so other should not be modified after the merge.
+func (b *Builder) Merge(prefix string, other *Builder) {
+	if other.node == b.node {
+		panic("rakuda: cannot merge a Builder into itself")
+	}
+	source := callerSource(2)
+	b.checkFrozen(source)
+	childNode := &node{
+		pattern:  prefix,
+		source:   source,
+		isRoute:  prefix != "", // merging at the root is not an empty Route
+		actions:  b.inlineActions(),
+		children: []*node{other.node},
+		notFound: other.notFoundHandler,
+	}
+	b.node.children = append(b.node.children, childNode)
+}
+
+// scopedHandler is a handler that serves the requests under a path prefix.
+type scopedHandler struct {
+	prefix  string
+	handler http.Handler
+}
+
+// match reports whether the path of r is under the prefix.
+func (s scopedHandler) match(r *http.Request) bool {
+	if s.prefix == "/" {
+		return true
+	}
+	return r.URL.Path == s.prefix || strings.HasPrefix(r.URL.Path, s.prefix+"/")
+}
+
+// scopedNotFoundHandlers collects the NotFound handlers of merged builders,
+// longest prefix first.
+func (b *Builder) scopedNotFoundHandlers() []scopedHandler {
+	var handlers []scopedHandler
+	var traverse func(n *node, prefix string)
+	traverse = func(n *node, prefix string) {
+		if n.disabled {
+			return
+		}
+		prefix = path.Join(prefix, n.pattern)
+		if n.notFound != nil {
+			handlers = append(handlers, scopedHandler{prefix: prefix, handler: n.notFound})
+		}
+		for _, child := range n.children {
+			traverse(child, prefix)
+		}
+	}
+	traverse(b.node, "/")
+	slices.SortStableFunc(handlers, func(a, b scopedHandler) int { return len(b.prefix) - len(a.prefix) })
+	return handlers
+}
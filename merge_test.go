@@ -0,0 +1,80 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMerge(t *testing.T) {
+	var calls []string
+	mw := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	respond := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(body)) })
+	}
+
+	// Built independently, e.g., in a billing package.
+	billing := NewBuilder()
+	billing.Use(mw("billing"))
+	billing.Get("/invoices", respond("invoices"))
+	billing.NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("no such billing resource"))
+	}))
+
+	root := NewBuilder()
+	root.Use(mw("root"))
+	root.Get("/health", respond("ok"))
+	root.Merge("/billing", billing)
+	router, err := root.Build()
+	if err != nil {
+		t.Fatalf("root.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+		wantCalls  []string
+	}{
+		{name: "merged route", path: "/billing/invoices", wantStatus: http.StatusOK, wantBody: "invoices", wantCalls: []string{"root", "billing"}},
+		{name: "root route", path: "/health", wantStatus: http.StatusOK, wantBody: "ok", wantCalls: []string{"root"}},
+		{name: "merged NotFound", path: "/billing/unknown", wantStatus: http.StatusNotFound, wantBody: "no such billing resource"},
+		{name: "root NotFound", path: "/unknown", wantStatus: http.StatusNotFound, wantBody: `{"error":"not found"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if got := rr.Body.String(); got != tt.wantBody {
+				t.Errorf("body: got %q, want %q", got, tt.wantBody)
+			}
+			if diff := cmp.Diff(tt.wantCalls, calls); diff != "" {
+				t.Errorf("middleware calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("walk", func(t *testing.T) {
+		var got []string
+		root.Walk(func(method, pattern string) { got = append(got, method+" "+pattern) })
+		if diff := cmp.Diff([]string{"GET /health", "GET /billing/invoices"}, got); diff != "" {
+			t.Errorf("Walk() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
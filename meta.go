@@ -0,0 +1,113 @@
+package rakuda
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+// Meta holds declarative metadata attached to a route at registration time.
+//
+//	b.Get("/health", healthHandler, rakuda.Meta{Tags: []string{"public"}})
+//
+// The metadata of the matched route is available to middlewares via the
+// request context, so that a single global middleware can be driven by declarations.
+type Meta struct {
+	// Name identifies the route, e.g., for documentation (an operation ID) or metrics.
+	Name string
+	// Description is a human-readable description of the route.
+	Description string
+	// Tags is a list of free-form labels (e.g., "public", "admin").
+	Tags []string
+	// Scopes lists the permissions required to call the route (e.g., "users:read").
+	// rakuda does not enforce them; they are declarations for middlewares and documentation.
+	Scopes []string
+	// Consumes lists the media types accepted for request bodies. See Consumes.
+	Consumes []string
+	// Produces lists the media types of responses, in order of preference. See Produces.
+	Produces []string
+	// Extra holds arbitrary application-defined metadata.
+	Extra map[string]any
+}
+
+// HasTag reports whether the metadata includes the given tag.
+func (m Meta) HasTag(tag string) bool {
+	return slices.Contains(m.Tags, tag)
+}
+
+// mergeMeta merges multiple Meta values into one. Slices are concatenated,
+// maps are merged, and later non-empty strings override earlier ones.
+func mergeMeta(metas []Meta) Meta {
+	var merged Meta
+	for _, m := range metas {
+		if m.Name != "" {
+			merged.Name = m.Name
+		}
+		if m.Description != "" {
+			merged.Description = m.Description
+		}
+		merged.Tags = append(merged.Tags, m.Tags...)
+		merged.Scopes = append(merged.Scopes, m.Scopes...)
+		merged.Consumes = append(merged.Consumes, m.Consumes...)
+		merged.Produces = append(merged.Produces, m.Produces...)
+		for k, v := range m.Extra {
+			if merged.Extra == nil {
+				merged.Extra = map[string]any{}
+			}
+			merged.Extra[k] = v
+		}
+	}
+	return merged
+}
+
+// routeContext is the value stored in the context of the requests served by a route.
+type routeContext struct {
+	meta   Meta
+	router *routerRef // the router serving the route (see ServeNotFound)
+}
+
+// withRouteMeta returns a handler that stores the route's metadata and router in the request context.
+func withRouteMeta(meta Meta, router *routerRef, next http.Handler) http.Handler {
+	rc := &routeContext{meta: meta, router: router}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), routeMetaKey, rc)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RouteMetaFromContext retrieves the metadata of the matched route from the context.
+// It reports false if the request is not served by a registered route.
+// Handlers, binding code, and middlewares can use it to key off declarative
+// route information instead of duplicating it in handler bodies.
+func RouteMetaFromContext(ctx context.Context) (Meta, bool) {
+	rc, ok := ctx.Value(routeMetaKey).(*routeContext)
+	if !ok {
+		return Meta{}, false
+	}
+	return rc.meta, true
+}
+
+// Conditional returns a middleware that applies mw only when cond reports true
+// for the metadata of the matched route. For requests that are not served by a
+// registered route, cond receives a zero Meta.
+func Conditional(cond func(Meta) bool, mw Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			meta, _ := RouteMetaFromContext(r.Context())
+			if cond(meta) {
+				wrapped.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SkipTagged returns a middleware that applies mw to every route except those tagged with tag.
+// For example, a global auth middleware can be skipped for routes tagged "public":
+//
+//	b.Use(rakuda.SkipTagged("public", authMiddleware))
+func SkipTagged(tag string, mw Middleware) Middleware {
+	return Conditional(func(m Meta) bool { return !m.HasTag(tag) }, mw)
+}
@@ -0,0 +1,118 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestConditionalMiddleware(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	b := NewBuilder()
+	b.Use(SkipTagged("public", auth))
+	b.Get("/health", handler, Meta{Tags: []string{"public"}})
+	b.Get("/private", handler)
+	b.Route("/admin", func(b *Builder) {
+		b.Get("/stats", handler, Meta{Tags: []string{"admin"}}, Meta{Tags: []string{"internal"}})
+	})
+
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		wantCode int
+	}{
+		{"/health", http.StatusOK},
+		{"/private", http.StatusUnauthorized},
+		{"/admin/stats", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rr.Code != tt.wantCode {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestMergeMeta(t *testing.T) {
+	m := mergeMeta([]Meta{{Tags: []string{"a"}}, {Tags: []string{"b"}}})
+	if !m.HasTag("a") || !m.HasTag("b") || m.HasTag("c") {
+		t.Errorf("unexpected tags: %v", m.Tags)
+	}
+
+	got := mergeMeta([]Meta{
+		{Name: "listUsers", Description: "old", Scopes: []string{"users:read"}, Extra: map[string]any{"a": 1}},
+		{Description: "Lists users.", Scopes: []string{"admin"}, Extra: map[string]any{"a": 2, "b": true}},
+	})
+	want := Meta{
+		Name:        "listUsers",
+		Description: "Lists users.",
+		Scopes:      []string{"users:read", "admin"},
+		Extra:       map[string]any{"a": 2, "b": true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mergeMeta() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWalkRoutes(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	b := NewBuilder()
+	b.Route("/users", func(b *Builder) {
+		b.Get("/", handler, Meta{Name: "listUsers", Tags: []string{"users"}, Scopes: []string{"users:read"}})
+	})
+
+	var got []RouteInfo
+	b.WalkRoutes(func(r RouteInfo) { got = append(got, r) })
+	want := []RouteInfo{
+		{Method: "GET", Pattern: "/users/{$}", Meta: Meta{Name: "listUsers", Tags: []string{"users"}, Scopes: []string{"users:read"}}},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(RouteInfo{}, "Source", "Prefix", "Middlewares", "Chain", "Handler")); diff != "" {
+		t.Errorf("WalkRoutes() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRouteMetaFromContext(t *testing.T) {
+	var got Meta
+	var found bool
+	b := NewBuilder()
+	b.Get("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, found = RouteMetaFromContext(r.Context())
+	}), Meta{Tags: []string{"users"}})
+
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if !found {
+		t.Fatal("expected route metadata to be found")
+	}
+	if !got.HasTag("users") {
+		t.Errorf("expected tag %q, got %v", "users", got.Tags)
+	}
+
+	if _, ok := RouteMetaFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()); ok {
+		t.Error("expected no route metadata outside of a route")
+	}
+}
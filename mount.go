@@ -0,0 +1,34 @@
+package rakuda
+
+import (
+	"net/http"
+	"strings"
+)
+
+// mountWildcard is the name of the wildcard that captures the path below a mount point.
+const mountWildcard = "rakudaMountPath"
+
+// Mount attaches a foreign handler, such as another mux, a third-party admin UI, or
+// net/http/pprof, under prefix for all methods. The prefix is stripped from the request
+// path before it is passed to the handler, as http.StripPrefix does.
+// Middleware of the enclosing group is applied, and the mount point is reported by
+// Walk and PrintRoutes as a method-agnostic route.
+//
+//	b.Mount("/admin", adminMux) // "/admin/users" is served by adminMux as "/users"
+func (b *Builder) Mount(prefix string, handler http.Handler) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	b.addHandler("", prefix+"/{"+mountWildcard+"...}", &mountHandler{handler: handler}, callerSource(2))
+}
+
+type mountHandler struct {
+	handler http.Handler
+}
+
+func (h *mountHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The prefix is relative to the enclosing groups and may contain wildcards,
+	// so strip it by the matched wildcard.
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = "/" + r.PathValue(mountWildcard)
+	r2.URL.RawPath = ""
+	h.handler.ServeHTTP(w, r2)
+}
@@ -0,0 +1,71 @@
+package rakuda
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMount(t *testing.T) {
+	foreign := http.NewServeMux()
+	foreign.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path + " " + r.Header.Get("X-Group")))
+	})
+
+	b := NewBuilder()
+	b.Get("/admin/health", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("health"))
+	}))
+	b.Route("/api", func(b *Builder) {
+		b.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.Header.Set("X-Group", "api")
+				next.ServeHTTP(w, r)
+			})
+		})
+		b.Mount("/admin/", foreign)
+	})
+	b.Mount("/admin", foreign)
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		method   string
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{method: http.MethodGet, path: "/admin/users", wantCode: http.StatusOK, wantBody: "GET /users "},
+		{method: "PROPFIND", path: "/admin/a/b", wantCode: http.StatusOK, wantBody: "PROPFIND /a/b "},
+		{method: http.MethodGet, path: "/admin/", wantCode: http.StatusOK, wantBody: "GET / "},
+		{method: http.MethodGet, path: "/admin/health", wantCode: http.StatusOK, wantBody: "health"},
+		{method: http.MethodPost, path: "/api/admin/users", wantCode: http.StatusOK, wantBody: "POST /users api"},
+		{method: http.MethodGet, path: "/admin", wantCode: http.StatusTemporaryRedirect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+			if rr.Code != tt.wantCode {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+				t.Errorf("body: got %q, want %q", rr.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("PrintRoutes", func(t *testing.T) {
+		var buf bytes.Buffer
+		PrintRoutes(&buf, b)
+		want := "GET  /admin/health\n*    /admin/{rakudaMountPath...}\n*    /api/admin/{rakudaMountPath...}\n"
+		if diff := cmp.Diff(want, buf.String()); diff != "" {
+			t.Errorf("PrintRoutes() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package rakuda
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/podhmo/rakuda/binding"
+)
+
+// ItemResult is the outcome of one item of a bulk operation, e.g., one user of a bulk create.
+type ItemResult struct {
+	// ID identifies the item in the request, e.g., its index or key.
+	ID     string `json:"id,omitempty"`
+	Status int    `json:"status"`
+	Body   any    `json:"body,omitempty"`
+}
+
+// ItemFailure returns the ItemResult of an item that failed with err, rendered like
+// Responder.Error: the status is taken from a StatusCode() int method of err (500 if absent),
+// validation errors are rendered as is, and the message of 5xx errors is not exposed.
+func ItemFailure(id string, err error) ItemResult {
+	status := http.StatusInternalServerError
+	var sc interface{ StatusCode() int }
+	if errors.As(err, &sc) {
+		status = sc.StatusCode()
+	}
+
+	var vErrs *binding.ValidationErrors
+	switch {
+	case status >= http.StatusInternalServerError:
+		return ItemResult{ID: id, Status: status, Body: map[string]string{"error": "Internal Server Error"}}
+	case errors.As(err, &vErrs):
+		return ItemResult{ID: id, Status: status, Body: vErrs}
+	default:
+		return ItemResult{ID: id, Status: status, Body: map[string]string{"error": err.Error()}}
+	}
+}
+
+// MultiStatusResponse is the body of a 207 Multi-Status response, listing the outcome of
+// each item of a bulk operation. It is created with MultiStatus.
+type MultiStatusResponse struct {
+	Results []ItemResult `json:"results"`
+}
+
+// MultiStatus returns a response for the results of a bulk operation whose items can
+// succeed or fail independently. Its StatusCode is 207, so that Lift renders it as
+// a 207 Multi-Status response:
+//
+//	func bulkCreate(r *http.Request) (*rakuda.MultiStatusResponse, error) {
+//		var results []rakuda.ItemResult
+//		for i, u := range users {
+//			if err := store.Create(r.Context(), u); err != nil {
+//				results = append(results, rakuda.ItemFailure(strconv.Itoa(i), err))
+//				continue
+//			}
+//			results = append(results, rakuda.ItemResult{ID: strconv.Itoa(i), Status: http.StatusCreated, Body: u})
+//		}
+//		return rakuda.MultiStatus(results), nil
+//	}
+func MultiStatus(results []ItemResult) *MultiStatusResponse {
+	if results == nil {
+		results = []ItemResult{}
+	}
+	return &MultiStatusResponse{Results: results}
+}
+
+// StatusCode returns 207 Multi-Status.
+func (m *MultiStatusResponse) StatusCode() int {
+	return http.StatusMultiStatus
+}
+
+// MultiStatus sends a 207 Multi-Status response with the results of a bulk operation.
+func (r *Responder) MultiStatus(w http.ResponseWriter, req *http.Request, results []ItemResult) {
+	r.JSON(w, req, http.StatusMultiStatus, MultiStatus(results))
+}
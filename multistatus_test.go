@@ -0,0 +1,50 @@
+package rakuda
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda/binding"
+)
+
+func TestMultiStatus(t *testing.T) {
+	responder := NewResponder()
+	h := Lift(responder, func(r *http.Request) (*MultiStatusResponse, error) {
+		return MultiStatus([]ItemResult{
+			{ID: "0", Status: http.StatusCreated, Body: map[string]string{"name": "alice"}},
+			ItemFailure("1", NewAPIError(http.StatusConflict, errors.New("user already exists"))),
+			ItemFailure("2", &binding.ValidationErrors{Errors: []*binding.Error{{Source: binding.Body, Key: "name", Err: errors.New("required parameter is missing")}}}),
+			ItemFailure("3", errors.New("database is down")),
+		}), nil
+	})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/users/bulk", nil))
+
+	if got, want := rr.Code, http.StatusMultiStatus; got != want {
+		t.Errorf("status code: got %d, want %d", got, want)
+	}
+	want := `{"results":[` +
+		`{"id":"0","status":201,"body":{"name":"alice"}},` +
+		`{"id":"1","status":409,"body":{"error":"user already exists"}},` +
+		`{"id":"2","status":400,"body":{"errors":[{"message":"required parameter is missing","source":"body","key":"name","value":null}]}},` +
+		`{"id":"3","status":500,"body":{"error":"Internal Server Error"}}]}` + "\n"
+	if diff := cmp.Diff(want, rr.Body.String()); diff != "" {
+		t.Errorf("body mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestResponderMultiStatus(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewResponder().MultiStatus(rr, httptest.NewRequest(http.MethodPost, "/", nil), nil)
+
+	if got, want := rr.Code, http.StatusMultiStatus; got != want {
+		t.Errorf("status code: got %d, want %d", got, want)
+	}
+	if diff := cmp.Diff(`{"results":[]}`+"\n", rr.Body.String()); diff != "" {
+		t.Errorf("body mismatch (-want +got):\n%s", diff)
+	}
+}
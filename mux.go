@@ -0,0 +1,30 @@
+package rakuda
+
+import "net/http"
+
+// Mux is the request matcher of a built router. *http.ServeMux implements it and is used
+// by default; WithMux replaces it, e.g., with a radix-tree router when the matching rules
+// or the performance of ServeMux are not enough. The Builder, middlewares, and the 404,
+// 405, trailing-slash, and case-insensitive handling work the same with any Mux.
+type Mux interface {
+	// Handle registers the handler for a ServeMux pattern ("[METHOD ][HOST]/[PATH]").
+	Handle(pattern string, handler http.Handler)
+	// Handler returns the handler for the request and its pattern, or an empty pattern if
+	// no route matches. It must not modify r; it is called to probe other methods and paths.
+	Handler(r *http.Request) (h http.Handler, pattern string)
+	// ServeHTTP serves the request with the matching handler. Like ServeMux, it must set
+	// r.Pattern and the path values (see http.Request.SetPathValue) before calling it.
+	ServeHTTP(w http.ResponseWriter, r *http.Request)
+}
+
+var _ Mux = (*http.ServeMux)(nil)
+
+// WithMux makes Build register the routes to the Mux returned by newMux, instead of
+// a new http.ServeMux. newMux is called once per Build.
+//
+//	b := rakuda.NewBuilder(rakuda.WithMux(func() rakuda.Mux { return radix.New() }))
+func WithMux(newMux func() Mux) func(*BuilderConfig) {
+	return func(c *BuilderConfig) {
+		c.NewMux = newMux
+	}
+}
@@ -0,0 +1,88 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// countingMux is a Mux that records the registered patterns and the served requests.
+type countingMux struct {
+	*http.ServeMux
+	patterns []string
+
+	mu     sync.Mutex
+	served int
+}
+
+func (m *countingMux) Handle(pattern string, handler http.Handler) {
+	m.patterns = append(m.patterns, pattern)
+	m.ServeMux.Handle(pattern, handler)
+}
+
+func (m *countingMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	m.served++
+	m.mu.Unlock()
+	m.ServeMux.ServeHTTP(w, r)
+}
+
+func TestWithMux(t *testing.T) {
+	var mux *countingMux
+	b := NewBuilder(WithMux(func() Mux {
+		mux = &countingMux{ServeMux: http.NewServeMux()}
+		return mux
+	}))
+	b.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Middleware", "1")
+			next.ServeHTTP(w, r)
+		})
+	})
+	b.Get("/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.PathValue("id")))
+	}))
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"GET /users/{id}"}, mux.patterns); diff != "" {
+		t.Errorf("patterns mismatch (-want +got):\n%s", diff)
+	}
+
+	tests := []struct {
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+		wantHeader string
+	}{
+		{method: http.MethodGet, path: "/users/1", wantStatus: http.StatusOK, wantBody: "1", wantHeader: "1"},
+		{method: http.MethodPost, path: "/users/1", wantStatus: http.StatusMethodNotAllowed},
+		{method: http.MethodGet, path: "/posts", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+			if diff := cmp.Diff(tt.wantStatus, rr.Code); diff != "" {
+				t.Errorf("status mismatch (-want +got):\n%s", diff)
+			}
+			if tt.wantBody != "" {
+				if diff := cmp.Diff(tt.wantBody, rr.Body.String()); diff != "" {
+					t.Errorf("body mismatch (-want +got):\n%s", diff)
+				}
+			}
+			if diff := cmp.Diff(tt.wantHeader, rr.Header().Get("X-Middleware")); diff != "" {
+				t.Errorf("X-Middleware mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+	if diff := cmp.Diff(1, mux.served); diff != "" {
+		t.Errorf("served mismatch (-want +got):\n%s", diff)
+	}
+}
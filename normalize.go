@@ -0,0 +1,89 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NormalizeConfig holds the configuration for the Normalize middleware.
+type NormalizeConfig struct {
+	// LowercaseHost lowercases the Host header, e.g., for host-based routing (see Builder.Host).
+	LowercaseHost bool
+}
+
+// Normalize returns a middleware that normalizes the request path: duplicate slashes are
+// collapsed and dot segments ("." and "..") are resolved, keeping a trailing slash.
+// The request is rewritten rather than redirected, so it works for any method.
+// Install it with WithPreMatch, so that the normalized path is matched against the routes:
+//
+//	b := rakuda.NewBuilder(rakuda.WithPreMatch(rakuda.Normalize(nil)))
+//
+// r.RequestURI keeps the original request target. If config is nil, it uses the default settings.
+func Normalize(config *NormalizeConfig) Middleware {
+	if config == nil {
+		config = &NormalizeConfig{}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The escaped path is normalized, so that an encoded slash (%2F) stays in its
+			// segment instead of becoming a separator that ".." could climb across.
+			escaped := r.URL.EscapedPath()
+			rawPath := normalizeEscapedPath(escaped)
+			host := r.Host
+			if config.LowercaseHost {
+				host = strings.ToLower(host)
+			}
+			p, err := url.PathUnescape(rawPath)
+			if err != nil || (rawPath == escaped && host == r.Host) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			r2 := new(http.Request)
+			*r2 = *r
+			u := *r.URL
+			u.Path, u.RawPath = p, rawPath
+			r2.URL = &u
+			r2.Host = host
+			next.ServeHTTP(w, r2)
+		})
+	}
+}
+
+// normalizePath collapses duplicate slashes and resolves dot segments, keeping a trailing slash.
+func normalizePath(p string) string {
+	return cleanSegments(p, func(segment string) string { return segment })
+}
+
+// normalizeEscapedPath is like normalizePath, but for an escaped path (see url.URL.EscapedPath):
+// only literal slashes separate segments, and encoded dot segments (e.g., "%2E%2E") are resolved too.
+func normalizeEscapedPath(p string) string {
+	return cleanSegments(p, func(segment string) string {
+		if decoded, err := url.PathUnescape(segment); err == nil {
+			return decoded
+		}
+		return segment
+	})
+}
+
+// cleanSegments collapses empty segments and resolves the segments that decode to "." and "..".
+func cleanSegments(p string, decode func(string) string) string {
+	var segments []string
+	for _, segment := range strings.Split(p, "/") {
+		switch decode(segment) {
+		case "", ".":
+		case "..":
+			if len(segments) > 0 {
+				segments = segments[:len(segments)-1]
+			}
+		default:
+			segments = append(segments, segment)
+		}
+	}
+	cleaned := "/" + strings.Join(segments, "/")
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
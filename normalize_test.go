@@ -0,0 +1,106 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	respond := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(body)) })
+	}
+
+	b := NewBuilder(WithPreMatch(Normalize(&NormalizeConfig{LowercaseHost: true})))
+	b.Post("/users/{id}/posts", respond("posts"))
+	b.Get("/docs", respond("docs"))
+	b.Get("/public/{name}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("public:" + r.PathValue("name")))
+	}))
+	b.Get("/admin/secret", respond("secret"))
+	b.Host("api.example.com", func(b *Builder) {
+		b.Get("/v1", respond("api"))
+	})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		host       string
+		target     string
+		rawTarget  string // an escaped target, parsed as is
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "duplicate slashes", method: http.MethodPost, target: "//users//1/posts", wantStatus: http.StatusOK, wantBody: "posts"},
+		{name: "dot segments", method: http.MethodPost, target: "/users/1/./drafts/../posts", wantStatus: http.StatusOK, wantBody: "posts"},
+		{name: "leading slashes", method: http.MethodGet, target: "///docs", wantStatus: http.StatusOK, wantBody: "docs"},
+		{name: "encoded slashes are not separators", method: http.MethodGet, rawTarget: "/public/..%2Fadmin%2Fsecret", wantStatus: http.StatusOK, wantBody: "public:../admin/secret"},
+		{name: "encoded dot segments", method: http.MethodPost, rawTarget: "/users/1/drafts/%2E%2E/posts", wantStatus: http.StatusOK, wantBody: "posts"},
+		{name: "host is lowercased", method: http.MethodGet, host: "API.Example.com", target: "/v1", wantStatus: http.StatusOK, wantBody: "api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.URL.Path = tt.target // bypass the parsing of httptest.NewRequest
+			if tt.rawTarget != "" {
+				u, err := url.Parse(tt.rawTarget)
+				if err != nil {
+					t.Fatalf("url.Parse(%q) failed: %v", tt.rawTarget, err)
+				}
+				req.URL = u
+			}
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if got := rr.Body.String(); got != tt.wantBody {
+				t.Errorf("body: got %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: "", want: "/"},
+		{in: "/", want: "/"},
+		{in: "/a//b", want: "/a/b"},
+		{in: "/a/b//", want: "/a/b/"},
+		{in: "/a/./b/../c", want: "/a/c"},
+		{in: "/../a", want: "/a"},
+		{in: "/a/..", want: "/"},
+	}
+	for _, tt := range tests {
+		if got := normalizePath(tt.in); got != tt.want {
+			t.Errorf("normalizePath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeEscapedPath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: "/a//b/./c", want: "/a/b/c"},
+		{in: "/a/..%2Fb", want: "/a/..%2Fb"},
+		{in: "/a/b/%2e%2E/c", want: "/a/c"},
+		{in: "/a/%2E/b/", want: "/a/b/"},
+	}
+	for _, tt := range tests {
+		if got := normalizeEscapedPath(tt.in); got != tt.want {
+			t.Errorf("normalizeEscapedPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package rakuda
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RouteOverlapKind is the kind of a RouteOverlap.
+type RouteOverlapKind string
+
+// Kinds of route overlaps.
+const (
+	// OverlapShadow means that the paths of Specific are also matched by General, and
+	// ServeMux routes them to Specific, e.g., /users/me and /users/{id}: GET /users/me
+	// never reaches the handler of /users/{id}.
+	OverlapShadow RouteOverlapKind = "shadow"
+	// OverlapConflict means that some paths are matched by both routes and neither is more
+	// specific, e.g., /users/{id} and /users/{name}, or /{org}/repos and /users/{id}.
+	// ServeMux rejects such routes, so Build panics.
+	OverlapConflict RouteOverlapKind = "conflict"
+)
+
+// RouteOverlap is a pair of routes whose patterns match some of the same requests,
+// reported by Builder.Overlaps and WithOverlapDetection.
+type RouteOverlap struct {
+	Kind RouteOverlapKind
+	// Specific is the route that takes precedence for the shared paths. For conflicts,
+	// it is the route registered later.
+	Specific RouteInfo
+	// General is the other route. For conflicts, it is the route registered earlier.
+	General RouteInfo
+}
+
+// String returns a human-readable description of the overlap, including the registration locations.
+func (o RouteOverlap) String() string {
+	if o.Kind == OverlapConflict {
+		return fmt.Sprintf("%s (%s) conflicts with %s (%s)", routeLabel(o.Specific), o.Specific.Source, routeLabel(o.General), o.General.Source)
+	}
+	return fmt.Sprintf("%s (%s) shadows %s (%s)", routeLabel(o.Specific), o.Specific.Source, routeLabel(o.General), o.General.Source)
+}
+
+func routeLabel(r RouteInfo) string {
+	if r.Method == "" {
+		return r.Pattern
+	}
+	return r.Method + " " + r.Pattern
+}
+
+// WithOverlapDetection makes Build analyze the routes for overlaps (see Builder.Overlaps)
+// and call report for each of them before registering the routes, so that precedence bugs
+// are caught before deployment. If report is nil, each overlap is logged as a warning.
+//
+//	b := rakuda.NewBuilder(rakuda.WithOverlapDetection(func(o rakuda.RouteOverlap) {
+//		log.Println(o) // GET /users/me (main.go:12) shadows GET /users/{id} (main.go:11)
+//	}))
+func WithOverlapDetection(report func(RouteOverlap)) func(*BuilderConfig) {
+	return func(c *BuilderConfig) {
+		if report == nil {
+			report = func(o RouteOverlap) {
+				c.Logger.WarnContext(context.Background(), "route overlap", "kind", o.Kind, "overlap", o.String())
+			}
+		}
+		c.OnOverlap = report
+	}
+}
+
+// Overlaps returns the pairs of routes whose patterns match some of the same requests under
+// the precedence rules of ServeMux, in registration order. Routes are compared if they have
+// the same host and the same method, or if one of them is method-agnostic.
+// Duplicate routes are reported by OnConflict instead. Catch-all routes such as
+// "/{path...}" shadow every other route under their prefix, and are reported as well.
+func (b *Builder) Overlaps() []RouteOverlap {
+	var seen []route
+	var overlaps []RouteOverlap
+	_ = b.walk(func(rt route) error {
+		for _, prev := range seen {
+			if prev.host != rt.host || (prev.method != rt.method && prev.method != "" && rt.method != "") {
+				continue
+			}
+			if prev.key() == rt.key() {
+				continue // a duplicate, reported by OnConflict
+			}
+			if isAliasPair(prev, rt) {
+				continue // intentional, see Alias
+			}
+			rel := comparePatterns(rt.pattern, prev.pattern)
+			if rel == patternsDisjoint {
+				continue
+			}
+			rtMore := rel == patternsMoreSpecific || rel == patternsOverlap
+			prevMore := rel == patternsLessSpecific || rel == patternsOverlap
+			if rt.method != prev.method {
+				// A route with a method is more specific than a method-agnostic one.
+				if rt.method != "" {
+					rtMore = true
+				} else {
+					prevMore = true
+				}
+			}
+			switch {
+			case rtMore && !prevMore:
+				overlaps = append(overlaps, RouteOverlap{Kind: OverlapShadow, Specific: rt.info(), General: prev.info()})
+			case prevMore && !rtMore:
+				overlaps = append(overlaps, RouteOverlap{Kind: OverlapShadow, Specific: prev.info(), General: rt.info()})
+			default:
+				overlaps = append(overlaps, RouteOverlap{Kind: OverlapConflict, Specific: rt.info(), General: prev.info()})
+			}
+		}
+		seen = append(seen, rt)
+		return nil
+	})
+	return overlaps
+}
+
+// isAliasPair reports whether one of the routes is an alias of the other, or both are aliases of the same route.
+func isAliasPair(a, b route) bool {
+	return a.aliasOf == b.pattern || b.aliasOf == a.pattern || (a.aliasOf != "" && a.aliasOf == b.aliasOf)
+}
+
+// patternRelation is the relation between the sets of paths matched by two patterns.
+type patternRelation int
+
+const (
+	patternsDisjoint     patternRelation = iota
+	patternsEquivalent                   // the same paths, e.g., /users/{id} and /users/{name}
+	patternsMoreSpecific                 // the first matches a subset of the paths of the second
+	patternsLessSpecific                 // the first matches a superset of the paths of the second
+	patternsOverlap                      // some paths in common, neither is more specific
+)
+
+// patternSegment is a segment of the path of a ServeMux pattern.
+type patternSegment struct {
+	literal  string
+	wildcard bool // {name}
+	multi    bool // {name...} or a trailing slash
+}
+
+func parsePatternPath(pattern string) []patternSegment {
+	parts := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	segments := make([]patternSegment, len(parts))
+	for i, part := range parts {
+		switch {
+		case part == "" && i == len(parts)-1:
+			segments[i] = patternSegment{multi: true}
+		case part == "{$}":
+			segments[i] = patternSegment{literal: ""} // matches only the trailing slash
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "...}"):
+			segments[i] = patternSegment{multi: true}
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+			segments[i] = patternSegment{wildcard: true}
+		default:
+			segments[i] = patternSegment{literal: part}
+		}
+	}
+	return segments
+}
+
+// comparePatterns compares the paths of two patterns, segment by segment, as ServeMux does:
+// a literal is more specific than a wildcard, and a wildcard than a multi wildcard.
+func comparePatterns(a, b string) patternRelation {
+	as, bs := parsePatternPath(a), parsePatternPath(b)
+	aMore, bMore := false, false
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		if i == len(as) || i == len(bs) {
+			return patternsDisjoint // a multi wildcard would have ended the loop
+		}
+		sa, sb := as[i], bs[i]
+		if sa.multi || sb.multi {
+			// A multi wildcard is the last segment and matches the rest of any path.
+			if !sa.multi {
+				aMore = true
+			} else if !sb.multi {
+				bMore = true
+			}
+			break
+		}
+		switch {
+		case !sa.wildcard && !sb.wildcard:
+			if sa.literal != sb.literal {
+				return patternsDisjoint
+			}
+		case !sa.wildcard: // a literal against a wildcard
+			if sa.literal == "" {
+				return patternsDisjoint // {$} does not match a non-empty segment
+			}
+			aMore = true
+		case !sb.wildcard:
+			if sb.literal == "" {
+				return patternsDisjoint
+			}
+			bMore = true
+		}
+	}
+
+	switch {
+	case aMore && bMore:
+		return patternsOverlap
+	case aMore:
+		return patternsMoreSpecific
+	case bMore:
+		return patternsLessSpecific
+	default:
+		return patternsEquivalent
+	}
+}
@@ -0,0 +1,77 @@
+package rakuda
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestComparePatterns(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want patternRelation
+	}{
+		{a: "/users/me", b: "/users/{id}", want: patternsMoreSpecific},
+		{a: "/users/{id}", b: "/users/me", want: patternsLessSpecific},
+		{a: "/users/{id}", b: "/users/{name}", want: patternsEquivalent},
+		{a: "/users/{id}", b: "/teams/{id}", want: patternsDisjoint},
+		{a: "/users/{id}", b: "/users/{id}/posts", want: patternsDisjoint},
+		{a: "/{org}/repos", b: "/users/{id}", want: patternsOverlap},
+		{a: "/files/{path...}", b: "/files/a/b", want: patternsLessSpecific},
+		{a: "/files/{$}", b: "/files/{path...}", want: patternsMoreSpecific},
+		{a: "/files/{$}", b: "/files/{name}", want: patternsDisjoint},
+		{a: "/files", b: "/files/{path...}", want: patternsDisjoint},
+		{a: "/{path...}", b: "/users/{id}", want: patternsLessSpecific},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := comparePatterns(tt.a, tt.b); got != tt.want {
+				t.Errorf("comparePatterns(%q, %q): got %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	b := NewBuilder()
+	b.Get("/users/{id}", h)
+	b.Get("/users/me", h)
+	b.Post("/users/me", h) // another method, no overlap
+	b.Get("/teams/{id}", h)
+	b.Handle("/teams/{id}", h)
+	b.Route("/admin", func(b *Builder) {
+		b.Get("/users/{id}", h)
+	})
+
+	var got []string
+	for _, o := range b.Overlaps() {
+		got = append(got, string(o.Kind)+": "+routeLabel(o.Specific)+" > "+routeLabel(o.General))
+	}
+	want := []string{
+		"shadow: GET /users/me > GET /users/{id}",
+		"shadow: GET /teams/{id} > /teams/{id}",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Overlaps() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithOverlapDetection(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	var reports []string
+	b := NewBuilder(WithOverlapDetection(func(o RouteOverlap) {
+		reports = append(reports, o.String())
+	}))
+	b.Get("/users/{id}", h)
+	b.Get("/users/me", h)
+
+	if _, err := b.Build(); err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	if len(reports) != 1 || !strings.HasPrefix(reports[0], "GET /users/me (") || !strings.Contains(reports[0], "overlap_test.go:") {
+		t.Errorf("unexpected reports: %q", reports)
+	}
+}
@@ -0,0 +1,26 @@
+package rakuda
+
+import "context"
+
+// Principal is the authenticated caller of a request, such as a user or an API client.
+// Authentication middleware stores it in the context, so that authorization,
+// rate limiting, and audit logging can share a single notion of "who".
+type Principal struct {
+	// ID identifies the caller (e.g., a user ID or an API client ID).
+	ID string `json:"id"`
+	// Kind describes how the caller was authenticated (e.g., "user", "api_key").
+	Kind string `json:"kind,omitempty"`
+	// Attributes holds additional claims, such as roles or scopes.
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// NewContextWithPrincipal returns a new context with the provided Principal.
+func NewContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// PrincipalFromContext retrieves the Principal from the context.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey).(*Principal)
+	return p, ok && p != nil
+}
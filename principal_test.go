@@ -0,0 +1,23 @@
+package rakuda
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPrincipalFromContext(t *testing.T) {
+	if _, ok := PrincipalFromContext(context.Background()); ok {
+		t.Error("expected no principal in an empty context")
+	}
+
+	want := &Principal{ID: "user-1", Kind: "user", Attributes: map[string]string{"role": "admin"}}
+	got, ok := PrincipalFromContext(NewContextWithPrincipal(context.Background(), want))
+	if !ok {
+		t.Fatal("expected a principal in the context")
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("principal mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,151 @@
+package rakuda
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// NotAcceptableError reports that none of the media types produced by the route
+// is acceptable to the client (406 Not Acceptable).
+type NotAcceptableError struct {
+	// Accept is the Accept header sent by the client.
+	Accept string
+	// Supported are the media types produced by the route.
+	Supported []string
+}
+
+// Error implements the error interface.
+func (e *NotAcceptableError) Error() string {
+	return fmt.Sprintf("not acceptable: %q, supported: %s", e.Accept, strings.Join(e.Supported, ", "))
+}
+
+// StatusCode returns 406 Not Acceptable, allowing it to work with the lift handler.
+func (e *NotAcceptableError) StatusCode() int {
+	return http.StatusNotAcceptable
+}
+
+// MarshalJSON renders the error with the supported media types.
+func (e *NotAcceptableError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"error":     "not acceptable",
+		"supported": e.Supported,
+	})
+}
+
+// Produces returns route metadata that declares the media types of the responses,
+// in order of preference. Build wraps the route with content negotiation: if the
+// Accept header allows none of them, it responds with 406 and a *NotAcceptableError;
+// otherwise, the chosen type is available to the handler via NegotiatedType.
+//
+//	b.Get("/report", report, rakuda.Produces("application/json", "text/csv"))
+//
+// For a whole group, use RequireAccept as a middleware instead.
+func Produces(mediaTypes ...string) Meta {
+	return Meta{Produces: mediaTypes}
+}
+
+// RequireAccept returns a middleware that enforces the same negotiation as Produces,
+// for use with Use on a group.
+func RequireAccept(mediaTypes ...string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return producesHandler(mediaTypes, next)
+	}
+}
+
+// NegotiatedType returns the media type chosen by content negotiation (see Produces).
+// It reports false if the route does not declare the media types it produces.
+func NegotiatedType(ctx context.Context) (string, bool) {
+	mediaType, ok := ctx.Value(negotiatedKey).(string)
+	return mediaType, ok
+}
+
+// producesHandler responds with 406 to requests that accept none of mediaTypes,
+// and stores the negotiated type in the context of the others.
+func producesHandler(mediaTypes []string, next http.Handler) http.Handler {
+	responder := NewResponder()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		accept := r.Header.Get("Accept")
+		mediaType, ok := negotiate(accept, mediaTypes)
+		if !ok {
+			responder.Error(w, r, http.StatusNotAcceptable, &NotAcceptableError{Accept: accept, Supported: mediaTypes})
+			return
+		}
+		ctx := context.WithValue(r.Context(), negotiatedKey, mediaType)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// negotiate returns the offered media type with the highest quality in the Accept header.
+// Ties are broken by the order of offers. An empty Accept header accepts the first offer.
+func negotiate(accept string, offers []string) (string, bool) {
+	if len(offers) == 0 {
+		return "", false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+	ranges := parseAccept(accept)
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		// The quality of an offer is that of the most specific matching range.
+		q, specificity := 0.0, -1
+		for _, ar := range ranges {
+			if s := ar.match(offer); s > specificity {
+				q, specificity = ar.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best, bestQ > 0
+}
+
+// acceptRange is a media range of an Accept header, e.g., "text/*;q=0.5".
+type acceptRange struct {
+	typ, subtype string
+	q            float64
+}
+
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(mediaRange)), "/")
+		if !ok {
+			continue
+		}
+		ar := acceptRange{typ: typ, subtype: subtype, q: 1}
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					ar.q = q
+				}
+			}
+		}
+		ranges = append(ranges, ar)
+	}
+	return ranges
+}
+
+// match returns the specificity of the match with mediaType (2 for an exact match,
+// 1 for "type/*", 0 for "*/*"), or -1 if it does not match.
+func (ar acceptRange) match(mediaType string) int {
+	typ, subtype, _ := strings.Cut(strings.ToLower(mediaType), "/")
+	switch {
+	case ar.typ == typ && ar.subtype == subtype:
+		return 2
+	case ar.typ == typ && ar.subtype == "*":
+		return 1
+	case ar.typ == "*" && ar.subtype == "*":
+		return 0
+	}
+	return -1
+}
@@ -0,0 +1,67 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProduces(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _ := NegotiatedType(r.Context())
+		w.Header().Set("Content-Type", mediaType)
+	})
+
+	b := NewBuilder()
+	b.Get("/report", handler, Produces("application/json", "text/csv"))
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		accept     string
+		wantStatus int
+		wantType   string
+	}{
+		{name: "no Accept header", accept: "", wantStatus: http.StatusOK, wantType: "application/json"},
+		{name: "exact", accept: "text/csv", wantStatus: http.StatusOK, wantType: "text/csv"},
+		{name: "quality", accept: "application/json;q=0.5, text/csv", wantStatus: http.StatusOK, wantType: "text/csv"},
+		{name: "wildcard keeps server preference", accept: "*/*", wantStatus: http.StatusOK, wantType: "application/json"},
+		{name: "specific range wins over wildcard", accept: "text/*, application/json;q=0", wantStatus: http.StatusOK, wantType: "text/csv"},
+		{name: "not acceptable", accept: "application/xml", wantStatus: http.StatusNotAcceptable, wantType: "application/json; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/report", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if got := rr.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type: got %q, want %q", got, tt.wantType)
+			}
+			if got := rr.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary: got %q, want %q", got, "Accept")
+			}
+		})
+	}
+
+	t.Run("error body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/report", nil)
+		req.Header.Set("Accept", "image/png")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		want := `{"error":"not acceptable","supported":["application/json","text/csv"]}` + "\n"
+		if got := rr.Body.String(); got != want {
+			t.Errorf("body: got %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package rakuda
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// Profile is a preset of the error verbosity and diagnostics of an environment, so that
+// development-only behavior is switched on in one place and cannot leak to production.
+// Responder returns the Responder of the profile; rakudamiddleware.UseProfile applies the
+// whole profile to a builder.
+type Profile struct {
+	// Name identifies the profile, e.g., "production".
+	Name string
+	// VerboseErrors exposes the messages of 5xx errors to clients (see Responder.Verbose).
+	VerboseErrors bool
+	// PrettyJSON indents all JSON responses (see Responder.Pretty).
+	PrettyJSON bool
+	// StrictResponses makes misuses of the Responder panic (see Responder.Strict).
+	StrictResponses bool
+	// ExposeStack includes the stack of recovered panics in 500 responses.
+	ExposeStack bool
+	// LogLevel is the level of the request log records.
+	LogLevel slog.Level
+}
+
+// Profiles for the usual environments.
+var (
+	Development = Profile{Name: "development", VerboseErrors: true, PrettyJSON: true, StrictResponses: true, ExposeStack: true, LogLevel: slog.LevelDebug}
+	Staging     = Profile{Name: "staging", VerboseErrors: true, LogLevel: slog.LevelInfo}
+	Production  = Profile{Name: "production", LogLevel: slog.LevelInfo}
+)
+
+// LookupProfile returns the profile with the given name (case-insensitive), e.g., from an
+// environment variable. Unknown and empty names fall back to Production, so that
+// a missing setting never enables development behavior.
+//
+//	profile := rakuda.LookupProfile(os.Getenv("APP_ENV"))
+func LookupProfile(name string) Profile {
+	switch strings.ToLower(name) {
+	case Development.Name:
+		return Development
+	case Staging.Name:
+		return Staging
+	default:
+		return Production
+	}
+}
+
+// Responder returns a Responder configured by the profile.
+func (p Profile) Responder() *Responder {
+	return &Responder{Verbose: p.VerboseErrors, Pretty: p.PrettyJSON, Strict: p.StrictResponses}
+}
@@ -0,0 +1,41 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLookupProfile(t *testing.T) {
+	tests := []struct {
+		name string
+		want Profile
+	}{
+		{name: "development", want: Development},
+		{name: "Staging", want: Staging},
+		{name: "production", want: Production},
+		{name: "", want: Production},
+		{name: "dev", want: Production},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, LookupProfile(tt.name)); diff != "" {
+			t.Errorf("LookupProfile(%q) mismatch (-want +got):\n%s", tt.name, diff)
+		}
+	}
+}
+
+func TestProfileResponder(t *testing.T) {
+	rr := httptest.NewRecorder()
+	Development.Responder().JSON(rr, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, map[string]int{"id": 1})
+	if diff := cmp.Diff("{\n  \"id\": 1\n}\n", rr.Body.String()); diff != "" {
+		t.Errorf("body mismatch (-want +got):\n%s", diff)
+	}
+
+	rr = httptest.NewRecorder()
+	Production.Responder().JSON(rr, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, map[string]int{"id": 1})
+	if diff := cmp.Diff("{\"id\":1}\n", rr.Body.String()); diff != "" {
+		t.Errorf("body mismatch (-want +got):\n%s", diff)
+	}
+}
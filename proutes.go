@@ -7,14 +7,69 @@ import (
 	"text/tabwriter"
 )
 
+// PrintRoutesOptions controls the details printed by PrintRoutesWithOptions.
+type PrintRoutesOptions struct {
+	// Handler prints the handler's function name (or type name) after the pattern.
+	Handler bool
+	// Source prints the registration location of each route.
+	Source bool
+	// Middlewares prints the resolved middleware chain below each route, outermost first,
+	// with the registration location of each middleware.
+	Middlewares bool
+	// Disabled also prints the routes disabled by When, marked with "(disabled)".
+	Disabled bool
+}
+
 // PrintRoutes prints a formatted table of all registered routes to the provided writer.
+// If the Builder is in debug mode (see WithDebug), the resolved middleware chain of each
+// route is printed below it, together with the registration source locations.
 func PrintRoutes(w io.Writer, b *Builder) {
+	PrintRoutesWithOptions(w, b, PrintRoutesOptions{
+		Source:      b.config.Debug,
+		Middlewares: b.config.Debug,
+	})
+}
+
+// PrintRoutesWithOptions is like PrintRoutes, but prints the details selected by opts,
+// e.g., to check which middlewares apply to a route:
+//
+//	rakuda.PrintRoutesWithOptions(os.Stdout, b, rakuda.PrintRoutesOptions{Handler: true, Middlewares: true})
+//	// GET  /admin/stats  main.getStats
+//	//        -> main.authMiddleware (main.go:42)
+func PrintRoutesWithOptions(w io.Writer, b *Builder, opts PrintRoutesOptions) {
 	// Format:
-	// METHOD <2 spaces> PATTERN
+	// METHOD <2 spaces> PATTERN [<2 spaces> HANDLER] [<2 spaces> SOURCE]
 	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
 	defer tw.Flush()
 
-	b.Walk(func(method, pattern string) {
-		fmt.Fprintf(tw, "%s\t%s\n", strings.ToUpper(method), pattern)
+	_ = b.walkAll(func(rt route) error {
+		if rt.disabled && !opts.Disabled {
+			return nil
+		}
+		method := strings.ToUpper(rt.method)
+		if method == "" {
+			method = "*" // method-agnostic, e.g., Mount
+		}
+		cols := []string{method, rt.host + rt.pattern}
+		if opts.Handler {
+			cols = append(cols, funcName(rt.handler))
+		}
+		if opts.Source {
+			cols = append(cols, rt.source)
+		}
+		if rt.aliasOf != "" {
+			cols = append(cols, "(alias of "+rt.host+rt.aliasOf+")")
+		}
+		if rt.disabled {
+			cols = append(cols, "(disabled)")
+		}
+		fmt.Fprintln(tw, strings.Join(cols, "\t"))
+
+		if opts.Middlewares {
+			for _, mw := range rt.describe() {
+				fmt.Fprintf(tw, "\t  -> %s\t\n", mw)
+			}
+		}
+		return nil
 	})
 }
@@ -0,0 +1,284 @@
+// Package rakudaaudit emits structured audit events for compliance-sensitive APIs.
+//
+// The Middleware records who (the actor, from rakuda.PrincipalFromContext) did what
+// (the action, from route metadata) to which resource (the target, from path parameters),
+// and with which outcome, and writes the event to a pluggable Sink.
+//
+//	b.Use(authMiddleware)
+//	b.Use(rakudaaudit.Middleware(rakudaaudit.Config{Sink: sink}))
+//	b.Delete("/users/{id}", deleteUser, rakudaaudit.Action("user.delete"))
+package rakudaaudit
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/podhmo/rakuda"
+)
+
+// actionTagPrefix marks route tags that name the audited action.
+const actionTagPrefix = "audit:"
+
+// Outcome is the result of an audited request.
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success" // status < 400
+	OutcomeDenied  Outcome = "denied"  // 401 or 403
+	OutcomeFailure Outcome = "failure" // any other error status
+)
+
+// Event is a single audit record.
+type Event struct {
+	Time      time.Time         `json:"time"`
+	Actor     string            `json:"actor,omitempty"` // empty for anonymous requests
+	ActorKind string            `json:"actor_kind,omitempty"`
+	Action    string            `json:"action"`
+	Target    map[string]string `json:"target,omitempty"` // path parameters of the route
+	Outcome   Outcome           `json:"outcome"`
+	Status    int               `json:"status"`
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	Details   map[string]any    `json:"details,omitempty"` // added by handlers via Detail
+}
+
+// Sink receives audit events.
+type Sink interface {
+	Write(ctx context.Context, event Event) error
+}
+
+// SinkFunc is an adapter to allow the use of ordinary functions as a Sink.
+type SinkFunc func(ctx context.Context, event Event) error
+
+// Write calls f(ctx, event).
+func (f SinkFunc) Write(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// Config holds the configuration for the Middleware.
+type Config struct {
+	// Sink receives the events. It is required.
+	Sink Sink
+	// Filter reports whether a request is audited. Routes tagged with Action are always audited.
+	// Default audits every request except GET, HEAD, and OPTIONS.
+	Filter func(*http.Request) bool
+}
+
+// Action returns route metadata that names the audited action of a route (e.g., "user.delete").
+// Routes without an action are recorded as "<METHOD> <pattern>".
+func Action(name string) rakuda.Meta {
+	return rakuda.Meta{Tags: []string{actionTagPrefix + name}}
+}
+
+// Middleware returns a middleware that writes an audit event for each audited request.
+// It must be installed with Builder.Use, after the authentication middleware that
+// stores the principal. Failures to write an event are logged and do not affect the response.
+// A panicking handler is recorded as a failure with status 500, and the panic is propagated.
+func Middleware(config Config) rakuda.Middleware {
+	if config.Sink == nil {
+		panic("rakudaaudit: Config.Sink is required")
+	}
+	if config.Filter == nil {
+		config.Filter = func(r *http.Request) bool {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return false
+			}
+			return true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			action, tagged := actionFromMeta(r.Context())
+			if !tagged && !config.Filter(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if action == "" {
+				action = strings.TrimSpace(r.Method + " " + patternPath(r.Pattern))
+			}
+
+			event := Event{
+				Time:   time.Now(),
+				Action: action,
+				Target: pathParams(r),
+				Method: r.Method,
+				Path:   r.URL.Path,
+			}
+			if p, ok := rakuda.PrincipalFromContext(r.Context()); ok {
+				event.Actor = p.ID
+				event.ActorKind = p.Kind
+			}
+
+			details := &details{}
+			ctx := context.WithValue(r.Context(), detailsKey, details)
+			rw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			// The event is written in a defer, so that a panicking handler, whose failed write
+			// must be kept too, is recorded as a 500 before the panic reaches the Recovery middleware.
+			defer func() {
+				rec := recover()
+				event.Status = rw.status
+				if rec != nil {
+					event.Status = http.StatusInternalServerError
+				}
+				event.Outcome = outcomeOf(event.Status)
+				event.Details = details.values()
+				if err := config.Sink.Write(context.WithoutCancel(r.Context()), event); err != nil {
+					logger := rakuda.LoggerFromContext(r.Context())
+					logger.ErrorContext(r.Context(), "failed to write audit event", "error", err, "action", event.Action)
+				}
+				if rec != nil {
+					panic(rec)
+				}
+			}()
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
+
+type contextKey string
+
+const detailsKey = contextKey("details")
+
+// details collects values added by handlers during an audited request.
+type details struct {
+	mu sync.Mutex
+	m  map[string]any
+}
+
+func (d *details) values() map[string]any {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.m
+}
+
+// Detail adds a key-value pair to the audit event of the current request,
+// such as the previous value of an updated field. It does nothing if the request is not audited.
+func Detail(ctx context.Context, key string, value any) {
+	d, ok := ctx.Value(detailsKey).(*details)
+	if !ok {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.m == nil {
+		d.m = map[string]any{}
+	}
+	d.m[key] = value
+}
+
+func actionFromMeta(ctx context.Context) (string, bool) {
+	meta, ok := rakuda.RouteMetaFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	for _, tag := range meta.Tags {
+		if action, ok := strings.CutPrefix(tag, actionTagPrefix); ok {
+			return action, true
+		}
+	}
+	return "", false
+}
+
+// patternPath strips the method and host from a ServeMux pattern.
+func patternPath(pattern string) string {
+	if _, rest, ok := strings.Cut(pattern, " "); ok {
+		pattern = strings.TrimSpace(rest)
+	}
+	if i := strings.Index(pattern, "/"); i > 0 {
+		pattern = pattern[i:]
+	}
+	return pattern
+}
+
+// pathParams returns the values of the wildcards of the matched pattern.
+func pathParams(r *http.Request) map[string]string {
+	var params map[string]string
+	for _, segment := range strings.Split(patternPath(r.Pattern), "/") {
+		name, ok := strings.CutPrefix(segment, "{")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSuffix(strings.TrimSuffix(name, "}"), "...")
+		if name == "$" {
+			continue
+		}
+		if params == nil {
+			params = map[string]string{}
+		}
+		params[name] = r.PathValue(name)
+	}
+	return params
+}
+
+func outcomeOf(status int) Outcome {
+	switch {
+	case status < http.StatusBadRequest:
+		return OutcomeSuccess
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return OutcomeDenied
+	default:
+		return OutcomeFailure
+	}
+}
+
+// statusWriter captures the status code of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(statusCode int) {
+	w.status = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// LogSink returns a Sink that writes events to logger at info level, under the "audit" message.
+func LogSink(logger *slog.Logger) Sink {
+	return SinkFunc(func(ctx context.Context, event Event) error {
+		attrs := []slog.Attr{
+			slog.String("action", event.Action),
+			slog.String("actor", event.Actor),
+			slog.String("outcome", string(event.Outcome)),
+			slog.Int("status", event.Status),
+			slog.String("method", event.Method),
+			slog.String("path", event.Path),
+		}
+		if len(event.Target) > 0 {
+			attrs = append(attrs, slog.Any("target", event.Target))
+		}
+		if len(event.Details) > 0 {
+			attrs = append(attrs, slog.Any("details", event.Details))
+		}
+		logger.LogAttrs(ctx, slog.LevelInfo, "audit", attrs...)
+		return nil
+	})
+}
+
+// MemorySink is an in-memory Sink, useful for tests.
+type MemorySink struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+var _ Sink = (*MemorySink)(nil)
+
+// Write implements Sink.
+func (s *MemorySink) Write(ctx context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+// Events returns the written events, oldest first.
+func (s *MemorySink) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.events...)
+}
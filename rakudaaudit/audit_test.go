@@ -0,0 +1,94 @@
+package rakudaaudit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/podhmo/rakuda"
+)
+
+func TestMiddleware(t *testing.T) {
+	sink := &MemorySink{}
+
+	b := rakuda.NewBuilder()
+	b.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id := r.Header.Get("X-User"); id != "" {
+				r = r.WithContext(rakuda.NewContextWithPrincipal(r.Context(), &rakuda.Principal{ID: id, Kind: "user"}))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	b.Use(Middleware(Config{Sink: sink}))
+	b.Get("/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	b.Get("/users/{id}/secrets", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}), Action("user.secrets.read"))
+	b.Delete("/orgs/{org}/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Detail(r.Context(), "reason", "offboarding")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	b.Post("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	}))
+	b.Put("/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	serve := func(method, path, user string) {
+		req := httptest.NewRequest(method, path, nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	serve(http.MethodGet, "/users/1", "alice") // not audited
+	serve(http.MethodGet, "/users/1/secrets", "bob")
+	serve(http.MethodDelete, "/orgs/acme/users/2", "alice")
+	serve(http.MethodPost, "/users", "")
+	func() {
+		defer func() {
+			if rec := recover(); rec != "boom" {
+				t.Errorf("expected the panic to propagate, got %v", rec)
+			}
+		}()
+		serve(http.MethodPut, "/users/3", "carol")
+	}()
+
+	want := []Event{
+		{
+			Actor: "bob", ActorKind: "user", Action: "user.secrets.read",
+			Target:  map[string]string{"id": "1"},
+			Outcome: OutcomeDenied, Status: http.StatusForbidden,
+			Method: http.MethodGet, Path: "/users/1/secrets",
+		},
+		{
+			Actor: "alice", ActorKind: "user", Action: "DELETE /orgs/{org}/users/{id}",
+			Target:  map[string]string{"org": "acme", "id": "2"},
+			Outcome: OutcomeSuccess, Status: http.StatusNoContent,
+			Method: http.MethodDelete, Path: "/orgs/acme/users/2",
+			Details: map[string]any{"reason": "offboarding"},
+		},
+		{
+			Action:  "POST /users",
+			Outcome: OutcomeFailure, Status: http.StatusConflict,
+			Method: http.MethodPost, Path: "/users",
+		},
+		{
+			Actor: "carol", ActorKind: "user", Action: "PUT /users/{id}",
+			Target:  map[string]string{"id": "3"},
+			Outcome: OutcomeFailure, Status: http.StatusInternalServerError,
+			Method: http.MethodPut, Path: "/users/3",
+		},
+	}
+	if diff := cmp.Diff(want, sink.Events(), cmpopts.IgnoreFields(Event{}, "Time")); diff != "" {
+		t.Errorf("events mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,217 @@
+// Package rakudachi provides a chi-like routing interface backed by rakuda.Builder,
+// so that chi-based codebases can migrate incrementally without rewriting every
+// route registration and handler.
+//
+//	b := rakuda.NewBuilder()
+//	r := rakudachi.New(b)
+//	r.Use(rakudamiddleware.Recovery)
+//	r.Route("/users", func(r rakudachi.Router) {
+//		r.Get("/", listUsers)
+//		r.Get("/{id:[0-9]+}", getUser) // handlers keep using rakudachi.URLParam(r, "id")
+//	})
+//	handler, err := b.Build()
+//
+// Patterns follow chi's syntax: "{name}" and "{name:regexp}" parameters, and a
+// trailing "*" catch-all (available as URLParam(r, "*")). Regular expressions are
+// checked when the request is served; mismatches are answered by the NotFound
+// handler of the builder (see rakuda.ServeNotFound).
+//
+// Unlike chi, routes that differ only in their regular expressions, such as
+// "/u/{id:[0-9]+}" and "/u/{name:[a-z]+}", translate to the same ServeMux pattern,
+// so Build fails with a conflict; register one route and branch in its handler.
+// NotFound is supported only on the router returned by New.
+package rakudachi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/podhmo/rakuda"
+)
+
+// catchAll is the name of the wildcard used for chi's "*".
+const catchAll = "chi_any"
+
+// Router is the subset of chi.Router supported by this package.
+type Router interface {
+	Use(middlewares ...func(http.Handler) http.Handler)
+	With(middlewares ...func(http.Handler) http.Handler) Router
+	Group(fn func(r Router))
+	Route(pattern string, fn func(r Router))
+	Mount(pattern string, h http.Handler)
+
+	Handle(pattern string, h http.Handler)
+	HandleFunc(pattern string, h http.HandlerFunc)
+	Method(method, pattern string, h http.Handler)
+	MethodFunc(method, pattern string, h http.HandlerFunc)
+
+	Get(pattern string, h http.HandlerFunc)
+	Post(pattern string, h http.HandlerFunc)
+	Put(pattern string, h http.HandlerFunc)
+	Patch(pattern string, h http.HandlerFunc)
+	Delete(pattern string, h http.HandlerFunc)
+
+	NotFound(h http.HandlerFunc)
+}
+
+// URLParam returns the value of a URL parameter, as chi.URLParam does.
+// Use "*" for the catch-all.
+func URLParam(r *http.Request, key string) string {
+	if key == "*" {
+		key = catchAll
+	}
+	return r.PathValue(key)
+}
+
+// New returns a Router that registers routes on b.
+func New(b *rakuda.Builder) Router {
+	return &router{b: b, root: true}
+}
+
+type router struct {
+	b    *rakuda.Builder
+	root bool // returned by New; the builders of Group and Route have no NotFound handler of their own
+	// prefix is the pattern of the enclosing Route calls. Routes are registered with full
+	// patterns, so that "/" inside Route("/users", ...) matches "/users" as in chi.
+	prefix string
+}
+
+func (r *router) Use(middlewares ...func(http.Handler) http.Handler) {
+	for _, mw := range middlewares {
+		r.b.Use(mw)
+	}
+}
+
+func (r *router) With(middlewares ...func(http.Handler) http.Handler) Router {
+	mws := make([]rakuda.Middleware, len(middlewares))
+	for i, mw := range middlewares {
+		mws[i] = mw
+	}
+	return &router{b: r.b.With(mws...), prefix: r.prefix}
+}
+
+func (r *router) Group(fn func(r Router)) {
+	r.b.Group(func(b *rakuda.Builder) {
+		fn(&router{b: b, prefix: r.prefix})
+	})
+}
+
+func (r *router) Route(pattern string, fn func(r Router)) {
+	r.b.Group(func(b *rakuda.Builder) {
+		fn(&router{b: b, prefix: r.prefix + strings.TrimSuffix(pattern, "/")})
+	})
+}
+
+// Mount attaches h under pattern for all methods. As with chi, the request path is
+// passed to h as is, without stripping the prefix; wrap h with http.StripPrefix if needed.
+func (r *router) Mount(pattern string, h http.Handler) {
+	prefix := r.prefix + strings.TrimSuffix(pattern, "/")
+	if prefix != "" {
+		r.b.Handle(prefix, h)
+	}
+	r.b.Handle(prefix+"/{"+catchAll+"...}", h)
+}
+
+// Handle registers h for all methods, as chi's method-agnostic Handle does.
+func (r *router) Handle(pattern string, h http.Handler) {
+	pattern, h = r.translate(pattern, h)
+	r.b.Handle(pattern, h)
+}
+
+func (r *router) HandleFunc(pattern string, h http.HandlerFunc) {
+	r.Handle(pattern, h)
+}
+
+func (r *router) Method(method, pattern string, h http.Handler) {
+	pattern, h = r.translate(pattern, h)
+	r.b.Method(method, pattern, h)
+}
+
+func (r *router) MethodFunc(method, pattern string, h http.HandlerFunc) {
+	r.Method(method, pattern, h)
+}
+
+func (r *router) Get(pattern string, h http.HandlerFunc)    { r.Method(http.MethodGet, pattern, h) }
+func (r *router) Post(pattern string, h http.HandlerFunc)   { r.Method(http.MethodPost, pattern, h) }
+func (r *router) Put(pattern string, h http.HandlerFunc)    { r.Method(http.MethodPut, pattern, h) }
+func (r *router) Patch(pattern string, h http.HandlerFunc)  { r.Method(http.MethodPatch, pattern, h) }
+func (r *router) Delete(pattern string, h http.HandlerFunc) { r.Method(http.MethodDelete, pattern, h) }
+
+// NotFound sets the NotFound handler of the builder. It panics if r is not the router
+// returned by New, because a NotFound handler scoped to a Route or Group would be ignored.
+func (r *router) NotFound(h http.HandlerFunc) {
+	if !r.root {
+		panic("rakudachi: NotFound is supported only on the router returned by New")
+	}
+	r.b.NotFound(h)
+}
+
+// translate converts a chi pattern into a ServeMux pattern and wraps h with the
+// checks for regular expression constraints.
+func (r *router) translate(pattern string, h http.Handler) (string, http.Handler) {
+	if r.prefix != "" && (pattern == "" || pattern == "/") {
+		pattern = r.prefix
+	} else {
+		pattern = r.prefix + pattern
+	}
+	pattern, constraints := translatePattern(pattern)
+	if len(constraints) > 0 {
+		next := h
+		h = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			for name, re := range constraints {
+				if !re.MatchString(req.PathValue(name)) {
+					rakuda.ServeNotFound(w, req)
+					return
+				}
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+	return pattern, h
+}
+
+// translatePattern converts chi's "{name:regexp}" parameters and trailing "*"
+// into ServeMux wildcards, returning the regular expressions by parameter name.
+func translatePattern(pattern string) (string, map[string]*regexp.Regexp) {
+	var constraints map[string]*regexp.Regexp
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '{' {
+			sb.WriteByte(pattern[i])
+			continue
+		}
+		// Find the matching brace; regular expressions may contain braces (e.g., "{id:[0-9]{3}}").
+		depth, end := 0, -1
+		for j := i; j < len(pattern); j++ {
+			if pattern[j] == '{' {
+				depth++
+			} else if pattern[j] == '}' {
+				depth--
+				if depth == 0 {
+					end = j
+					break
+				}
+			}
+		}
+		if end < 0 {
+			panic(fmt.Sprintf("rakudachi: unbalanced braces in pattern %q", pattern))
+		}
+		name, expr, hasExpr := strings.Cut(pattern[i+1:end], ":")
+		if hasExpr {
+			if constraints == nil {
+				constraints = map[string]*regexp.Regexp{}
+			}
+			constraints[name] = regexp.MustCompile("^(?:" + expr + ")$")
+		}
+		sb.WriteString("{" + name + "}")
+		i = end
+	}
+
+	translated := sb.String()
+	if strings.HasSuffix(translated, "*") {
+		translated = strings.TrimSuffix(translated, "*") + "{" + catchAll + "...}"
+	}
+	return translated, constraints
+}
@@ -0,0 +1,157 @@
+package rakudachi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda"
+)
+
+func TestRouter(t *testing.T) {
+	var calls []string
+	trace := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	write := func(s string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(s)) }
+	}
+
+	// As with chi, mounted handlers see the full path.
+	admin := http.NewServeMux()
+	admin.HandleFunc("/admin/stats", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("admin " + r.URL.Path))
+	})
+	api := http.NewServeMux()
+	api.HandleFunc("/v1/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("api " + r.URL.Path))
+	})
+
+	b := rakuda.NewBuilder()
+	r := New(b)
+	r.Use(trace("root"))
+	r.Get("/", write("index"))
+	r.Route("/users", func(r Router) {
+		r.Use(trace("users"))
+		r.Get("/", write("list"))
+		r.Get("/{id:[0-9]+}", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("user " + URLParam(req, "id")))
+		})
+		r.With(trace("inline")).Post("/", write("create"))
+	})
+	r.Get("/files/*", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("file " + URLParam(req, "*")))
+	})
+	r.Mount("/admin", admin)
+	r.Mount("/api", http.StripPrefix("/api", api))
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("custom not found"))
+	})
+
+	handler, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		method    string
+		target    string
+		wantCode  int
+		wantBody  string
+		wantCalls []string
+	}{
+		{http.MethodGet, "/", http.StatusOK, "index", []string{"root"}},
+		{http.MethodGet, "/users", http.StatusOK, "list", []string{"root", "users"}},
+		{http.MethodGet, "/users/42", http.StatusOK, "user 42", []string{"root", "users"}},
+		{http.MethodGet, "/users/abc", http.StatusNotFound, "custom not found", []string{"root", "users"}},
+		{http.MethodPost, "/users", http.StatusOK, "create", []string{"root", "users", "inline"}},
+		{http.MethodGet, "/files/a/b.txt", http.StatusOK, "file a/b.txt", []string{"root"}},
+		{http.MethodGet, "/admin/stats", http.StatusOK, "admin /admin/stats", []string{"root"}},
+		{http.MethodGet, "/api/v1/ping", http.StatusOK, "api /v1/ping", []string{"root"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			calls = nil
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.target, nil))
+			if rr.Code != tt.wantCode {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+				t.Errorf("body: got %q, want %q", rr.Body.String(), tt.wantBody)
+			}
+			if diff := cmp.Diff(tt.wantCalls, calls); diff != "" {
+				t.Errorf("middleware calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNotFound_NonRoot(t *testing.T) {
+	r := New(rakuda.NewBuilder())
+	for name, fn := range map[string]func(){
+		"Route": func() { r.Route("/api", func(r Router) { r.NotFound(http.NotFound) }) },
+		"Group": func() { r.Group(func(r Router) { r.NotFound(http.NotFound) }) },
+		"With":  func() { r.With().NotFound(http.NotFound) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected NotFound on a non-root router to panic")
+				}
+			}()
+			fn()
+		})
+	}
+}
+
+func TestTranslatePattern(t *testing.T) {
+	tests := []struct {
+		input           string
+		want            string
+		wantConstraints []string
+	}{
+		{"/users/{id}", "/users/{id}", nil},
+		{"/users/{id:[0-9]{3}}/posts", "/users/{id}/posts", []string{"id"}},
+		{"/static/*", "/static/{chi_any...}", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, constraints := translatePattern(tt.input)
+			if got != tt.want {
+				t.Errorf("pattern: got %q, want %q", got, tt.want)
+			}
+			var names []string
+			for name := range constraints {
+				names = append(names, name)
+			}
+			if diff := cmp.Diff(tt.wantConstraints, names); diff != "" {
+				t.Errorf("constraints mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMethod_CustomVerb(t *testing.T) {
+	b := rakuda.NewBuilder()
+	New(b).Method("propfind", "/files/*", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(URLParam(r, "*")))
+	}))
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("PROPFIND", "/files/a/b", nil))
+	if got, want := rr.Body.String(), "a/b"; got != want {
+		t.Errorf("body: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,172 @@
+// Package rakudaclient provides an http.Client for outbound calls that keeps
+// inbound and outbound observability symmetric: request IDs and trace headers of
+// the inbound request are passed through, every call is logged with the request
+// logger, and calls can be bounded by timeouts.
+//
+//	b.Use(rakudaclient.Propagate())
+//	client := rakudaclient.New(&rakudaclient.Config{Timeout: 5 * time.Second})
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, "http://users/api", nil)
+//		res, err := client.Do(req) // carries X-Request-ID and traceparent of r
+//		...
+//	}
+package rakudaclient
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/podhmo/rakuda"
+)
+
+// DefaultHeaders are the inbound headers passed through by default:
+// the request ID and the W3C Trace Context headers.
+var DefaultHeaders = []string{"X-Request-ID", "Traceparent", "Tracestate"}
+
+type contextKey string
+
+const (
+	headersKey = contextKey("headers")
+	timeoutKey = contextKey("timeout")
+)
+
+// Propagate returns a middleware that captures the given inbound headers
+// (DefaultHeaders if none are given) into the request context, so that clients
+// created by New pass them through to outbound calls.
+func Propagate(headers ...string) rakuda.Middleware {
+	if len(headers) == 0 {
+		headers = DefaultHeaders
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var captured http.Header
+			for _, name := range headers {
+				if v := r.Header.Get(name); v != "" {
+					if captured == nil {
+						captured = http.Header{}
+					}
+					captured.Set(name, v)
+				}
+			}
+			if captured != nil {
+				r = r.WithContext(NewContextWithHeaders(r.Context(), captured))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// NewContextWithHeaders returns a new context with headers to be passed through to outbound calls.
+func NewContextWithHeaders(ctx context.Context, h http.Header) context.Context {
+	return context.WithValue(ctx, headersKey, h)
+}
+
+// HeadersFromContext returns the headers to be passed through to outbound calls.
+func HeadersFromContext(ctx context.Context) http.Header {
+	h, _ := ctx.Value(headersKey).(http.Header)
+	return h
+}
+
+// WithTimeout returns a context that bounds the outbound calls made with it by d,
+// overriding Config.Timeout. The timeout covers reading the response body.
+func WithTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutKey, d)
+}
+
+// Config holds the configuration for New.
+type Config struct {
+	// Transport sends the requests. Default is http.DefaultTransport.
+	Transport http.RoundTripper
+	// Timeout bounds each call, including reading the response body. Zero means no timeout.
+	// It can be overridden per call with WithTimeout.
+	Timeout time.Duration
+	// LogLevel is the level of the log record of each call. Default is slog.LevelInfo.
+	// Failed calls are logged at error level.
+	LogLevel slog.Level
+}
+
+// New returns an http.Client whose transport passes through the headers captured by
+// Propagate, logs each call with rakuda.LoggerFromContext, and applies timeouts.
+// If config is nil, it uses the default settings.
+func New(config *Config) *http.Client {
+	return &http.Client{Transport: NewTransport(config)}
+}
+
+// NewTransport returns the http.RoundTripper used by New, for composing with an existing client.
+func NewTransport(config *Config) http.RoundTripper {
+	if config == nil {
+		config = &Config{}
+	}
+	t := &transport{base: config.Transport, timeout: config.Timeout, level: config.LogLevel}
+	if t.base == nil {
+		t.base = http.DefaultTransport
+	}
+	return t
+}
+
+type transport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+	level   slog.Level
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	timeout := t.timeout
+	if d, ok := ctx.Value(timeoutKey).(time.Duration); ok {
+		timeout = d
+	}
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	// A RoundTripper must not modify the request.
+	out := req.Clone(ctx)
+	for name, values := range HeadersFromContext(ctx) {
+		if out.Header.Get(name) == "" {
+			out.Header[name] = values
+		}
+	}
+
+	start := time.Now()
+	res, err := t.base.RoundTrip(out)
+	duration := time.Since(start)
+
+	logger := rakuda.LoggerFromContext(ctx)
+	attrs := []slog.Attr{
+		slog.String("method", out.Method),
+		slog.String("url", out.URL.Redacted()),
+		slog.Duration("duration", duration),
+	}
+	if err != nil {
+		cancel()
+		attrs = append(attrs, slog.String("error", err.Error()))
+		logger.LogAttrs(ctx, slog.LevelError, "outbound request failed", attrs...)
+		return nil, err
+	}
+	attrs = append(attrs, slog.Int("status", res.StatusCode))
+	logger.LogAttrs(ctx, t.level, "outbound request", attrs...)
+
+	// Release the timeout when the caller is done with the body.
+	res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// cancelBody cancels the context of a call when its response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,90 @@
+package rakudaclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/podhmo/rakuda"
+)
+
+func TestClient(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Request-ID") + " " + r.Header.Get("Traceparent")))
+	}))
+	defer upstream.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	client := New(nil)
+
+	b := rakuda.NewBuilder(rakuda.WithLogger(logger))
+	b.Use(Propagate())
+	b.Get("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, upstream.URL+"/users?page=1", nil)
+		res, err := client.Do(req)
+		if err != nil {
+			t.Errorf("client.Do() failed: %v", err)
+			return
+		}
+		defer res.Body.Close()
+		io.Copy(w, res.Body)
+	}))
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set("Traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if got, want := rr.Body.String(), "req-1 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"; got != want {
+		t.Errorf("propagated headers: got %q, want %q", got, want)
+	}
+
+	var logOutput map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &logOutput); err != nil {
+		t.Fatalf("failed to unmarshal log output: %v", err)
+	}
+	if got, want := logOutput["msg"], "outbound request"; got != want {
+		t.Errorf("msg: got %v, want %v", got, want)
+	}
+	if got, want := logOutput["url"], upstream.URL+"/users?page=1"; got != want {
+		t.Errorf("url: got %v, want %v", got, want)
+	}
+	if got, want := logOutput["status"], float64(http.StatusOK); got != want {
+		t.Errorf("status: got %v, want %v", got, want)
+	}
+	if got, want := logOutput["path"], "/"; got != want {
+		t.Errorf("expected the inbound request logger to be used, path: got %v, want %v", got, want)
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer upstream.Close()
+
+	ctx := rakuda.NewContextWithLogger(context.Background(), slog.New(slog.DiscardHandler))
+	client := New(&Config{Timeout: time.Minute})
+
+	req, _ := http.NewRequestWithContext(WithTimeout(ctx, 10*time.Millisecond), http.MethodGet, upstream.URL, nil)
+	_, err := client.Do(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package rakudamiddleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/podhmo/rakuda"
+)
+
+// ErrInvalidAPIKey is returned by an APIKeyConfig.Lookup function when the key is unknown or revoked.
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// APIKeyConfig holds the configuration for the APIKey middleware.
+type APIKeyConfig struct {
+	// Header is the name of the header carrying the key. Default is "X-API-Key".
+	Header string
+	// Query is the name of a query parameter carrying the key. If empty, the query is not checked.
+	// Keys in URLs tend to leak into logs, so prefer the header.
+	Query string
+	// Lookup resolves a key into the principal it belongs to. It is required.
+	// It should return ErrInvalidAPIKey for unknown keys; other errors result in 500.
+	// Use StaticAPIKeys for keys fixed at startup.
+	Lookup func(ctx context.Context, key string) (*rakuda.Principal, error)
+	// Cache is how long successful lookups are cached. Zero disables caching.
+	Cache time.Duration
+}
+
+// APIKey returns a middleware that authenticates requests by an API key.
+// On success, the principal is stored in the context (see rakuda.PrincipalFromContext),
+// so that per-key rate limiting and auditing can key on it. Otherwise, it responds with 401.
+func APIKey(config APIKeyConfig) rakuda.Middleware {
+	if config.Lookup == nil {
+		panic("rakudamiddleware: APIKeyConfig.Lookup is required")
+	}
+	if config.Header == "" {
+		config.Header = "X-API-Key"
+	}
+	lookup := config.Lookup
+	if config.Cache > 0 {
+		lookup = (&apiKeyCache{lookup: config.Lookup, ttl: config.Cache, entries: map[[32]byte]apiKeyCacheEntry{}}).Lookup
+	}
+	responder := rakuda.NewResponder()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(config.Header)
+			if key == "" && config.Query != "" {
+				key = r.URL.Query().Get(config.Query)
+			}
+			if key == "" {
+				responder.Error(w, r, http.StatusUnauthorized, errors.New("missing api key"))
+				return
+			}
+
+			principal, err := lookup(r.Context(), key)
+			if err != nil {
+				if errors.Is(err, ErrInvalidAPIKey) {
+					responder.Error(w, r, http.StatusUnauthorized, ErrInvalidAPIKey)
+					return
+				}
+				responder.Error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(rakuda.NewContextWithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// StaticAPIKeys returns a lookup function for a fixed set of keys.
+// Keys are compared in constant time, so that response timing does not reveal them.
+func StaticAPIKeys(keys map[string]*rakuda.Principal) func(ctx context.Context, key string) (*rakuda.Principal, error) {
+	type entry struct {
+		hash      [32]byte
+		principal *rakuda.Principal
+	}
+	entries := make([]entry, 0, len(keys))
+	for k, p := range keys {
+		entries = append(entries, entry{hash: sha256.Sum256([]byte(k)), principal: p})
+	}
+
+	return func(ctx context.Context, key string) (*rakuda.Principal, error) {
+		// Compare fixed-size hashes against every entry without returning early.
+		hash := sha256.Sum256([]byte(key))
+		var found *rakuda.Principal
+		for _, e := range entries {
+			if subtle.ConstantTimeCompare(hash[:], e.hash[:]) == 1 {
+				found = e.principal
+			}
+		}
+		if found == nil {
+			return nil, ErrInvalidAPIKey
+		}
+		return found, nil
+	}
+}
+
+// apiKeyCache caches successful lookups, keyed by the hash of the key
+// so that raw keys are not kept in memory.
+type apiKeyCache struct {
+	lookup func(ctx context.Context, key string) (*rakuda.Principal, error)
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	entries map[[32]byte]apiKeyCacheEntry
+}
+
+type apiKeyCacheEntry struct {
+	principal *rakuda.Principal
+	expires   time.Time
+}
+
+func (c *apiKeyCache) Lookup(ctx context.Context, key string) (*rakuda.Principal, error) {
+	hash := sha256.Sum256([]byte(key))
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[hash]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.principal, nil
+	}
+
+	principal, err := c.lookup(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for h, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, h)
+		}
+	}
+	c.entries[hash] = apiKeyCacheEntry{principal: principal, expires: now.Add(c.ttl)}
+	return principal, nil
+}
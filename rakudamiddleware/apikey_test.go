@@ -0,0 +1,88 @@
+package rakudamiddleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/podhmo/rakuda"
+)
+
+func TestAPIKey(t *testing.T) {
+	lookup := StaticAPIKeys(map[string]*rakuda.Principal{
+		"key-1": {ID: "client-1", Kind: "api_key"},
+	})
+	handler := APIKey(APIKeyConfig{Query: "api_key", Lookup: lookup})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := rakuda.PrincipalFromContext(r.Context())
+		w.Write([]byte(p.ID))
+	}))
+
+	tests := []struct {
+		name     string
+		target   string
+		header   string
+		wantCode int
+		wantBody string
+	}{
+		{name: "header", target: "/", header: "key-1", wantCode: http.StatusOK, wantBody: "client-1"},
+		{name: "query", target: "/?api_key=key-1", wantCode: http.StatusOK, wantBody: "client-1"},
+		{name: "missing", target: "/", wantCode: http.StatusUnauthorized},
+		{name: "invalid", target: "/", header: "key-2", wantCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-API-Key", tt.header)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.wantCode {
+				t.Errorf("expected status code %d, got %d", tt.wantCode, rr.Code)
+			}
+			if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPIKey_Cache(t *testing.T) {
+	calls := 0
+	lookup := func(ctx context.Context, key string) (*rakuda.Principal, error) {
+		calls++
+		if key != "key-1" {
+			return nil, ErrInvalidAPIKey
+		}
+		return &rakuda.Principal{ID: "client-1"}, nil
+	}
+	handler := APIKey(APIKeyConfig{Lookup: lookup, Cache: time.Minute})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, key := range []string{"key-1", "key-1", "key-2", "key-2"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", key)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	// Successful lookups are cached; failures are not.
+	if want := 3; calls != want {
+		t.Errorf("expected %d lookups, got %d", want, calls)
+	}
+}
+
+func TestAPIKey_LookupError(t *testing.T) {
+	lookup := func(ctx context.Context, key string) (*rakuda.Principal, error) {
+		return nil, errors.New("db down")
+	}
+	handler := APIKey(APIKeyConfig{Lookup: lookup})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-API-Key", "key-1")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status code %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+}
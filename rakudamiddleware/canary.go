@@ -0,0 +1,37 @@
+package rakudamiddleware
+
+import (
+	"net/http"
+
+	"github.com/podhmo/rakuda"
+)
+
+// Canary returns a middleware that routes requests carrying a header (or a cookie of the
+// same name) with the given value to canary instead of the next handler. It is useful for
+// internal pre-release testing behind the same URL. If value is empty, any non-empty value matches.
+// Responses have "Vary: <header>, Cookie", so that shared caches keep the two versions apart.
+//
+// canary may be a whole route set, such as the result of another Builder's Build.
+func Canary(header, value string, canary http.Handler) rakuda.Middleware {
+	matches := func(v string) bool {
+		if value == "" {
+			return v != ""
+		}
+		return v == value
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The cookie can select the canary too, so caches must key on both.
+			w.Header().Add("Vary", header+", Cookie")
+			if matches(r.Header.Get(header)) {
+				canary.ServeHTTP(w, r)
+				return
+			}
+			if c, err := r.Cookie(header); err == nil && matches(c.Value) {
+				canary.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
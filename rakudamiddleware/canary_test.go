@@ -0,0 +1,42 @@
+package rakudamiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanary(t *testing.T) {
+	stable := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("stable")) })
+	canary := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("canary")) })
+
+	tests := []struct {
+		name  string
+		value string
+		setup func(r *http.Request)
+		want  string
+	}{
+		{name: "no header", value: "always", setup: func(r *http.Request) {}, want: "stable"},
+		{name: "header", value: "always", setup: func(r *http.Request) { r.Header.Set("X-Canary", "always") }, want: "canary"},
+		{name: "header mismatch", value: "always", setup: func(r *http.Request) { r.Header.Set("X-Canary", "never") }, want: "stable"},
+		{name: "cookie", value: "always", setup: func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "X-Canary", Value: "always"}) }, want: "canary"},
+		{name: "any value", value: "", setup: func(r *http.Request) { r.Header.Set("X-Canary", "1") }, want: "canary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Canary("X-Canary", tt.value, canary)(stable)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			tt.setup(req)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if got := rr.Body.String(); got != tt.want {
+				t.Errorf("body: got %q, want %q", got, tt.want)
+			}
+			if got, want := rr.Header().Get("Vary"), "X-Canary, Cookie"; got != want {
+				t.Errorf("Vary: got %q, want %q", got, want)
+			}
+		})
+	}
+}
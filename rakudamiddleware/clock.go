@@ -0,0 +1,19 @@
+package rakudamiddleware
+
+import (
+	"net/http"
+
+	"github.com/podhmo/rakuda"
+)
+
+// Clock returns a middleware that installs clock into the request context, so that
+// rakuda.Now(ctx) returns its time in the handlers and in the middlewares installed after it
+// (e.g., RateLimit computes Retry-After with it). Use rakuda.FixedClock in tests, and
+// rakuda.ClockIn to render times in a specific time zone.
+func Clock(clock rakuda.Clock) rakuda.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(rakuda.NewContextWithClock(r.Context(), clock)))
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package rakudamiddleware
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/podhmo/rakuda"
+)
+
+const responseSizesKey = contextKey("responseSizes")
+
+// responseSizes is shared between HTTPLog and Compress, so that the access log
+// can report the size before compression next to the size on the wire.
+type responseSizes struct {
+	compressed   bool
+	uncompressed int
+}
+
+// CompressConfig holds the configuration for the Compress middleware.
+type CompressConfig struct {
+	// Level is the gzip compression level. Default is gzip.DefaultCompression.
+	Level int
+	// ContentTypes is a list of media type prefixes that are compressed (e.g., "text/", "application/json").
+	// Default is to compress every response.
+	ContentTypes []string
+}
+
+// Compress returns a middleware that gzip-compresses responses for clients that accept it.
+// Responses that already have a Content-Encoding are passed through.
+// When installed inside HTTPLog, the access log reports the uncompressed size
+// as "uncompressed_size" next to the compressed "size".
+// If config is nil, it uses the default settings.
+func Compress(config *CompressConfig) rakuda.Middleware {
+	if config == nil {
+		config = &CompressConfig{}
+	}
+	if config.Level == 0 {
+		config.Level = gzip.DefaultCompression
+	}
+	if _, err := gzip.NewWriterLevel(io.Discard, config.Level); err != nil {
+		panic(fmt.Sprintf("rakudamiddleware: invalid CompressConfig.Level: %v", err))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if !acceptsGzip(r) || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			sizes, _ := r.Context().Value(responseSizesKey).(*responseSizes)
+			cw := &compressWriter{ResponseWriter: w, config: config, sizes: sizes}
+			defer cw.close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter compresses the response body, deciding whether to compress
+// when the header is written.
+type compressWriter struct {
+	http.ResponseWriter
+	config      *CompressConfig
+	sizes       *responseSizes
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+func (cw *compressWriter) WriteHeader(statusCode int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	if cw.shouldCompress(statusCode) {
+		h := cw.Header()
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		cw.gz, _ = gzip.NewWriterLevel(cw.ResponseWriter, cw.config.Level) // the level is validated by Compress
+		if cw.sizes != nil {
+			cw.sizes.compressed = true
+		}
+	}
+	cw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (cw *compressWriter) shouldCompress(statusCode int) bool {
+	if statusCode < http.StatusOK || statusCode == http.StatusNoContent || statusCode == http.StatusNotModified {
+		return false
+	}
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	if len(cw.config.ContentTypes) == 0 {
+		return true
+	}
+	contentType := h.Get("Content-Type")
+	for _, prefix := range cw.config.ContentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		if cw.Header().Get("Content-Type") == "" {
+			// Sniff before compressing; the compressed bytes would be detected as binary.
+			cw.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.sizes != nil {
+		cw.sizes.uncompressed += len(b)
+	}
+	if cw.gz != nil {
+		return cw.gz.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, for streaming responses such as SSE.
+func (cw *compressWriter) Flush() {
+	if cw.gz != nil {
+		cw.gz.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressWriter) close() {
+	if cw.gz != nil {
+		cw.gz.Close()
+	}
+}
+
+// withResponseSizes returns a context in which Compress reports the uncompressed size.
+func withResponseSizes(ctx context.Context) (context.Context, *responseSizes) {
+	sizes := &responseSizes{}
+	return context.WithValue(ctx, responseSizesKey, sizes), sizes
+}
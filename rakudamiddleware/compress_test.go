@@ -0,0 +1,98 @@
+package rakudamiddleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/podhmo/rakuda"
+)
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat(`{"message":"hello"}`, 100)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		config         *CompressConfig
+		wantEncoding   string
+	}{
+		{name: "gzip", acceptEncoding: "gzip, deflate", wantEncoding: "gzip"},
+		{name: "not accepted", acceptEncoding: "", wantEncoding: ""},
+		{name: "refused", acceptEncoding: "gzip;q=0", wantEncoding: ""},
+		{name: "content type not listed", acceptEncoding: "gzip", config: &CompressConfig{ContentTypes: []string{"text/"}}, wantEncoding: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rr := httptest.NewRecorder()
+			Compress(tt.config)(handler).ServeHTTP(rr, req)
+
+			if got := rr.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding: got %q, want %q", got, tt.wantEncoding)
+			}
+			got := rr.Body.Bytes()
+			if tt.wantEncoding == "gzip" {
+				zr, err := gzip.NewReader(rr.Body)
+				if err != nil {
+					t.Fatalf("gzip.NewReader() failed: %v", err)
+				}
+				got, _ = io.ReadAll(zr)
+			}
+			if string(got) != body {
+				t.Errorf("body mismatch: got %d bytes, want %d bytes", len(got), len(body))
+			}
+		})
+	}
+}
+
+func TestHTTPLog_Compressed(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	body := strings.Repeat("hello ", 1000)
+	b := rakuda.NewBuilder(rakuda.WithLogger(logger))
+	b.Use(HTTPLog)
+	b.Use(Compress(nil))
+	b.Get("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(body))
+	}))
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var logOutput map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &logOutput); err != nil {
+		t.Fatalf("failed to unmarshal log output: %v", err)
+	}
+	if got, want := logOutput["uncompressed_size"], float64(len(body)); got != want {
+		t.Errorf("uncompressed_size: got %v, want %v", got, want)
+	}
+	if got, want := logOutput["size"], float64(rr.Body.Len()); got != want {
+		t.Errorf("size: got %v, want %v", got, want)
+	}
+	if size := logOutput["size"].(float64); size >= float64(len(body)) {
+		t.Errorf("expected the compressed size to be smaller than %d, got %v", len(body), size)
+	}
+}
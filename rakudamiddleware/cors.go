@@ -14,7 +14,8 @@ type CORSConfig struct {
 	// Use "*" to allow any origin. Default is "*".
 	AllowedOrigins []string
 	// AllowedMethods is a list of methods the client is allowed to use.
-	// Default is GET, POST, PUT, DELETE, PATCH, OPTIONS.
+	// Default is the Allow header set by the router for the path (see rakuda.WithAutoOptions),
+	// or GET, POST, PUT, DELETE, PATCH, OPTIONS if there is none.
 	AllowedMethods []string
 	// AllowedHeaders is a list of headers the client is allowed to use.
 	// Default is Accept, Content-Type, Authorization.
@@ -30,6 +31,7 @@ type CORSConfig struct {
 // CORS returns a middleware that handles Cross-Origin Resource Sharing (CORS).
 // If config is nil, it uses default permissive settings.
 func CORS(config *CORSConfig) rakuda.Middleware {
+	methodsFromRoutes := config == nil || len(config.AllowedMethods) == 0
 	if config == nil {
 		config = &CORSConfig{
 			AllowedOrigins: []string{"*"},
@@ -86,7 +88,11 @@ func CORS(config *CORSConfig) rakuda.Middleware {
 
 			// Handle preflight requests
 			if r.Method == http.MethodOptions {
-				w.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+				methods := allowedMethods
+				if allow := w.Header().Get("Allow"); methodsFromRoutes && allow != "" {
+					methods = allow
+				}
+				w.Header().Set("Access-Control-Allow-Methods", methods)
 				w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
 				if config.MaxAge > 0 {
 					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/podhmo/rakuda"
 )
 
 func TestCORS(t *testing.T) {
@@ -25,4 +27,28 @@ func TestCORS(t *testing.T) {
 			t.Errorf("expected Access-Control-Allow-Origin %q, got %q", "*", rr.Header().Get("Access-Control-Allow-Origin"))
 		}
 	})
+
+	t.Run("preflight with auto options", func(t *testing.T) {
+		b := rakuda.NewBuilder(rakuda.WithAutoOptions())
+		b.Use(CORS(nil))
+		b.Get("/items", handler)
+		b.Post("/items", handler)
+		router, err := b.Build()
+		if err != nil {
+			t.Fatalf("b.Build() failed: %v", err)
+		}
+
+		req := httptest.NewRequest(http.MethodOptions, "/items", nil)
+		req.Header.Set("Origin", "http://example.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNoContent {
+			t.Errorf("expected status code %d, got %d", http.StatusNoContent, rr.Code)
+		}
+		if got, want := rr.Header().Get("Access-Control-Allow-Methods"), "GET, HEAD, OPTIONS, POST"; got != want {
+			t.Errorf("expected Access-Control-Allow-Methods %q, got %q", want, got)
+		}
+	})
 }
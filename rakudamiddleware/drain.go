@@ -0,0 +1,38 @@
+package rakudamiddleware
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/podhmo/rakuda"
+)
+
+// DrainBody returns a middleware that reads and discards the unread part of the request body,
+// up to limit bytes, after the handler returns, and then closes it. Handlers that return early,
+// e.g., on a validation error, leave the body unread, and the connection cannot be reused for
+// the next request until the body is consumed. net/http drains small bodies by itself; DrainBody
+// makes the cap explicit. Larger bodies are read only up to limit, so that clients cannot
+// keep the server reading, and net/http closes their connections. If limit is zero or
+// negative, 1 MB is used.
+func DrainBody(limit int64) rakuda.Middleware {
+	if limit <= 0 {
+		limit = 1 << 20
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body := r.Body
+			next.ServeHTTP(w, r)
+			if body == nil || body == http.NoBody {
+				return
+			}
+			if n, err := io.CopyN(io.Discard, body, limit+1); n > limit {
+				logger := rakuda.LoggerFromContext(r.Context())
+				logger.DebugContext(r.Context(), "request body is too large to drain", "limit", limit)
+			} else if err != nil && err != io.EOF {
+				logger := rakuda.LoggerFromContext(r.Context())
+				logger.DebugContext(r.Context(), "failed to drain request body", "error", err)
+			}
+			body.Close()
+		})
+	}
+}
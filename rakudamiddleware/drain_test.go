@@ -0,0 +1,65 @@
+package rakudamiddleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// trackingBody records how much of the body is read and whether it is closed.
+type trackingBody struct {
+	io.Reader
+	read   int
+	closed bool
+}
+
+func (b *trackingBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	b.read += n
+	return n, err
+}
+
+func (b *trackingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestDrainBody(t *testing.T) {
+	earlyReturn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := make([]byte, 4)
+		r.Body.Read(buf) // reads only a part of the body
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	tests := []struct {
+		name     string
+		size     int
+		limit    int64
+		wantRead int
+	}{
+		{name: "drained", size: 100, limit: 1024, wantRead: 100},
+		{name: "too large", size: 2048, limit: 1024, wantRead: 4 + 1025},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &trackingBody{Reader: strings.NewReader(strings.Repeat("x", tt.size))}
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Body = body
+
+			rr := httptest.NewRecorder()
+			DrainBody(tt.limit)(earlyReturn).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("status code: got %d, want %d", rr.Code, http.StatusBadRequest)
+			}
+			if body.read != tt.wantRead {
+				t.Errorf("bytes read: got %d, want %d", body.read, tt.wantRead)
+			}
+			if !body.closed {
+				t.Error("expected the body to be closed")
+			}
+		})
+	}
+}
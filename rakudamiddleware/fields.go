@@ -0,0 +1,20 @@
+package rakudamiddleware
+
+import (
+	"net/http"
+
+	"github.com/podhmo/rakuda"
+)
+
+// Fields is a middleware that enables partial responses: if the request has a
+// "fields" query parameter (e.g., "?fields=id,name,author.name"), successful JSON
+// responses written by rakuda.Responder are filtered to the selected fields,
+// reducing payload sizes without handler changes.
+func Fields(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fields := rakuda.ParseFields(r.URL.Query().Get("fields")); len(fields) > 0 {
+			r = r.WithContext(rakuda.NewContextWithFields(r.Context(), fields))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
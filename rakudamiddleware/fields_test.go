@@ -0,0 +1,33 @@
+package rakudamiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/podhmo/rakuda"
+)
+
+func TestFields(t *testing.T) {
+	responder := rakuda.NewResponder()
+	handler := Fields(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		responder.JSON(w, r, http.StatusOK, map[string]any{"id": 1, "name": "foo", "profile": map[string]any{"age": 20, "bio": "..."}})
+	}))
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{target: "/", want: `{"id":1,"name":"foo","profile":{"age":20,"bio":"..."}}` + "\n"},
+		{target: "/?fields=id,profile.age", want: `{"id":1,"profile":{"age":20}}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rr.Body.String() != tt.want {
+				t.Errorf("expected body %q, got %q", tt.want, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package rakudamiddleware
+
+import (
+	"net/http"
+
+	"github.com/podhmo/rakuda"
+)
+
+// FeatureFlags returns a middleware that makes flags available to handlers via
+// rakuda.FlagEnabled, evaluated for the principal and the tenant of the request and cached
+// for the rest of it. Install it after the authentication and Tenant middlewares.
+// The flags listed in preload are evaluated up front, e.g., those that most requests check.
+//
+// HTTPLog logs the evaluated flags when the Values middleware is installed.
+func FeatureFlags(flags rakuda.Flags, preload ...string) rakuda.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(rakuda.NewContextWithFlags(r.Context(), flags))
+			for _, flag := range preload {
+				rakuda.FlagEnabled(r, flag)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package rakudamiddleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/podhmo/rakuda"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	var logs strings.Builder
+	flags := rakuda.FlagsFunc(func(ctx context.Context, flag string, subject rakuda.FlagSubject) (bool, error) {
+		return flag == "new-checkout", nil
+	})
+
+	b := rakuda.NewBuilder(rakuda.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	b.Use(Values)
+	b.Use(HTTPLog)
+	b.Use(FeatureFlags(flags, "dark-mode"))
+	b.Get("/checkout", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rakuda.FlagEnabled(r, "new-checkout") {
+			w.Write([]byte("new"))
+			return
+		}
+		w.Write([]byte("old"))
+	}))
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/checkout", nil))
+	if got := rr.Body.String(); got != "new" {
+		t.Errorf("body: got %q, want %q", got, "new")
+	}
+	if want := `flags="map[dark-mode:false new-checkout:true]"`; !strings.Contains(logs.String(), want) {
+		t.Errorf("logs do not contain %q: %s", want, logs.String())
+	}
+}
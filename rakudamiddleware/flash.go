@@ -0,0 +1,21 @@
+package rakudamiddleware
+
+import (
+	"net/http"
+
+	"github.com/podhmo/rakuda"
+)
+
+// Flash returns a middleware that reads the flash messages set by rakuda.Responder.RedirectWithFlash
+// in the previous request, clears them, and makes them available via rakuda.FlashesFromContext.
+// secret must be the FlashSecret of the Responder that set them.
+func Flash(secret []byte) rakuda.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if flashes := rakuda.ReadFlashes(w, r, secret); len(flashes) > 0 {
+				r = r.WithContext(rakuda.NewContextWithFlashes(r.Context(), flashes))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
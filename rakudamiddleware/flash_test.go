@@ -0,0 +1,32 @@
+package rakudamiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda"
+)
+
+func TestFlash(t *testing.T) {
+	secret := []byte("s3cret")
+	redirect := httptest.NewRecorder()
+	(&rakuda.Responder{FlashSecret: secret}).RedirectWithFlash(redirect, httptest.NewRequest(http.MethodPost, "/", nil), "/", http.StatusSeeOther, rakuda.Flash{Kind: "error", Message: "failed"})
+
+	var got []rakuda.Flash
+	handler := Flash(secret)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = rakuda.FlashesFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range redirect.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := []rakuda.Flash{{Kind: "error", Message: "failed"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("flashes mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -1,6 +1,7 @@
 package rakudamiddleware
 
 import (
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -29,25 +30,62 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 
 // HTTPLog is a middleware that logs request and response information.
 func HTTPLog(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+	return HTTPLogWithConfig(HTTPLogConfig{Level: slog.LevelInfo})(next)
+}
+
+// HTTPLogConfig holds the configuration for the HTTPLogWithConfig middleware.
+type HTTPLogConfig struct {
+	// Level is the level of the request log records. Default is Info (the zero value).
+	Level slog.Level
+}
+
+// HTTPLogWithConfig returns an HTTPLog middleware that logs at the configured level.
+func HTTPLogWithConfig(config HTTPLogConfig) rakuda.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			_, hasTenant := rakuda.TenantFromContext(r.Context())
 
-		// Wrap the response writer
-		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			// Wrap the response writer
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			ctx, sizes := withResponseSizes(r.Context())
+			ctx, timings := rakuda.NewContextWithTimings(ctx)
+			r = r.WithContext(ctx)
 
-		next.ServeHTTP(rw, r)
+			next.ServeHTTP(rw, r)
 
-		duration := time.Since(start)
+			duration := time.Since(start)
 
-		logger := rakuda.LoggerFromContext(r.Context())
+			logger := rakuda.LoggerFromContext(r.Context())
 
-		logger.InfoContext(r.Context(), "request",
-			"method", r.Method,
-			"path", r.URL.Path,
-			"status", rw.status,
-			"size", rw.size,
-			"content-type", rw.Header().Get("Content-Type"),
-			"duration", duration,
-		)
-	})
+			attrs := []any{
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", rw.status,
+				"size", rw.size, // bytes on the wire, after compression
+				"content-type", rw.Header().Get("Content-Type"),
+				"duration", duration,
+			}
+			if sizes.compressed {
+				attrs = append(attrs, "uncompressed_size", sizes.uncompressed)
+			}
+			if meta, ok := rakuda.RouteMetaFromContext(r.Context()); ok && len(meta.Tags) > 0 {
+				attrs = append(attrs, "tags", meta.Tags)
+			}
+			if variant, ok := rakuda.VariantFromContext(r.Context()); ok {
+				attrs = append(attrs, "experiment", variant.Experiment, "variant", variant.Name)
+			}
+			if tenant, ok := rakuda.TenantFromContext(r.Context()); ok && !hasTenant {
+				// A tenant resolved by an inner middleware is not on the logger yet.
+				attrs = append(attrs, "tenant", string(tenant))
+			}
+			if flags, ok := rakuda.EvaluatedFlagsFromContext(r.Context()); ok && len(flags) > 0 {
+				attrs = append(attrs, "flags", flags)
+			}
+			if len(timings.Entries()) > 0 {
+				attrs = append(attrs, "timings", timings)
+			}
+			logger.Log(r.Context(), config.Level, "request", attrs...)
+		})
+	}
 }
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/podhmo/rakuda"
@@ -186,3 +187,81 @@ func TestResponseWriter_Write(t *testing.T) {
 		t.Errorf("response body mismatch (-want +got):\n%s", diff)
 	}
 }
+
+// TestHTTPLog_RouteTags verifies that the tags of the matched route are logged.
+func TestHTTPLog_RouteTags(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	b := rakuda.NewBuilder(rakuda.WithLogger(logger))
+	b.Use(HTTPLog)
+	b.Get("/health", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), rakuda.Meta{Tags: []string{"public"}})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var logOutput map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &logOutput); err != nil {
+		t.Fatalf("failed to unmarshal log output: %v", err)
+	}
+	if diff := cmp.Diff([]any{"public"}, logOutput["tags"]); diff != "" {
+		t.Errorf("tags mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHTTPLog_ExperimentVariant(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	b := rakuda.NewBuilder(rakuda.WithLogger(logger))
+	b.Use(Values)
+	b.Use(HTTPLog)
+	b.Experiment("GET /checkout", rakuda.Split{
+		Name:     "new-checkout",
+		Control:  http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		Variant:  http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		Fraction: 1,
+		KeyFunc:  func(r *http.Request) string { return "user-1" },
+	})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/checkout", nil))
+
+	var logOutput map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &logOutput); err != nil {
+		t.Fatalf("failed to unmarshal log output: %v", err)
+	}
+	if got, want := logOutput["experiment"], "new-checkout"; got != want {
+		t.Errorf("experiment: got %v, want %v", got, want)
+	}
+	if got, want := logOutput["variant"], rakuda.VariantTest; got != want {
+		t.Errorf("variant: got %v, want %v", got, want)
+	}
+}
+
+func TestHTTPLog_Timings(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := HTTPLog(ServerTiming(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rakuda.Timing(r.Context()).Add("db", 2*time.Millisecond)
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(rakuda.NewContextWithLogger(req.Context(), logger))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var logOutput map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &logOutput); err != nil {
+		t.Fatalf("failed to unmarshal log output: %v", err)
+	}
+	want := map[string]any{"db": float64(2 * time.Millisecond)}
+	if diff := cmp.Diff(want, logOutput["timings"]); diff != "" {
+		t.Errorf("timings mismatch (-want +got):\n%s", diff)
+	}
+}
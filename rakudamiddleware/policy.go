@@ -0,0 +1,356 @@
+package rakudamiddleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/podhmo/rakuda"
+)
+
+// ErrPolicyDenied is the error of responses rejected by the Policy middleware.
+var ErrPolicyDenied = errors.New("access denied by policy")
+
+// Policy returns a middleware that authorizes requests with an expression, so that simple
+// rules can be declared per route instead of writing a middleware for each:
+//
+//	b.With(rakudamiddleware.Policy(`role == "admin" || owner == path.id`)).Delete("/users/{id}", deleteUser)
+//
+// The expression has the operators ==, !=, in, !, &&, and ||, parentheses, string literals
+// in double quotes, true and false, and the following names:
+//
+//   - id, kind: the ID and Kind of the principal (see rakuda.PrincipalFromContext).
+//   - authenticated: whether the request has a principal.
+//   - method: the request method.
+//   - path.NAME: the path value NAME (see http.Request.PathValue).
+//   - meta.name, meta.tags, meta.scopes, meta.extra.KEY: the metadata of the matched route.
+//     meta.tags and meta.scopes are lists, for the in operator (e.g., "public" in meta.tags).
+//   - any other name: the attribute of the principal with that name (e.g., role).
+//
+// Comparisons with a missing value (e.g., an attribute that is not set) are false, so that
+// owner == path.id does not hold for a principal without an owner attribute. Used as a
+// condition, a value is true if it is a non-empty string or list.
+//
+// Denied requests are rejected with 401 if there is no principal, and 403 otherwise.
+// It panics if the expression is invalid, so that mistakes are caught at startup.
+func Policy(expr string) rakuda.Middleware {
+	node, err := parsePolicy(expr)
+	if err != nil {
+		panic(fmt.Sprintf("rakudamiddleware: invalid policy %q: %v", expr, err))
+	}
+	responder := rakuda.NewResponder()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if node.eval(r).truthy() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if _, ok := rakuda.PrincipalFromContext(r.Context()); !ok {
+				responder.Error(w, r, http.StatusUnauthorized, ErrPolicyDenied)
+				return
+			}
+			responder.Error(w, r, http.StatusForbidden, ErrPolicyDenied)
+		})
+	}
+}
+
+// policyValue is the value of a policy expression.
+type policyValue struct {
+	missing bool
+	isBool  bool
+	isList  bool
+	b       bool
+	s       string
+	list    []string
+}
+
+func (v policyValue) truthy() bool {
+	switch {
+	case v.missing:
+		return false
+	case v.isBool:
+		return v.b
+	case v.isList:
+		return len(v.list) > 0
+	default:
+		return v.s != ""
+	}
+}
+
+func (v policyValue) equal(other policyValue) bool {
+	switch {
+	case v.isBool || other.isBool:
+		return v.isBool && other.isBool && v.b == other.b
+	case v.isList || other.isList:
+		return v.isList && other.isList && slices.Equal(v.list, other.list)
+	default:
+		return v.s == other.s
+	}
+}
+
+func boolValue(b bool) policyValue { return policyValue{isBool: true, b: b} }
+
+var missingValue = policyValue{missing: true}
+
+// policyNode is a node of a parsed policy expression.
+type policyNode interface {
+	eval(r *http.Request) policyValue
+}
+
+type (
+	policyLiteral struct{ value policyValue }
+	policyName    struct{ name string }
+	policyNot     struct{ x policyNode }
+	policyBinary  struct {
+		op   string
+		x, y policyNode
+	}
+)
+
+func (n policyLiteral) eval(*http.Request) policyValue { return n.value }
+
+func (n policyNot) eval(r *http.Request) policyValue { return boolValue(!n.x.eval(r).truthy()) }
+
+func (n policyBinary) eval(r *http.Request) policyValue {
+	switch n.op {
+	case "&&":
+		return boolValue(n.x.eval(r).truthy() && n.y.eval(r).truthy())
+	case "||":
+		return boolValue(n.x.eval(r).truthy() || n.y.eval(r).truthy())
+	}
+	x, y := n.x.eval(r), n.y.eval(r)
+	if x.missing || y.missing {
+		return boolValue(false)
+	}
+	switch n.op {
+	case "==":
+		return boolValue(x.equal(y))
+	case "!=":
+		return boolValue(!x.equal(y))
+	default: // "in"
+		if !y.isList {
+			return boolValue(x.equal(y))
+		}
+		return boolValue(!x.isBool && !x.isList && slices.Contains(y.list, x.s))
+	}
+}
+
+func (n policyName) eval(r *http.Request) policyValue {
+	ctx := r.Context()
+	principal, hasPrincipal := rakuda.PrincipalFromContext(ctx)
+	switch {
+	case n.name == "authenticated":
+		return boolValue(hasPrincipal)
+	case n.name == "method":
+		return policyValue{s: r.Method}
+	case strings.HasPrefix(n.name, "path."):
+		if v := r.PathValue(strings.TrimPrefix(n.name, "path.")); v != "" {
+			return policyValue{s: v}
+		}
+		return missingValue
+	case strings.HasPrefix(n.name, "meta."):
+		meta, ok := rakuda.RouteMetaFromContext(ctx)
+		if !ok {
+			return missingValue
+		}
+		switch key := strings.TrimPrefix(n.name, "meta."); {
+		case key == "name":
+			return policyValue{s: meta.Name}
+		case key == "tags":
+			return policyValue{isList: true, list: meta.Tags}
+		case key == "scopes":
+			return policyValue{isList: true, list: meta.Scopes}
+		case strings.HasPrefix(key, "extra."):
+			if v, ok := meta.Extra[strings.TrimPrefix(key, "extra.")]; ok {
+				return policyValue{s: fmt.Sprint(v)}
+			}
+		}
+		return missingValue
+	case !hasPrincipal:
+		return missingValue
+	case n.name == "id":
+		return policyValue{s: principal.ID}
+	case n.name == "kind":
+		return policyValue{s: principal.Kind}
+	}
+	if v, ok := principal.Attributes[n.name]; ok {
+		return policyValue{s: v}
+	}
+	return missingValue
+}
+
+// parsePolicy parses a policy expression:
+//
+//	expr       = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | comparison
+//	comparison = operand [ ( "==" | "!=" | "in" ) operand ]
+//	operand    = string | "true" | "false" | name | "(" expr ")"
+func parsePolicy(expr string) (policyNode, error) {
+	tokens, err := tokenizePolicy(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &policyParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return node, nil
+}
+
+type policyParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *policyParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *policyParser) parseOr() (policyNode, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.pos++
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = policyBinary{op: "||", x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *policyParser) parseAnd() (policyNode, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.pos++
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = policyBinary{op: "&&", x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *policyParser) parseUnary() (policyNode, error) {
+	if p.peek() == "!" {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return policyNot{x: x}, nil
+	}
+	x, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	switch op := p.peek(); op {
+	case "==", "!=", "in":
+		p.pos++
+		y, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return policyBinary{op: op, x: x, y: y}, nil
+	}
+	return x, nil
+}
+
+func (p *policyParser) parseOperand() (policyNode, error) {
+	tok := p.peek()
+	p.pos++
+	switch {
+	case tok == "":
+		return nil, errors.New("unexpected end of expression")
+	case tok == "(":
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return x, nil
+	case tok[0] == '"':
+		s, err := strconv.Unquote(tok)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", tok)
+		}
+		return policyLiteral{value: policyValue{s: s}}, nil
+	case tok == "true" || tok == "false":
+		return policyLiteral{value: boolValue(tok == "true")}, nil
+	case isPolicyNameByte(tok[0]) && tok[0] != '-' && tok != "in":
+		return policyName{name: tok}, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+}
+
+// tokenizePolicy splits a policy expression into operators, parentheses, string literals, and names.
+func tokenizePolicy(expr string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case strings.HasPrefix(expr[i:], "==") || strings.HasPrefix(expr[i:], "!=") ||
+			strings.HasPrefix(expr[i:], "&&") || strings.HasPrefix(expr[i:], "||"):
+			tokens = append(tokens, expr[i:i+2])
+			i += 2
+		case c == '!':
+			tokens = append(tokens, "!")
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(expr) && expr[j] != '"' {
+				if expr[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(expr) {
+				return nil, errors.New("unterminated string")
+			}
+			tokens = append(tokens, expr[i:j+1])
+			i = j + 1
+		case isPolicyNameByte(c) && c != '-':
+			j := i
+			for j < len(expr) && (isPolicyNameByte(expr[j]) || expr[j] == '.' || ('0' <= expr[j] && expr[j] <= '9')) {
+				j++
+			}
+			tokens = append(tokens, expr[i:j])
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	return tokens, nil
+}
+
+func isPolicyNameByte(c byte) bool {
+	return c == '_' || c == '-' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,73 @@
+package rakudamiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda"
+)
+
+func TestPolicy(t *testing.T) {
+	admin := &rakuda.Principal{ID: "u1", Kind: "user", Attributes: map[string]string{"role": "admin"}}
+	owner := &rakuda.Principal{ID: "u2", Kind: "user", Attributes: map[string]string{"owner": "42"}}
+	guest := &rakuda.Principal{ID: "u3", Kind: "user"}
+
+	tests := []struct {
+		name       string
+		expr       string
+		principal  *rakuda.Principal
+		path       string
+		wantStatus int
+	}{
+		{name: "admin", expr: `role == "admin" || owner == path.id`, principal: admin, path: "/users/1", wantStatus: http.StatusOK},
+		{name: "owner", expr: `role == "admin" || owner == path.id`, principal: owner, path: "/users/42", wantStatus: http.StatusOK},
+		{name: "not owner", expr: `role == "admin" || owner == path.id`, principal: owner, path: "/users/1", wantStatus: http.StatusForbidden},
+		{name: "missing attributes", expr: `role == "admin" || owner == path.id`, principal: guest, path: "/users/1", wantStatus: http.StatusForbidden},
+		{name: "no principal", expr: `role == "admin"`, path: "/users/1", wantStatus: http.StatusUnauthorized},
+		{name: "missing is never equal", expr: `role != "admin"`, principal: guest, path: "/users/1", wantStatus: http.StatusForbidden},
+		{name: "authenticated", expr: `authenticated && kind == "user"`, principal: guest, path: "/users/1", wantStatus: http.StatusOK},
+		{name: "not", expr: `!(role == "admin")`, principal: guest, path: "/users/1", wantStatus: http.StatusOK},
+		{name: "precedence", expr: `role == "admin" || id == "u3" && method == "GET"`, principal: guest, path: "/users/1", wantStatus: http.StatusOK},
+		{name: "in tags", expr: `"public" in meta.tags`, path: "/users/1", wantStatus: http.StatusOK},
+		{name: "not in scopes", expr: `"users:write" in meta.scopes`, principal: admin, path: "/users/1", wantStatus: http.StatusForbidden},
+		{name: "meta extra", expr: `meta.extra.level == "1"`, principal: admin, path: "/users/1", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := rakuda.NewBuilder()
+			b.With(Policy(tt.expr)).Get("/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}), rakuda.Meta{Tags: []string{"public"}, Scopes: []string{"users:read"}, Extra: map[string]any{"level": 1}})
+			router, err := b.Build()
+			if err != nil {
+				t.Fatalf("b.Build() failed: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.principal != nil {
+				req = req.WithContext(rakuda.NewContextWithPrincipal(req.Context(), tt.principal))
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if diff := cmp.Diff(tt.wantStatus, rr.Code); diff != "" {
+				t.Errorf("status mismatch (-want +got):\n%s\n%s", diff, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestPolicy_Invalid(t *testing.T) {
+	for _, expr := range []string{``, `role ==`, `(role == "admin"`, `role == "admin`, `role = "admin"`, `role == "admin" extra`, `in == "x"`} {
+		t.Run(expr, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Policy(%q) did not panic", expr)
+				}
+			}()
+			Policy(expr)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package rakudamiddleware
+
+import "github.com/podhmo/rakuda"
+
+// UseProfile applies an environment profile to the root builder in one call: it sets the
+// responder of the profile (see rakuda.Builder.UseResponder) and adds the HTTPLog and
+// Recovery middlewares configured by it.
+//
+//	b := rakuda.NewBuilder()
+//	rakudamiddleware.UseProfile(b, rakuda.LookupProfile(os.Getenv("APP_ENV")))
+func UseProfile(b *rakuda.Builder, profile rakuda.Profile) {
+	b.Use(HTTPLogWithConfig(HTTPLogConfig{Level: profile.LogLevel}))
+	b.Use(RecoveryWithConfig(RecoveryConfig{ExposeStack: profile.ExposeStack}))
+	b.UseResponder(profile.Responder())
+}
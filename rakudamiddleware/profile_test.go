@@ -0,0 +1,67 @@
+package rakudamiddleware
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/podhmo/rakuda"
+)
+
+func TestUseProfile(t *testing.T) {
+	tests := []struct {
+		profile     rakuda.Profile
+		wantError   string
+		wantStack   bool
+		wantLogged  bool // at the Info level of the logger
+		wantIndent  bool
+		wantVerbose bool
+	}{
+		{profile: rakuda.Development, wantError: "a panic occurred: boom", wantStack: true, wantLogged: false, wantIndent: true, wantVerbose: true},
+		{profile: rakuda.Production, wantError: "Internal Server Error", wantLogged: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile.Name, func(t *testing.T) {
+			var logs strings.Builder
+			b := rakuda.NewBuilder(rakuda.WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+			UseProfile(b, tt.profile)
+			b.Get("/panic", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") }))
+			b.Get("/error", rakuda.Lift(nil, func(r *http.Request) (map[string]string, error) {
+				return nil, errors.New("database is down")
+			}))
+			router, err := b.Build()
+			if err != nil {
+				t.Fatalf("b.Build() failed: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/panic", nil))
+			var body map[string]string
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to unmarshal the body: %v\n%s", err, rr.Body.String())
+			}
+			if got := body["error"]; got != tt.wantError {
+				t.Errorf("error: got %q, want %q", got, tt.wantError)
+			}
+			if got := body["stack"] != ""; got != tt.wantStack {
+				t.Errorf("stack exposed: got %v, want %v", got, tt.wantStack)
+			}
+
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/error", nil))
+			if got := strings.Contains(rr.Body.String(), "database is down"); got != tt.wantVerbose {
+				t.Errorf("verbose error: got %v, want %v\n%s", got, tt.wantVerbose, rr.Body.String())
+			}
+			if got := strings.Contains(rr.Body.String(), "\n  "); got != tt.wantIndent {
+				t.Errorf("indented: got %v, want %v\n%s", got, tt.wantIndent, rr.Body.String())
+			}
+			if got := strings.Contains(logs.String(), `"msg":"request"`); got != tt.wantLogged {
+				t.Errorf("request logged: got %v, want %v", got, tt.wantLogged)
+			}
+		})
+	}
+}
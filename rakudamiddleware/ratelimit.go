@@ -0,0 +1,199 @@
+package rakudamiddleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/podhmo/rakuda"
+)
+
+// ErrRateLimited is the error of responses rejected by the RateLimit middleware.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// Quota is a rate-limit policy: at most Limit requests per Window.
+// A zero Limit means no limit.
+type Quota struct {
+	Limit  int64
+	Window time.Duration
+}
+
+// UsageStore counts requests per key and window, so that limits can be shared across instances.
+type UsageStore interface {
+	// Increment adds one to the usage of key and returns the new usage.
+	// The usage can be forgotten after expiresAt.
+	Increment(ctx context.Context, key string, expiresAt time.Time) (int64, error)
+}
+
+// RateLimitConfig holds the configuration for the RateLimit middleware.
+type RateLimitConfig struct {
+	// Quota is the default policy.
+	Quota Quota
+	// Policy returns the policy for a key, for per-tenant or per-plan quotas.
+	// If it returns ok=false, the default Quota is used.
+	Policy func(r *http.Request, key string) (quota Quota, ok bool)
+	// Key identifies the caller whose usage is counted.
+	// Default is the tenant (rakuda.TenantFromContext), then the principal
+	// (rakuda.PrincipalFromContext), then the remote IP address.
+	Key func(*http.Request) string
+	// Store counts the usage. Default is an in-memory store.
+	Store UsageStore
+	// WarnAt are fractions of the quota (e.g., 0.8 for 80%) from which responses carry
+	// a Warning header (code 299). Crossing a threshold is logged at warn level with
+	// the key and the principal, so that operators can reach out to the caller.
+	WarnAt []float64
+	// Soft makes the quota advisory: requests over the quota are served with a Warning header
+	// instead of being rejected, giving API consumers a grace period to fix their clients.
+	// The first request over the quota in each window is logged.
+	Soft bool
+}
+
+// RateLimit returns a middleware that enforces request quotas with fixed windows.
+// Every response carries the X-RateLimit-Limit, X-RateLimit-Remaining, and X-RateLimit-Reset
+// (Unix time in seconds) headers; requests over the quota are rejected with 429 and Retry-After.
+// If the store fails, the error is logged and the request is allowed.
+// See WarnAt and Soft for warning callers before rejecting them.
+func RateLimit(config RateLimitConfig) rakuda.Middleware {
+	if config.Key == nil {
+		config.Key = defaultRateLimitKey
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryUsageStore()
+	}
+	responder := rakuda.NewResponder()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := config.Key(r)
+			quota := config.Quota
+			if config.Policy != nil {
+				if q, ok := config.Policy(r, key); ok {
+					quota = q
+				}
+			}
+			if quota.Limit <= 0 || quota.Window <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			now := rakuda.Now(r.Context())
+			start := now.Truncate(quota.Window)
+			reset := start.Add(quota.Window)
+			windowKey := key + "@" + strconv.FormatInt(start.Unix(), 10)
+			used, err := config.Store.Increment(r.Context(), windowKey, reset)
+			if err != nil {
+				logger := rakuda.LoggerFromContext(r.Context())
+				logger.ErrorContext(r.Context(), "failed to count rate limit usage", "error", err, "key", key)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("X-RateLimit-Limit", strconv.FormatInt(quota.Limit, 10))
+			h.Set("X-RateLimit-Remaining", strconv.FormatInt(max(quota.Limit-used, 0), 10))
+			h.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+			switch threshold, warn := rateLimitThreshold(config.WarnAt, quota.Limit, used); {
+			case used > quota.Limit && !config.Soft:
+				retryAfter := int64(reset.Sub(now).Seconds() + 0.999) // round up
+				h.Set("Retry-After", strconv.FormatInt(max(retryAfter, 1), 10))
+				responder.Error(w, r, http.StatusTooManyRequests, ErrRateLimited)
+				return
+			case used > quota.Limit:
+				if used == quota.Limit+1 {
+					logRateLimitWarning(r, key, quota, used, "rate limit exceeded (soft)")
+				}
+				h.Add("Warning", fmt.Sprintf("299 - %q", fmt.Sprintf("rate limit exceeded: %d of %d requests", used, quota.Limit)))
+			case warn:
+				if used == threshold {
+					logRateLimitWarning(r, key, quota, used, "rate limit threshold reached")
+				}
+				h.Add("Warning", fmt.Sprintf("299 - %q", fmt.Sprintf("rate limit: %d of %d requests used", used, quota.Limit)))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitThreshold returns the highest usage threshold of warnAt reached by used.
+func rateLimitThreshold(warnAt []float64, limit, used int64) (int64, bool) {
+	var reached int64
+	for _, fraction := range warnAt {
+		threshold := max(int64(math.Ceil(fraction*float64(limit))), 1)
+		if used >= threshold && threshold > reached {
+			reached = threshold
+		}
+	}
+	return reached, reached > 0
+}
+
+func logRateLimitWarning(r *http.Request, key string, quota Quota, used int64, msg string) {
+	ctx := r.Context()
+	var principal string
+	if p, ok := rakuda.PrincipalFromContext(ctx); ok {
+		principal = p.ID
+	}
+	rakuda.LoggerFromContext(ctx).WarnContext(ctx, msg, "key", key, "principal", principal, "used", used, "limit", quota.Limit)
+}
+
+func defaultRateLimitKey(r *http.Request) string {
+	if tenant, ok := rakuda.TenantFromContext(r.Context()); ok && tenant != "" {
+		return "tenant:" + string(tenant)
+	}
+	if p, ok := rakuda.PrincipalFromContext(r.Context()); ok && p.ID != "" {
+		return "principal:" + p.ID
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// MemoryUsageStore is an in-memory UsageStore. Usage is not shared across processes.
+type MemoryUsageStore struct {
+	mu      sync.Mutex
+	entries map[string]*usageEntry
+	sweep   time.Time
+}
+
+type usageEntry struct {
+	count     int64
+	expiresAt time.Time
+}
+
+var _ UsageStore = (*MemoryUsageStore)(nil)
+
+// NewMemoryUsageStore creates a new MemoryUsageStore.
+func NewMemoryUsageStore() *MemoryUsageStore {
+	return &MemoryUsageStore{entries: map[string]*usageEntry{}}
+}
+
+// Increment implements UsageStore.
+func (s *MemoryUsageStore) Increment(ctx context.Context, key string, expiresAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := rakuda.Now(ctx)
+	if now.Sub(s.sweep) > time.Minute {
+		for k, e := range s.entries {
+			if now.After(e.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.sweep = now
+	}
+
+	e, ok := s.entries[key]
+	if !ok {
+		e = &usageEntry{expiresAt: expiresAt}
+		s.entries[key] = e
+	}
+	e.count++
+	return e.count, nil
+}
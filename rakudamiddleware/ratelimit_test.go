@@ -0,0 +1,170 @@
+package rakudamiddleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda"
+)
+
+func TestRateLimit(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	withTenant := func(tenant rakuda.TenantID) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		return req.WithContext(rakuda.NewContextWithTenant(req.Context(), tenant))
+	}
+
+	t.Run("per-tenant quotas", func(t *testing.T) {
+		handler := RateLimit(RateLimitConfig{
+			Quota: Quota{Limit: 2, Window: time.Hour},
+			Policy: func(r *http.Request, key string) (Quota, bool) {
+				if key == "tenant:enterprise" {
+					return Quota{Limit: 3, Window: time.Hour}, true
+				}
+				return Quota{}, false
+			},
+		})(ok)
+
+		serve := func(tenant rakuda.TenantID) []int {
+			var codes []int
+			for i := 0; i < 4; i++ {
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, withTenant(tenant))
+				codes = append(codes, rr.Code)
+			}
+			return codes
+		}
+		if diff := cmp.Diff([]int{200, 200, 429, 429}, serve("free")); diff != "" {
+			t.Errorf("free tenant (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]int{200, 200, 200, 429}, serve("enterprise")); diff != "" {
+			t.Errorf("enterprise tenant (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		handler := RateLimit(RateLimitConfig{Quota: Quota{Limit: 1, Window: time.Hour}})(ok)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, withTenant("acme"))
+		if got, want := rr.Header().Get("X-RateLimit-Limit"), "1"; got != want {
+			t.Errorf("X-RateLimit-Limit: got %q, want %q", got, want)
+		}
+		if got, want := rr.Header().Get("X-RateLimit-Remaining"), "0"; got != want {
+			t.Errorf("X-RateLimit-Remaining: got %q, want %q", got, want)
+		}
+		reset, err := strconv.ParseInt(rr.Header().Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil || reset < time.Now().Unix() || reset > time.Now().Add(time.Hour).Unix() {
+			t.Errorf("X-RateLimit-Reset: unexpected value %q", rr.Header().Get("X-RateLimit-Reset"))
+		}
+
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, withTenant("acme"))
+		if got, want := rr.Code, http.StatusTooManyRequests; got != want {
+			t.Errorf("status: got %d, want %d", got, want)
+		}
+		if rr.Header().Get("Retry-After") == "" {
+			t.Error("expected a Retry-After header")
+		}
+	})
+
+	t.Run("clock", func(t *testing.T) {
+		now := time.Date(2024, 5, 1, 12, 40, 0, 0, time.UTC)
+		handler := Clock(rakuda.FixedClock(now))(RateLimit(RateLimitConfig{Quota: Quota{Limit: 1, Window: time.Hour}})(ok))
+
+		handler.ServeHTTP(httptest.NewRecorder(), withTenant("clock"))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, withTenant("clock"))
+		if got, want := rr.Header().Get("Retry-After"), "1200"; got != want {
+			t.Errorf("Retry-After: got %q, want %q", got, want)
+		}
+		if got, want := rr.Header().Get("X-RateLimit-Reset"), strconv.FormatInt(now.Add(20*time.Minute).Unix(), 10); got != want {
+			t.Errorf("X-RateLimit-Reset: got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("soft limits", func(t *testing.T) {
+		var logs strings.Builder
+		logger := slog.New(slog.NewTextHandler(&logs, nil))
+		handler := RateLimit(RateLimitConfig{
+			Quota:  Quota{Limit: 4, Window: time.Hour},
+			WarnAt: []float64{0.5, 0.75},
+			Soft:   true,
+		})(ok)
+
+		var codes []int
+		var warnings []string
+		for i := 0; i < 6; i++ {
+			req := withTenant("soft")
+			req = req.WithContext(rakuda.NewContextWithLogger(rakuda.NewContextWithPrincipal(req.Context(), &rakuda.Principal{ID: "alice"}), logger))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			codes = append(codes, rr.Code)
+			warnings = append(warnings, rr.Header().Get("Warning"))
+		}
+		if diff := cmp.Diff([]int{200, 200, 200, 200, 200, 200}, codes); diff != "" {
+			t.Errorf("status codes (-want +got):\n%s", diff)
+		}
+		want := []string{
+			"",
+			`299 - "rate limit: 2 of 4 requests used"`,
+			`299 - "rate limit: 3 of 4 requests used"`,
+			`299 - "rate limit: 4 of 4 requests used"`,
+			`299 - "rate limit exceeded: 5 of 4 requests"`,
+			`299 - "rate limit exceeded: 6 of 4 requests"`,
+		}
+		if diff := cmp.Diff(want, warnings); diff != "" {
+			t.Errorf("Warning headers (-want +got):\n%s", diff)
+		}
+		// Each threshold is logged once, when it is crossed.
+		if got, want := strings.Count(logs.String(), "principal=alice"), 3; got != want {
+			t.Errorf("logged warnings: got %d, want %d\n%s", got, want, logs.String())
+		}
+	})
+
+	t.Run("warnings before the hard limit", func(t *testing.T) {
+		handler := RateLimit(RateLimitConfig{
+			Quota:  Quota{Limit: 2, Window: time.Hour},
+			WarnAt: []float64{0.5},
+		})(ok)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, withTenant("hard"))
+		if got := rr.Header().Get("Warning"); got == "" {
+			t.Error("expected a Warning header")
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), withTenant("hard"))
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, withTenant("hard"))
+		if got, want := rr.Code, http.StatusTooManyRequests; got != want {
+			t.Errorf("status: got %d, want %d", got, want)
+		}
+	})
+
+	t.Run("store failure allows the request", func(t *testing.T) {
+		handler := RateLimit(RateLimitConfig{
+			Quota: Quota{Limit: 1, Window: time.Minute},
+			Store: failingUsageStore{},
+		})(ok)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		if got, want := rr.Code, http.StatusOK; got != want {
+			t.Errorf("status: got %d, want %d", got, want)
+		}
+	})
+}
+
+type failingUsageStore struct{}
+
+func (failingUsageStore) Increment(ctx context.Context, key string, expiresAt time.Time) (int64, error) {
+	return 0, errors.New("store down")
+}
@@ -0,0 +1,243 @@
+package rakudamiddleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/podhmo/rakuda"
+)
+
+// Redacted replaces the values of redacted headers in a Recording.
+const Redacted = "[REDACTED]"
+
+// Recording is a captured request/response pair.
+type Recording struct {
+	ID       string        `json:"id"`
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+
+	Method        string      `json:"method"`
+	URL           string      `json:"url"`
+	RequestHeader http.Header `json:"requestHeader,omitempty"`
+	RequestBody   string      `json:"requestBody,omitempty"`
+
+	Status         int         `json:"status"`
+	ResponseHeader http.Header `json:"responseHeader,omitempty"`
+	ResponseBody   string      `json:"responseBody,omitempty"`
+
+	// Truncated reports whether a body was cut at RecordConfig.MaxBodySize.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Request rebuilds the recorded request, for replaying it against a handler.
+func (rec *Recording) Request() (*http.Request, error) {
+	req, err := http.NewRequest(rec.Method, rec.URL, strings.NewReader(rec.RequestBody))
+	if err != nil {
+		return nil, fmt.Errorf("rebuild recorded request: %w", err)
+	}
+	req.Header = rec.RequestHeader.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.RequestURI = req.URL.RequestURI()
+	return req, nil
+}
+
+// RecordingStore persists recordings.
+type RecordingStore interface {
+	Save(ctx context.Context, rec *Recording) error
+}
+
+// RecordConfig holds the configuration for the Record middleware.
+type RecordConfig struct {
+	// Store persists the recordings. It is required.
+	Store RecordingStore
+	// Enabled reports whether a request is recorded. Default is every request.
+	Enabled func(*http.Request) bool
+	// MaxBodySize is the maximum number of bytes recorded per body. Default is 64 KB.
+	MaxBodySize int64
+	// RedactHeaders are request and response headers whose values are replaced with Redacted.
+	// Default is Authorization, Cookie, Set-Cookie, and X-API-Key.
+	RedactHeaders []string
+	// Redact is called before a recording is saved, to remove sensitive data from the bodies.
+	Redact func(*Recording)
+}
+
+// Record returns an opt-in middleware that captures full request/response pairs into a store,
+// for reproducing production bugs. Recorded requests can be replayed against a handler
+// with rakudatest.Replay. Failures to save a recording are logged and do not affect the response.
+func Record(config RecordConfig) rakuda.Middleware {
+	if config.Store == nil {
+		panic("rakudamiddleware: RecordConfig.Store is required")
+	}
+	if config.MaxBodySize == 0 {
+		config.MaxBodySize = 64 << 10
+	}
+	if config.RedactHeaders == nil {
+		config.RedactHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-API-Key"}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if config.Enabled != nil && !config.Enabled(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &Recording{
+				ID:            rakuda.NewID(8),
+				Time:          time.Now(),
+				Method:        r.Method,
+				URL:           r.URL.RequestURI(),
+				RequestHeader: redactHeader(r.Header, config.RedactHeaders),
+			}
+			// The request body is recorded as far as the handler reads it.
+			reqBody := &cappedBuffer{max: config.MaxBodySize}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.TeeReader(r.Body, reqBody), r.Body}
+			}
+
+			rw := &recordingWriter{ResponseWriter: w, body: cappedBuffer{max: config.MaxBodySize}}
+			next.ServeHTTP(rw, r)
+
+			rec.Duration = time.Since(rec.Time)
+			rec.Status = rw.status
+			if rec.Status == 0 {
+				rec.Status = http.StatusOK
+			}
+			if rw.header == nil {
+				rw.header = w.Header().Clone()
+			}
+			rec.ResponseHeader = redactHeader(rw.header, config.RedactHeaders)
+			rec.ResponseBody = rw.body.String()
+			rec.RequestBody = reqBody.String()
+			rec.Truncated = reqBody.truncated || rw.body.truncated
+
+			if config.Redact != nil {
+				config.Redact(rec)
+			}
+			if err := config.Store.Save(context.WithoutCancel(r.Context()), rec); err != nil {
+				logger := rakuda.LoggerFromContext(r.Context())
+				logger.ErrorContext(r.Context(), "failed to save recording", "error", err)
+			}
+		})
+	}
+}
+
+func redactHeader(h http.Header, names []string) http.Header {
+	h = h.Clone()
+	for _, name := range names {
+		if values := h.Values(name); len(values) > 0 {
+			h.Set(name, Redacted)
+		}
+	}
+	return h
+}
+
+// cappedBuffer keeps at most max bytes of what is written to it.
+type cappedBuffer struct {
+	bytes.Buffer
+	max       int64
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if rest := b.max - int64(b.Len()); int64(len(p)) > rest {
+		b.Buffer.Write(p[:max(rest, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// recordingWriter captures the response while writing it through.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	header http.Header // snapshot at WriteHeader
+	body   cappedBuffer
+}
+
+func (rw *recordingWriter) WriteHeader(statusCode int) {
+	if rw.status == 0 {
+		rw.status = statusCode
+		rw.header = rw.ResponseWriter.Header().Clone()
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// MemoryRecordingStore is an in-memory RecordingStore.
+type MemoryRecordingStore struct {
+	mu         sync.Mutex
+	recordings []*Recording
+}
+
+var _ RecordingStore = (*MemoryRecordingStore)(nil)
+
+// Save implements RecordingStore.
+func (s *MemoryRecordingStore) Save(ctx context.Context, rec *Recording) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recordings = append(s.recordings, rec)
+	return nil
+}
+
+// Recordings returns the saved recordings, oldest first.
+func (s *MemoryRecordingStore) Recordings() []*Recording {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Recording(nil), s.recordings...)
+}
+
+// FileRecordingStore is a RecordingStore that writes each recording to "<dir>/<id>.json".
+// The files can be loaded with LoadRecording.
+type FileRecordingStore struct {
+	Dir string
+}
+
+var _ RecordingStore = (*FileRecordingStore)(nil)
+
+// Save implements RecordingStore.
+func (s *FileRecordingStore) Save(ctx context.Context, rec *Recording) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("create recording directory: %w", err)
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.Dir, rec.ID+".json"), data, 0o600)
+}
+
+// LoadRecording reads a recording written by FileRecordingStore.
+func LoadRecording(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("decode recording %s: %w", path, err)
+	}
+	return &rec, nil
+}
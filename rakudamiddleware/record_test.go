@@ -0,0 +1,101 @@
+package rakudamiddleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestRecord(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret"})
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("got " + string(body)))
+	})
+
+	t.Run("records with redaction", func(t *testing.T) {
+		store := &MemoryRecordingStore{}
+		mw := Record(RecordConfig{
+			Store: store,
+			Redact: func(rec *Recording) {
+				rec.RequestBody = strings.ReplaceAll(rec.RequestBody, "hunter2", Redacted)
+			},
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/login?next=/home", strings.NewReader("password=hunter2"))
+		req.Header.Set("Authorization", "Bearer token")
+		req.Header.Set("X-Request-Id", "abc")
+		rr := httptest.NewRecorder()
+		mw(handler).ServeHTTP(rr, req)
+
+		if got, want := rr.Body.String(), "got password=hunter2"; got != want {
+			t.Errorf("response body: got %q, want %q", got, want)
+		}
+
+		recordings := store.Recordings()
+		if len(recordings) != 1 {
+			t.Fatalf("expected 1 recording, got %d", len(recordings))
+		}
+		want := &Recording{
+			Method:        http.MethodPost,
+			URL:           "/login?next=/home",
+			RequestHeader: http.Header{"Authorization": {Redacted}, "X-Request-Id": {"abc"}},
+			RequestBody:   "password=" + Redacted,
+			Status:        http.StatusAccepted,
+			ResponseHeader: http.Header{
+				"Content-Type": {"text/plain"},
+				"Set-Cookie":   {Redacted},
+			},
+			ResponseBody: "got password=hunter2",
+		}
+		if diff := cmp.Diff(want, recordings[0], cmpopts.IgnoreFields(Recording{}, "ID", "Time", "Duration")); diff != "" {
+			t.Errorf("recording mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("size cap", func(t *testing.T) {
+		store := &MemoryRecordingStore{}
+		mw := Record(RecordConfig{Store: store, MaxBodySize: 4})
+
+		rr := httptest.NewRecorder()
+		mw(handler).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789")))
+
+		if got, want := rr.Body.String(), "got 0123456789"; got != want {
+			t.Errorf("response body: got %q, want %q", got, want)
+		}
+		rec := store.Recordings()[0]
+		if rec.RequestBody != "0123" || rec.ResponseBody != "got " || !rec.Truncated {
+			t.Errorf("unexpected recording: %+v", rec)
+		}
+	})
+
+	t.Run("file store", func(t *testing.T) {
+		store := &FileRecordingStore{Dir: t.TempDir()}
+		mw := Record(RecordConfig{Store: store, Enabled: func(r *http.Request) bool { return r.Header.Get("X-Debug") != "" }})
+
+		mw(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/skipped", nil))
+		req := httptest.NewRequest(http.MethodGet, "/recorded", nil)
+		req.Header.Set("X-Debug", "1")
+		mw(handler).ServeHTTP(httptest.NewRecorder(), req)
+
+		files, _ := filepath.Glob(filepath.Join(store.Dir, "*.json"))
+		if len(files) != 1 {
+			t.Fatalf("expected 1 recording file, got %d", len(files))
+		}
+		rec, err := LoadRecording(files[0])
+		if err != nil {
+			t.Fatalf("LoadRecording() failed: %v", err)
+		}
+		if got, want := rec.URL, "/recorded"; got != want {
+			t.Errorf("URL: got %q, want %q", got, want)
+		}
+	})
+}
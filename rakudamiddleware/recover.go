@@ -1,7 +1,10 @@
 package rakudamiddleware
 
 import (
-	"errors"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"runtime/debug"
 
@@ -10,17 +13,171 @@ import (
 
 // Recovery is a middleware that recovers from panics, logs the panic, and returns a 500 Internal Server Error.
 func Recovery(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				logger := rakuda.LoggerFromContext(r.Context())
-				logger.ErrorContext(r.Context(), "panic recovered", "error", err, "stack", string(debug.Stack()))
-
-				// Use the new Error method for a standardized response
-				responder := rakuda.NewResponder()
-				responder.Error(w, r, http.StatusInternalServerError, errors.New("a panic occurred"))
+	return RecoveryWithConfig(RecoveryConfig{})(next)
+}
+
+// RecoveryConfig holds the configuration for the RecoveryWithConfig middleware.
+type RecoveryConfig struct {
+	// Snapshot adds a sanitized snapshot of the request to the panic log record.
+	Snapshot bool
+	// Headers is the allowlist of request headers included in the snapshot.
+	// Default is Accept, Content-Type, and User-Agent.
+	Headers []string
+	// MaxBodySize is the maximum number of bytes of the request body included in the snapshot.
+	// Only the part of the body read by the handler before the panic is captured. Default is 4 KB.
+	MaxBodySize int64
+	// Reporter receives a report of each panic, e.g., to forward it to an error tracker.
+	// The report includes the snapshot even if Snapshot is false.
+	Reporter PanicReporter
+	// ErrorReporter receives each panic as a *PanicError, as Responder.Error reports 5xx errors.
+	ErrorReporter rakuda.ErrorReporter
+	// ExposeStack includes the panic value and stack in the 500 response. Enable it only in development.
+	ExposeStack bool
+}
+
+// PanicReporter receives reports of recovered panics.
+type PanicReporter interface {
+	ReportPanic(ctx context.Context, report *PanicReport)
+}
+
+// PanicReporterFunc is an adapter to allow the use of ordinary functions as a PanicReporter.
+type PanicReporterFunc func(ctx context.Context, report *PanicReport)
+
+// ReportPanic calls f(ctx, report).
+func (f PanicReporterFunc) ReportPanic(ctx context.Context, report *PanicReport) {
+	f(ctx, report)
+}
+
+// PanicError is the error passed to rakuda.Responder.Error, and so to the
+// ErrorReporter of the RecoveryConfig, for a recovered panic.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("a panic occurred: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// PanicReport describes a recovered panic.
+type PanicReport struct {
+	Value   any
+	Stack   []byte
+	Request *RequestSnapshot
+}
+
+// RequestSnapshot is a sanitized copy of a request, for post-mortems.
+// The query string is left out, because it may carry credentials.
+type RequestSnapshot struct {
+	Method        string      `json:"method"`
+	Path          string      `json:"path"`
+	Route         string      `json:"route,omitempty"`  // the matched pattern
+	Header        http.Header `json:"header,omitempty"` // allowlisted headers only
+	Body          string      `json:"body,omitempty"`
+	BodyTruncated bool        `json:"body_truncated,omitempty"`
+}
+
+// LogValue implements slog.LogValuer.
+func (s *RequestSnapshot) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("method", s.Method),
+		slog.String("path", s.Path),
+	}
+	if s.Route != "" {
+		attrs = append(attrs, slog.String("route", s.Route))
+	}
+	if len(s.Header) > 0 {
+		attrs = append(attrs, slog.Any("header", s.Header))
+	}
+	if s.Body != "" {
+		attrs = append(attrs, slog.String("body", s.Body), slog.Bool("body_truncated", s.BodyTruncated))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// RecoveryWithConfig returns a Recovery middleware that can capture a request snapshot
+// and forward panics to a PanicReporter.
+func RecoveryWithConfig(config RecoveryConfig) rakuda.Middleware {
+	if config.Headers == nil {
+		config.Headers = []string{"Accept", "Content-Type", "User-Agent"}
+	}
+	if config.MaxBodySize == 0 {
+		config.MaxBodySize = 4 << 10
+	}
+	capture := config.Snapshot || config.Reporter != nil
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body *cappedBuffer
+			if capture && r.Body != nil && r.Body != http.NoBody {
+				body = &cappedBuffer{max: config.MaxBodySize}
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.TeeReader(r.Body, body), r.Body}
+			}
+
+			defer func() {
+				if err := recover(); err != nil {
+					stack := debug.Stack()
+					logger := rakuda.LoggerFromContext(r.Context())
+					attrs := []any{"error", err, "stack", string(stack)}
+
+					var snapshot *RequestSnapshot
+					if capture {
+						snapshot = newRequestSnapshot(r, config.Headers, body)
+					}
+					if config.Snapshot {
+						attrs = append(attrs, "request", snapshot)
+					}
+					logger.ErrorContext(r.Context(), "panic recovered", attrs...)
+					if config.Reporter != nil {
+						config.Reporter.ReportPanic(r.Context(), &PanicReport{Value: err, Stack: stack, Request: snapshot})
+					}
+
+					perr := &PanicError{Value: err, Stack: stack}
+					responder := &rakuda.Responder{Reporter: config.ErrorReporter}
+					if config.ExposeStack {
+						if config.ErrorReporter != nil {
+							config.ErrorReporter.Report(r.Context(), perr, r)
+						}
+						responder.JSON(w, r, http.StatusInternalServerError, map[string]string{"error": perr.Error(), "stack": string(stack)})
+						return
+					}
+					// Use the new Error method for a standardized response.
+					// It also sends the panic to the ErrorReporter.
+					responder.Error(w, r, http.StatusInternalServerError, perr)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func newRequestSnapshot(r *http.Request, headers []string, body *cappedBuffer) *RequestSnapshot {
+	s := &RequestSnapshot{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Route:  r.Pattern,
+	}
+	for _, name := range headers {
+		if values := r.Header.Values(name); len(values) > 0 {
+			if s.Header == nil {
+				s.Header = http.Header{}
 			}
-		}()
-		next.ServeHTTP(w, r)
-	})
+			s.Header[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	if body != nil {
+		s.Body = body.String()
+		s.BodyTruncated = body.truncated
+	}
+	return s
 }
@@ -1,9 +1,19 @@
 package rakudamiddleware
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda"
 )
 
 func TestRecovery(t *testing.T) {
@@ -49,3 +59,87 @@ func TestRecovery(t *testing.T) {
 		}
 	})
 }
+
+func TestRecoveryWithConfig(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	var report *PanicReport
+	mw := RecoveryWithConfig(RecoveryConfig{
+		Snapshot:    true,
+		MaxBodySize: 8,
+		Reporter: PanicReporterFunc(func(ctx context.Context, r *PanicReport) {
+			report = r
+		}),
+	})
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		panic("boom")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders?token=secret", strings.NewReader(`{"item":"book"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	req = req.WithContext(rakuda.NewContextWithLogger(req.Context(), logger))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status code %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+
+	wantSnapshot := &RequestSnapshot{
+		Method:        http.MethodPost,
+		Path:          "/orders",
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          `{"item":`,
+		BodyTruncated: true,
+	}
+	if report == nil {
+		t.Fatal("expected a panic report")
+	}
+	if report.Value != "boom" || len(report.Stack) == 0 {
+		t.Errorf("unexpected report: value=%v, stack=%d bytes", report.Value, len(report.Stack))
+	}
+	if diff := cmp.Diff(wantSnapshot, report.Request); diff != "" {
+		t.Errorf("snapshot mismatch (-want +got):\n%s", diff)
+	}
+
+	var logOutput struct {
+		Request map[string]any `json:"request"`
+	}
+	firstLine, _, _ := bytes.Cut(buf.Bytes(), []byte("\n"))
+	if err := json.Unmarshal(firstLine, &logOutput); err != nil {
+		t.Fatalf("failed to unmarshal log output: %v", err)
+	}
+	if got, want := logOutput.Request["body"], `{"item":`; got != want {
+		t.Errorf("logged body: got %v, want %v", got, want)
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("expected credentials not to be logged: %s", buf.String())
+	}
+}
+
+func TestRecovery_ErrorReporter(t *testing.T) {
+	var reported error
+	errBoom := errors.New("boom")
+	handler := RecoveryWithConfig(RecoveryConfig{
+		ErrorReporter: rakuda.ErrorReporterFunc(func(ctx context.Context, err error, req *http.Request) {
+			reported = err
+		}),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(errBoom)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var panicErr *PanicError
+	if !errors.As(reported, &panicErr) {
+		t.Fatalf("expected a *PanicError to be reported, got %#v", reported)
+	}
+	if len(panicErr.Stack) == 0 {
+		t.Error("expected the stack to be captured")
+	}
+	if !errors.Is(reported, errBoom) {
+		t.Errorf("expected the reported error to wrap the panic value")
+	}
+}
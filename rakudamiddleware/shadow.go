@@ -0,0 +1,217 @@
+package rakudamiddleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/podhmo/rakuda"
+)
+
+// ShadowConfig holds the configuration for the Shadow middleware.
+// Exactly one of Handler or URL must be set.
+type ShadowConfig struct {
+	// Handler is an in-process shadow target.
+	Handler http.Handler
+	// URL is the base URL of a remote shadow target. The request path and query are appended.
+	URL string
+	// Client is used for remote shadow targets. Default is http.DefaultClient.
+	Client *http.Client
+	// Fraction is the share of requests that are shadowed, between 0 and 1.
+	// Zero means every request.
+	Fraction float64
+	// MaxBodySize is the maximum size of a request body that is copied to the shadow target.
+	// Requests with larger bodies are not shadowed. Default is 1 MB.
+	MaxBodySize int64
+	// Timeout bounds each shadow request. Default is 10 seconds.
+	Timeout time.Duration
+	// MaxInFlight is the maximum number of shadow requests running at once. Sampled requests
+	// beyond it are not shadowed, and reported with ErrShadowDropped. Default is 64.
+	MaxInFlight int
+	// OnResult is called with the outcome of each shadow request, from the shadow goroutine
+	// (or from the request goroutine, for a dropped one).
+	// Default logs the result, at warn level when the status codes differ.
+	OnResult func(ctx context.Context, result ShadowResult)
+}
+
+// ShadowResult compares the primary response with the shadow response.
+type ShadowResult struct {
+	Method         string
+	Path           string
+	Status         int
+	Duration       time.Duration
+	ShadowStatus   int // zero if a remote shadow request failed, 500 if the in-process target panicked
+	ShadowDuration time.Duration
+	Err            error // the error of a remote shadow request, or the panic of an in-process target
+}
+
+// ErrShadowDropped is the error of a ShadowResult for a sampled request that was not
+// shadowed, because MaxInFlight shadow requests were already running.
+var ErrShadowDropped = errors.New("shadow request dropped: too many in flight")
+
+// Match reports whether the shadow target responded with the same status code.
+func (r ShadowResult) Match() bool {
+	return r.Err == nil && r.Status == r.ShadowStatus
+}
+
+// Shadow returns a middleware that duplicates a sampled fraction of requests to a secondary
+// target, for dark launches: validating a rewrite against production traffic without
+// affecting clients. The shadow request is sent asynchronously after the primary response
+// is written; its response is discarded, and the status and latency are reported to OnResult.
+func Shadow(config ShadowConfig) rakuda.Middleware {
+	if (config.Handler == nil) == (config.URL == "") {
+		panic("rakudamiddleware: exactly one of ShadowConfig.Handler or ShadowConfig.URL is required")
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.Fraction == 0 {
+		config.Fraction = 1
+	}
+	if config.MaxBodySize == 0 {
+		config.MaxBodySize = 1 << 20
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = 64
+	}
+	if config.OnResult == nil {
+		config.OnResult = logShadowResult
+	}
+	inFlight := make(chan struct{}, config.MaxInFlight)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rand.Float64() >= config.Fraction {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var body []byte
+			if r.Body != nil && r.Body != http.NoBody {
+				buf, err := io.ReadAll(io.LimitReader(r.Body, config.MaxBodySize+1))
+				// Give the primary handler the whole body, including any unread rest.
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+				if err != nil || int64(len(buf)) > config.MaxBodySize {
+					next.ServeHTTP(w, r)
+					return
+				}
+				body = buf
+			}
+			// Capture the request before the primary handler can modify it.
+			shadowReq := r.Clone(context.WithoutCancel(r.Context()))
+
+			start := time.Now()
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			result := ShadowResult{
+				Method:   r.Method,
+				Path:     r.URL.Path,
+				Status:   rw.status,
+				Duration: time.Since(start),
+			}
+			select {
+			case inFlight <- struct{}{}:
+			default:
+				result.Err = ErrShadowDropped
+				config.OnResult(shadowReq.Context(), result)
+				return
+			}
+			go func() {
+				defer func() { <-inFlight }()
+				ctx, cancel := context.WithTimeout(shadowReq.Context(), config.Timeout)
+				defer cancel()
+				shadowReq = shadowReq.WithContext(ctx)
+				shadowReq.Body = io.NopCloser(bytes.NewReader(body))
+				shadowReq.ContentLength = int64(len(body))
+
+				start := time.Now()
+				if config.Handler != nil {
+					result.ShadowStatus, result.Err = serveShadow(config.Handler, shadowReq)
+				} else {
+					result.ShadowStatus, result.Err = sendShadow(config, shadowReq)
+				}
+				result.ShadowDuration = time.Since(start)
+				config.OnResult(ctx, result)
+			}()
+		})
+	}
+}
+
+// serveShadow serves r with an in-process shadow target. The shadow goroutine is outside
+// of net/http and of any Recovery middleware, so a panic of the target, the least trusted
+// code, is recovered here and reported as a 500 with an error, instead of crashing the process.
+func serveShadow(handler http.Handler, r *http.Request) (status int, err error) {
+	sw := &shadowWriter{header: http.Header{}, status: http.StatusOK}
+	defer func() {
+		if rec := recover(); rec != nil {
+			ctx := r.Context()
+			rakuda.LoggerFromContext(ctx).ErrorContext(ctx, "panic recovered in shadow handler", "error", fmt.Sprint(rec), "stack", string(debug.Stack()))
+			status, err = http.StatusInternalServerError, fmt.Errorf("shadow handler panicked: %v", rec)
+		}
+	}()
+	handler.ServeHTTP(sw, r)
+	return sw.status, nil
+}
+
+func sendShadow(config ShadowConfig, r *http.Request) (int, error) {
+	u, err := url.Parse(strings.TrimSuffix(config.URL, "/") + r.URL.RequestURI())
+	if err != nil {
+		return 0, err
+	}
+	r.URL = u
+	r.Host = u.Host
+	r.RequestURI = ""
+	resp, err := config.Client.Do(r)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+func logShadowResult(ctx context.Context, result ShadowResult) {
+	logger := rakuda.LoggerFromContext(ctx)
+	level := slog.LevelInfo
+	if !result.Match() {
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{
+		slog.String("method", result.Method),
+		slog.String("path", result.Path),
+		slog.Int("status", result.Status),
+		slog.Int("shadow_status", result.ShadowStatus),
+		slog.Duration("duration", result.Duration),
+		slog.Duration("shadow_duration", result.ShadowDuration),
+	}
+	if result.Err != nil {
+		attrs = append(attrs, slog.String("error", result.Err.Error()))
+	}
+	logger.LogAttrs(ctx, level, "shadow request", attrs...)
+}
+
+// shadowWriter discards the response of an in-process shadow target.
+type shadowWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *shadowWriter) Header() http.Header         { return w.header }
+func (w *shadowWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *shadowWriter) WriteHeader(statusCode int)  { w.status = statusCode }
@@ -0,0 +1,143 @@
+package rakudamiddleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestShadow(t *testing.T) {
+	primary := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte("primary:" + string(body)))
+	})
+
+	t.Run("in-process", func(t *testing.T) {
+		results := make(chan ShadowResult, 1)
+		shadowBodies := make(chan string, 1)
+		mw := Shadow(ShadowConfig{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				shadowBodies <- string(body)
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte("shadow"))
+			}),
+			OnResult: func(ctx context.Context, result ShadowResult) { results <- result },
+		})
+
+		rr := httptest.NewRecorder()
+		mw(primary).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"id":1}`)))
+
+		if got, want := rr.Body.String(), `primary:{"id":1}`; got != want {
+			t.Errorf("primary body: got %q, want %q", got, want)
+		}
+		if got, want := <-shadowBodies, `{"id":1}`; got != want {
+			t.Errorf("shadow request body: got %q, want %q", got, want)
+		}
+		result := <-results
+		want := ShadowResult{Method: http.MethodPost, Path: "/orders", Status: http.StatusOK, ShadowStatus: http.StatusInternalServerError}
+		if diff := cmp.Diff(want, result, cmpopts.IgnoreFields(ShadowResult{}, "Duration", "ShadowDuration")); diff != "" {
+			t.Errorf("result mismatch (-want +got):\n%s", diff)
+		}
+		if result.Match() {
+			t.Errorf("expected Match() to be false")
+		}
+	})
+
+	t.Run("in-process panic", func(t *testing.T) {
+		results := make(chan ShadowResult, 1)
+		mw := Shadow(ShadowConfig{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			}),
+			OnResult: func(ctx context.Context, result ShadowResult) { results <- result },
+		})
+
+		rr := httptest.NewRecorder()
+		mw(primary).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/orders", nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("primary status: got %d, want %d", rr.Code, http.StatusOK)
+		}
+
+		result := <-results
+		if result.ShadowStatus != http.StatusInternalServerError || result.Err == nil || !strings.Contains(result.Err.Error(), "boom") {
+			t.Errorf("expected the panic to be reported as a 500 with an error, got %+v", result)
+		}
+	})
+
+	t.Run("remote", func(t *testing.T) {
+		paths := make(chan string, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths <- r.URL.RequestURI()
+		}))
+		defer srv.Close()
+
+		results := make(chan ShadowResult, 1)
+		mw := Shadow(ShadowConfig{
+			URL:      srv.URL,
+			OnResult: func(ctx context.Context, result ShadowResult) { results <- result },
+		})
+
+		rr := httptest.NewRecorder()
+		mw(primary).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/orders?page=2", nil))
+
+		if got, want := <-paths, "/orders?page=2"; got != want {
+			t.Errorf("shadow path: got %q, want %q", got, want)
+		}
+		if result := <-results; !result.Match() {
+			t.Errorf("expected Match() to be true, got %+v", result)
+		}
+	})
+
+	t.Run("body too large is not shadowed", func(t *testing.T) {
+		results := make(chan ShadowResult, 1)
+		mw := Shadow(ShadowConfig{
+			Handler:     http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			MaxBodySize: 4,
+			OnResult:    func(ctx context.Context, result ShadowResult) { results <- result },
+		})
+
+		rr := httptest.NewRecorder()
+		mw(primary).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("0123456789")))
+
+		if got, want := rr.Body.String(), "primary:0123456789"; got != want {
+			t.Errorf("primary body: got %q, want %q", got, want)
+		}
+		select {
+		case result := <-results:
+			t.Errorf("unexpected shadow request: %+v", result)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("in flight limit", func(t *testing.T) {
+		results := make(chan ShadowResult, 2)
+		release := make(chan struct{})
+		mw := Shadow(ShadowConfig{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-release
+			}),
+			MaxInFlight: 1,
+			OnResult:    func(ctx context.Context, result ShadowResult) { results <- result },
+		})
+
+		for range 2 {
+			mw(primary).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+		}
+		if result := <-results; !errors.Is(result.Err, ErrShadowDropped) {
+			t.Errorf("expected the second request to be dropped, got %+v", result)
+		}
+		close(release)
+		if result := <-results; result.Err != nil {
+			t.Errorf("expected the first request to be shadowed, got %+v", result)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package rakudamiddleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignature(t *testing.T) {
+	secrets := map[string]string{"billing": "s3cret"}
+	mw := Signature(SignatureConfig{
+		Secret: func(ctx context.Context, keyID string) (string, bool) {
+			secret, ok := secrets[keyID]
+			return secret, ok
+		},
+	})
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signer, _ := SignerFromContext(r.Context())
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(signer + ":" + string(body)))
+	}))
+
+	newRequest := func(body string) *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/charges?dry_run=true", strings.NewReader(body))
+	}
+
+	tests := []struct {
+		name     string
+		req      func(t *testing.T) *http.Request
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "valid",
+			req: func(t *testing.T) *http.Request {
+				req := newRequest(`{"amount":100}`)
+				if err := SignRequest(req, "billing", "s3cret"); err != nil {
+					t.Fatalf("SignRequest() failed: %v", err)
+				}
+				return req
+			},
+			wantCode: http.StatusOK,
+			wantBody: `billing:{"amount":100}`,
+		},
+		{
+			name:     "unsigned",
+			req:      func(t *testing.T) *http.Request { return newRequest("") },
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "wrong secret",
+			req: func(t *testing.T) *http.Request {
+				req := newRequest(`{"amount":100}`)
+				SignRequest(req, "billing", "wrong")
+				return req
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "unknown key",
+			req: func(t *testing.T) *http.Request {
+				req := newRequest(`{"amount":100}`)
+				SignRequest(req, "unknown", "s3cret")
+				return req
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "tampered body",
+			req: func(t *testing.T) *http.Request {
+				req := newRequest(`{"amount":100}`)
+				SignRequest(req, "billing", "s3cret")
+				signed := newRequest(`{"amount":999}`)
+				signed.Header = req.Header
+				return signed
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "expired",
+			req: func(t *testing.T) *http.Request {
+				req := newRequest(`{"amount":100}`)
+				req.Header.Set("Date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+				SignRequest(req, "billing", "s3cret")
+				return req
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tt.req(t))
+			if rr.Code != tt.wantCode {
+				t.Errorf("expected status code %d, got %d, body: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+}
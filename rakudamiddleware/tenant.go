@@ -0,0 +1,98 @@
+package rakudamiddleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/podhmo/rakuda"
+)
+
+// ErrNoTenant is returned by tenant resolvers when the request does not identify a tenant.
+var ErrNoTenant = errors.New("no tenant")
+
+// Tenant returns a middleware that resolves the tenant of each request and stores it
+// in the context (see rakuda.TenantFromContext). The request logger gets a "tenant" attribute,
+// and HTTPLog logs it when the Values middleware is installed.
+//
+// If resolve fails, it responds with the status of the error if it has a StatusCode() int
+// method, or with 400. Use TenantFromHost, TenantFromHeader, or TenantFromPath as resolve,
+// or FirstTenant to combine them.
+func Tenant(resolve func(*http.Request) (rakuda.TenantID, error)) rakuda.Middleware {
+	responder := rakuda.NewResponder()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant, err := resolve(r)
+			if err != nil {
+				status := http.StatusBadRequest
+				var sc interface{ StatusCode() int }
+				if errors.As(err, &sc) {
+					status = sc.StatusCode()
+				}
+				responder.Error(w, r, status, err)
+				return
+			}
+			ctx := rakuda.NewContextWithTenant(r.Context(), tenant)
+			logger := rakuda.LoggerFromContext(ctx).With("tenant", string(tenant))
+			ctx = rakuda.NewContextWithLogger(ctx, logger)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// TenantFromHost resolves the tenant from the subdomain of the host,
+// e.g., "acme" for "acme.example.com" with the suffix ".example.com".
+func TenantFromHost(suffix string) func(*http.Request) (rakuda.TenantID, error) {
+	return func(r *http.Request) (rakuda.TenantID, error) {
+		host := r.Host
+		if i := strings.LastIndex(host, ":"); i >= 0 && !strings.Contains(host[i:], "]") {
+			host = host[:i] // strip the port
+		}
+		sub, ok := strings.CutSuffix(strings.ToLower(host), strings.ToLower(suffix))
+		if !ok || sub == "" || strings.Contains(sub, ".") {
+			return "", ErrNoTenant
+		}
+		return rakuda.TenantID(sub), nil
+	}
+}
+
+// TenantFromHeader resolves the tenant from a request header (e.g., "X-Tenant-ID").
+func TenantFromHeader(name string) func(*http.Request) (rakuda.TenantID, error) {
+	return func(r *http.Request) (rakuda.TenantID, error) {
+		if v := r.Header.Get(name); v != "" {
+			return rakuda.TenantID(v), nil
+		}
+		return "", ErrNoTenant
+	}
+}
+
+// TenantFromPath resolves the tenant from a path prefix declared as a wildcard of the
+// route pattern, e.g., TenantFromPath("tenant") for "/{tenant}/users". The wildcard
+// is also available to binding as a path value, like any other path parameter.
+// The middleware must be installed with Builder.Use, so that the route is matched first.
+func TenantFromPath(wildcard string) func(*http.Request) (rakuda.TenantID, error) {
+	return func(r *http.Request) (rakuda.TenantID, error) {
+		if v := r.PathValue(wildcard); v != "" {
+			return rakuda.TenantID(v), nil
+		}
+		return "", ErrNoTenant
+	}
+}
+
+// FirstTenant returns a resolver that tries each resolver in order and uses the first
+// tenant found. It fails with ErrNoTenant if none of them finds a tenant, or with the
+// first error other than ErrNoTenant.
+func FirstTenant(resolvers ...func(*http.Request) (rakuda.TenantID, error)) func(*http.Request) (rakuda.TenantID, error) {
+	return func(r *http.Request) (rakuda.TenantID, error) {
+		for _, resolve := range resolvers {
+			tenant, err := resolve(r)
+			if err == nil {
+				return tenant, nil
+			}
+			if !errors.Is(err, ErrNoTenant) {
+				return "", err
+			}
+		}
+		return "", ErrNoTenant
+	}
+}
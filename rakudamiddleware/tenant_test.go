@@ -0,0 +1,123 @@
+package rakudamiddleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/podhmo/rakuda"
+)
+
+func TestTenantResolvers(t *testing.T) {
+	tests := []struct {
+		name    string
+		resolve func(*http.Request) (rakuda.TenantID, error)
+		setup   func(r *http.Request)
+		want    rakuda.TenantID
+		wantErr error
+	}{
+		{
+			name:    "host",
+			resolve: TenantFromHost(".example.com"),
+			setup:   func(r *http.Request) { r.Host = "acme.example.com:8080" },
+			want:    "acme",
+		},
+		{
+			name:    "host without subdomain",
+			resolve: TenantFromHost(".example.com"),
+			setup:   func(r *http.Request) { r.Host = "example.com" },
+			wantErr: ErrNoTenant,
+		},
+		{
+			name:    "nested subdomain",
+			resolve: TenantFromHost(".example.com"),
+			setup:   func(r *http.Request) { r.Host = "a.b.example.com" },
+			wantErr: ErrNoTenant,
+		},
+		{
+			name:    "header",
+			resolve: TenantFromHeader("X-Tenant-ID"),
+			setup:   func(r *http.Request) { r.Header.Set("X-Tenant-ID", "globex") },
+			want:    "globex",
+		},
+		{
+			name:    "first",
+			resolve: FirstTenant(TenantFromHeader("X-Tenant-ID"), TenantFromHost(".example.com")),
+			setup:   func(r *http.Request) { r.Host = "acme.example.com" },
+			want:    "acme",
+		},
+		{
+			name:    "none",
+			resolve: FirstTenant(TenantFromHeader("X-Tenant-ID"), TenantFromHost(".example.com")),
+			setup:   func(r *http.Request) { r.Host = "localhost" },
+			wantErr: ErrNoTenant,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			tt.setup(req)
+			got, err := tt.resolve(req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error: got %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("tenant: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTenant(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	b := rakuda.NewBuilder(rakuda.WithLogger(logger))
+	b.Use(Values)
+	b.Use(HTTPLog)
+	b.Use(Tenant(TenantFromPath("tenant")))
+	b.Get("/{tenant}/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, _ := rakuda.TenantFromContext(r.Context())
+		w.Write([]byte(tenant))
+	}))
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/acme/users", nil))
+
+	if got, want := rr.Body.String(), "acme"; got != want {
+		t.Errorf("body: got %q, want %q", got, want)
+	}
+	if got := strings.Count(buf.String(), `"tenant"`); got != 1 {
+		t.Errorf("expected the tenant to be logged once, got %d: %s", got, buf.String())
+	}
+	var logOutput map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &logOutput); err != nil {
+		t.Fatalf("failed to unmarshal log output: %v", err)
+	}
+	if got, want := logOutput["tenant"], "acme"; got != want {
+		t.Errorf("tenant: got %v, want %v", got, want)
+	}
+}
+
+func TestTenant_Error(t *testing.T) {
+	handler := Tenant(TenantFromHeader("X-Tenant-ID"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called")
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got, want := rr.Code, http.StatusBadRequest; got != want {
+		t.Errorf("status: got %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+package rakudamiddleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/podhmo/rakuda"
+)
+
+// ServerTiming is a middleware that sends the timings recorded with rakuda.Timing(ctx).Add
+// in the Server-Timing response header, followed by a "total" entry for the time until
+// the header was written. Only the entries added before the handler writes the header are sent;
+// the access log of HTTPLog includes all of them.
+func ServerTiming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, timings := rakuda.NewContextWithTimings(r.Context())
+		tw := &timingWriter{ResponseWriter: w, timings: timings, start: time.Now()}
+		next.ServeHTTP(tw, r.WithContext(ctx))
+		if !tw.wroteHeader {
+			tw.writeTimingHeader()
+		}
+	})
+}
+
+// timingWriter sets the Server-Timing header just before the header is written.
+type timingWriter struct {
+	http.ResponseWriter
+	timings     *rakuda.Timings
+	start       time.Time
+	wroteHeader bool
+}
+
+func (tw *timingWriter) writeTimingHeader() {
+	tw.wroteHeader = true
+	entries := append(tw.timings.Entries(), rakuda.TimingEntry{Name: "total", Duration: time.Since(tw.start)})
+	tw.Header().Set("Server-Timing", rakuda.FormatServerTiming(entries))
+}
+
+func (tw *timingWriter) WriteHeader(statusCode int) {
+	if !tw.wroteHeader {
+		tw.writeTimingHeader()
+	}
+	tw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (tw *timingWriter) Write(b []byte) (int, error) {
+	if !tw.wroteHeader {
+		tw.writeTimingHeader()
+	}
+	return tw.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher.
+func (tw *timingWriter) Flush() {
+	if f, ok := tw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,45 @@
+package rakudamiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/podhmo/rakuda"
+)
+
+func TestServerTiming(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    string
+	}{
+		{
+			name: "entries before the body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				rakuda.Timing(r.Context()).Add("db", 2*time.Millisecond)
+				w.Write([]byte("ok"))
+				rakuda.Timing(r.Context()).Add("late", time.Millisecond) // too late for the header
+			},
+			want: `^db;dur=2, total;dur=[0-9.]+$`,
+		},
+		{
+			name:    "no body",
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+			want:    `^total;dur=[0-9.]+$`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ServerTiming(tt.handler).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if got := rr.Header().Get("Server-Timing"); !regexp.MustCompile(tt.want).MatchString(got) {
+				t.Errorf("Server-Timing: got %q, want match for %q", got, tt.want)
+			}
+		})
+	}
+}
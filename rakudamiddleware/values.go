@@ -0,0 +1,19 @@
+package rakudamiddleware
+
+import (
+	"net/http"
+
+	"github.com/podhmo/rakuda"
+)
+
+// Values is a middleware that installs an empty rakuda.ValueBag into the request context,
+// so that later middleware and handlers can exchange values via rakuda.Values(ctx).
+// If a bag is already present, it is reused.
+func Values(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rakuda.Values(r.Context()) == nil {
+			r = r.WithContext(rakuda.NewContextWithValues(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
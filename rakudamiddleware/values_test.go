@@ -0,0 +1,33 @@
+package rakudamiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/podhmo/rakuda"
+)
+
+func TestValues(t *testing.T) {
+	key := rakuda.NewKey[string]("user")
+
+	setter := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key.Set(r.Context(), "gopher")
+			next.ServeHTTP(w, r)
+		})
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ := key.Get(r.Context())
+		w.Write([]byte(user))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+
+	Values(setter(handler)).ServeHTTP(rr, req)
+
+	if got, want := rr.Body.String(), "gopher"; got != want {
+		t.Errorf("body: got %q, want %q", got, want)
+	}
+}
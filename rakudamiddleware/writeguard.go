@@ -0,0 +1,142 @@
+package rakudamiddleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/podhmo/rakuda"
+)
+
+// WriteGuard is a development middleware that catches misuses of the http.ResponseWriter
+// by handlers and middleware, logging the offending call sites at warn level:
+//
+//   - A superfluous WriteHeader call, i.e., after the header was written explicitly or
+//     by the first Write. The call is dropped instead of reaching net/http, which would
+//     only log "http: superfluous response.WriteHeader call" without the handler's location.
+//   - A header mutation after the header was written, which is silently ignored by net/http.
+//     The mutation is detected on the next Write or when the handler returns, and
+//     reported with the location of the last Header call.
+//
+// Call sites inside net/http and the rakuda package (e.g., the Responder) are skipped, so
+// that the reported location is in application code. Use it in development only:
+//
+//	if profile.Name == rakuda.Development.Name {
+//		b.Use(rakudamiddleware.WriteGuard)
+//	}
+func WriteGuard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gw := &guardWriter{ResponseWriter: w, r: r}
+		next.ServeHTTP(gw, r)
+		gw.checkHeader()
+	})
+}
+
+// guardWriter records where the response header was written, to report later misuses.
+type guardWriter struct {
+	http.ResponseWriter
+	r *http.Request
+
+	status      int
+	wroteAt     string      // the call site that wrote the header
+	header      http.Header // a snapshot of the header when it was written
+	lastTouchAt string      // the call site of the last Header call after the header was written
+}
+
+func (gw *guardWriter) Header() http.Header {
+	if gw.wroteAt != "" {
+		gw.lastTouchAt = writerCallSite()
+	}
+	return gw.ResponseWriter.Header()
+}
+
+func (gw *guardWriter) WriteHeader(statusCode int) {
+	if gw.wroteAt != "" {
+		gw.warn("superfluous WriteHeader call", "status", statusCode, "written_status", gw.status, "source", writerCallSite(), "written_at", gw.wroteAt)
+		return
+	}
+	gw.commit(statusCode, writerCallSite())
+	gw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (gw *guardWriter) Write(b []byte) (int, error) {
+	if gw.wroteAt != "" {
+		gw.checkHeader()
+		return gw.ResponseWriter.Write(b)
+	}
+	// The snapshot is taken after the implicit WriteHeader, which may add fields (e.g., a sniffed Content-Type).
+	defer gw.commit(http.StatusOK, writerCallSite())
+	return gw.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, for streaming responses such as SSE.
+func (gw *guardWriter) Flush() {
+	if gw.wroteAt == "" {
+		defer gw.commit(http.StatusOK, writerCallSite())
+	}
+	if f, ok := gw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (gw *guardWriter) commit(statusCode int, source string) {
+	gw.status = statusCode
+	gw.wroteAt = source
+	gw.header = gw.ResponseWriter.Header().Clone()
+}
+
+// checkHeader reports the header fields changed since the header was written, once per change.
+func (gw *guardWriter) checkHeader() {
+	if gw.wroteAt == "" {
+		return
+	}
+	current := gw.ResponseWriter.Header()
+	var changed []string
+	for k := range current {
+		if !slices.Equal(current[k], gw.header[k]) {
+			changed = append(changed, k)
+		}
+	}
+	for k := range gw.header {
+		if _, ok := current[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+	slices.Sort(changed)
+	source := gw.lastTouchAt
+	if source == "" {
+		source = "unknown"
+	}
+	gw.warn("header modified after the response header was written", "fields", changed, "source", source, "written_at", gw.wroteAt)
+	gw.header = current.Clone()
+}
+
+func (gw *guardWriter) warn(msg string, args ...any) {
+	ctx := gw.r.Context()
+	args = append([]any{"method", gw.r.Method, "path", gw.r.URL.Path}, args...)
+	rakuda.LoggerFromContext(ctx).WarnContext(ctx, msg, args...)
+}
+
+// writerCallSite returns the location of the first caller of the guardWriter outside
+// net/http, the rakuda package, and the guardWriter itself.
+func writerCallSite() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs) // skip runtime.Callers, writerCallSite, and the guardWriter method
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "net/http.") &&
+			!strings.HasPrefix(frame.Function, "github.com/podhmo/rakuda.") &&
+			!strings.HasPrefix(frame.Function, "github.com/podhmo/rakuda/rakudamiddleware.(*guardWriter)") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
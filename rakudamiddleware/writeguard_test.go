@@ -0,0 +1,84 @@
+package rakudamiddleware
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda"
+)
+
+func TestWriteGuard(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantLogs   []string
+	}{
+		{
+			name: "well-behaved",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("ok"))
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "superfluous WriteHeader",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok"))
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantStatus: http.StatusOK,
+			wantLogs:   []string{`msg="superfluous WriteHeader call"`, "status=500", "written_status=200", "writeguard_test.go"},
+		},
+		{
+			name: "superfluous WriteHeader via the responder",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				rakuda.NewResponder().Error(w, r, http.StatusBadRequest, errors.New("bad"))
+			},
+			wantStatus: http.StatusAccepted,
+			wantLogs:   []string{`msg="superfluous WriteHeader call"`, "status=400", "writeguard_test.go"},
+		},
+		{
+			name: "header modified after write",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok"))
+				w.Header().Set("X-Late", "1")
+			},
+			wantStatus: http.StatusOK,
+			wantLogs:   []string{`msg="header modified after the response header was written"`, "fields=[X-Late]", "writeguard_test.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&logs, nil))
+			req := httptest.NewRequest("GET", "/", nil)
+			req = req.WithContext(rakuda.NewContextWithLogger(req.Context(), logger))
+			rec := httptest.NewRecorder()
+
+			WriteGuard(tt.handler).ServeHTTP(rec, req)
+
+			if diff := cmp.Diff(tt.wantStatus, rec.Code); diff != "" {
+				t.Errorf("status mismatch (-want +got):\n%s", diff)
+			}
+			if len(tt.wantLogs) == 0 && logs.Len() > 0 {
+				t.Errorf("unexpected logs: %s", logs.String())
+			}
+			for _, want := range tt.wantLogs {
+				if !strings.Contains(logs.String(), want) {
+					t.Errorf("logs do not contain %q: %s", want, logs.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+// Package rakudaoidc provides login routes implementing the OpenID Connect
+// authorization code flow, so that small apps get single sign-on without
+// pulling in a full web framework.
+//
+//	auth, err := rakudaoidc.New(rakudaoidc.Config{
+//		Issuer:        "https://accounts.example.com",
+//		ClientID:      "...",
+//		ClientSecret:  "...",
+//		RedirectURL:   "https://app.example.com/auth/callback",
+//		SessionSecret: []byte("..."),
+//	})
+//	auth.Mount(b, "/auth") // GET /auth/login, GET /auth/callback, POST /auth/logout
+//	b.Group(func(b *rakuda.Builder) {
+//		b.Use(auth.Require)
+//		...
+//	})
+//
+// The flow uses state, nonce, and PKCE. ID tokens must be signed with RS256.
+// The session is a signed cookie; its content is not encrypted.
+package rakudaoidc
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/podhmo/rakuda"
+	"github.com/podhmo/rakuda/internal/signedcookie"
+)
+
+// Config holds the configuration for the login routes.
+type Config struct {
+	// Issuer is the OpenID provider's issuer URL. It is required.
+	Issuer string
+	// ClientID and ClientSecret are the client credentials. ClientID is required.
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the absolute URL of the callback route. It is required.
+	RedirectURL string
+	// Scopes are the requested scopes. Default is "openid", "profile", "email".
+	Scopes []string
+
+	// AuthURL, TokenURL, and JWKSURL are the provider endpoints.
+	// If any of them is empty, they are discovered from the issuer's
+	// /.well-known/openid-configuration on first use.
+	AuthURL  string
+	TokenURL string
+	JWKSURL  string
+
+	// SessionSecret is the key used to sign cookies. It is required.
+	SessionSecret []byte
+	// SessionTTL is the lifetime of a session. Default is 24 hours.
+	SessionTTL time.Duration
+	// CookieName is the name of the session cookie. Default is "rakuda_session".
+	CookieName string
+	// AfterLogout is where users are redirected after logout. Default is "/".
+	AfterLogout string
+
+	// Client is used for requests to the provider. Default is a client with a 10 second timeout.
+	Client *http.Client
+	// Responder is used to write error responses. Default is rakuda.NewResponder().
+	Responder *rakuda.Responder
+}
+
+// Handler serves the login routes and checks sessions.
+type Handler struct {
+	config    Config
+	responder *rakuda.Responder
+
+	discoverMu  sync.Mutex
+	discovered  bool      // set once the discovery succeeded
+	discoverErr error     // the last failure, returned until retryAt
+	retryAt     time.Time // when the discovery is attempted again after a failure
+	keys        *keySet
+}
+
+// discoverRetryInterval is how long a failed discovery is cached, so that a provider
+// outage does not turn every login into a request to the provider.
+const discoverRetryInterval = 5 * time.Second
+
+// New creates a Handler. It does not contact the provider until the first login.
+func New(config Config) (*Handler, error) {
+	var errs []error
+	if config.Issuer == "" {
+		errs = append(errs, errors.New("Issuer is required"))
+	}
+	if config.ClientID == "" {
+		errs = append(errs, errors.New("ClientID is required"))
+	}
+	if config.RedirectURL == "" {
+		errs = append(errs, errors.New("RedirectURL is required"))
+	}
+	if len(config.SessionSecret) == 0 {
+		errs = append(errs, errors.New("SessionSecret is required"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("rakudaoidc: invalid config: %w", err)
+	}
+
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "profile", "email"}
+	}
+	if config.SessionTTL == 0 {
+		config.SessionTTL = 24 * time.Hour
+	}
+	if config.CookieName == "" {
+		config.CookieName = "rakuda_session"
+	}
+	if config.AfterLogout == "" {
+		config.AfterLogout = "/"
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	h := &Handler{config: config, responder: config.Responder}
+	if h.responder == nil {
+		h.responder = rakuda.NewResponder()
+	}
+	return h, nil
+}
+
+// Mount registers the login routes under prefix:
+// GET prefix/login, GET prefix/callback, and POST prefix/logout.
+// The login route accepts a "return_to" query parameter (a local path).
+func (h *Handler) Mount(b *rakuda.Builder, prefix string) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	b.Get(prefix+"/login", http.HandlerFunc(h.login))
+	b.Get(prefix+"/callback", http.HandlerFunc(h.callback))
+	b.Post(prefix+"/logout", http.HandlerFunc(h.logout))
+}
+
+// Middleware stores the principal of a valid session in the context
+// (see rakuda.PrincipalFromContext). Requests without a session pass through.
+func (h *Handler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if session, ok := h.Session(r); ok {
+			r = r.WithContext(rakuda.NewContextWithPrincipal(r.Context(), session.principal()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Require is like Middleware, but responds with 401 if there is no valid session.
+func (h *Handler) Require(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, ok := h.Session(r)
+		if !ok {
+			h.responder.Error(w, r, http.StatusUnauthorized, errors.New("login required"))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(rakuda.NewContextWithPrincipal(r.Context(), session.principal())))
+	})
+}
+
+// Session returns the valid session of the request, if any.
+func (h *Handler) Session(r *http.Request) (*Session, bool) {
+	cookie, err := r.Cookie(h.config.CookieName)
+	if err != nil {
+		return nil, false
+	}
+	var session Session
+	if err := signedcookie.Decode(h.config.SessionSecret, sessionPurpose, cookie.Value, &session); err != nil {
+		return nil, false
+	}
+	if session.Subject == "" || !time.Now().Before(session.Expires) {
+		return nil, false
+	}
+	return &session, true
+}
+
+func (s *Session) principal() *rakuda.Principal {
+	attrs := map[string]string{}
+	if s.Email != "" {
+		attrs["email"] = s.Email
+	}
+	if s.Name != "" {
+		attrs["name"] = s.Name
+	}
+	return &rakuda.Principal{ID: s.Subject, Kind: "user", Attributes: attrs}
+}
+
+func (h *Handler) stateCookieName() string {
+	return h.config.CookieName + "_login"
+}
+
+func (h *Handler) login(w http.ResponseWriter, r *http.Request) {
+	if err := h.discover(r.Context()); err != nil {
+		h.responder.Error(w, r, http.StatusBadGateway, err)
+		return
+	}
+
+	state := loginState{
+		State:    randomString(),
+		Nonce:    randomString(),
+		Verifier: randomString(),
+		ReturnTo: localPath(r.URL.Query().Get("return_to")),
+		Expires:  time.Now().Add(10 * time.Minute),
+	}
+	value, err := signedcookie.Encode(h.config.SessionSecret, loginPurpose, state)
+	if err != nil {
+		h.responder.Error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	http.SetCookie(w, h.cookie(h.stateCookieName(), value, state.Expires))
+
+	challenge := sha256.Sum256([]byte(state.Verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {h.config.ClientID},
+		"redirect_uri":          {h.config.RedirectURL},
+		"scope":                 {strings.Join(h.config.Scopes, " ")},
+		"state":                 {state.State},
+		"nonce":                 {state.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	h.responder.Redirect(w, r, h.config.AuthURL+"?"+q.Encode(), http.StatusFound)
+}
+
+func (h *Handler) callback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		h.responder.Error(w, r, http.StatusUnauthorized, fmt.Errorf("login failed: %s", e))
+		return
+	}
+
+	var state loginState
+	cookie, err := r.Cookie(h.stateCookieName())
+	if err != nil || signedcookie.Decode(h.config.SessionSecret, loginPurpose, cookie.Value, &state) != nil || !time.Now().Before(state.Expires) {
+		h.responder.Error(w, r, http.StatusBadRequest, errors.New("login session is missing or expired"))
+		return
+	}
+	if q.Get("state") != state.State {
+		h.responder.Error(w, r, http.StatusBadRequest, errors.New("state mismatch"))
+		return
+	}
+	http.SetCookie(w, h.cookie(h.stateCookieName(), "", time.Unix(0, 0)))
+
+	if err := h.discover(ctx); err != nil {
+		h.responder.Error(w, r, http.StatusBadGateway, err)
+		return
+	}
+	idToken, err := h.exchange(ctx, q.Get("code"), state.Verifier)
+	if err != nil {
+		h.responder.Error(w, r, http.StatusBadGateway, err)
+		return
+	}
+	claims, err := verifyIDToken(ctx, h.keys, idToken, h.config.Issuer, h.config.ClientID, state.Nonce, time.Now())
+	if err != nil {
+		h.responder.Error(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	session := Session{
+		Subject: claims.Subject,
+		Email:   claims.Email,
+		Name:    claims.Name,
+		Expires: time.Now().Add(h.config.SessionTTL),
+	}
+	value, err := signedcookie.Encode(h.config.SessionSecret, sessionPurpose, session)
+	if err != nil {
+		h.responder.Error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	http.SetCookie(w, h.cookie(h.config.CookieName, value, session.Expires))
+	h.responder.Redirect(w, r, state.ReturnTo, http.StatusFound)
+}
+
+func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, h.cookie(h.config.CookieName, "", time.Unix(0, 0)))
+	h.responder.Redirect(w, r, h.config.AfterLogout, http.StatusSeeOther)
+}
+
+func (h *Handler) cookie(name, value string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.config.RedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// discover fills in the provider endpoints from the discovery document, if needed.
+// Only a successful discovery is kept; a failure is retried after discoverRetryInterval,
+// so that a transient outage of the provider does not break logins until a restart.
+func (h *Handler) discover(ctx context.Context) error {
+	h.discoverMu.Lock()
+	defer h.discoverMu.Unlock()
+	if h.discovered {
+		return nil
+	}
+	now := rakuda.Now(ctx)
+	if h.discoverErr != nil && now.Before(h.retryAt) {
+		return h.discoverErr
+	}
+
+	if h.config.AuthURL == "" || h.config.TokenURL == "" || h.config.JWKSURL == "" {
+		var doc struct {
+			Issuer   string `json:"issuer"`
+			AuthURL  string `json:"authorization_endpoint"`
+			TokenURL string `json:"token_endpoint"`
+			JWKSURL  string `json:"jwks_uri"`
+		}
+		wellKnown := strings.TrimSuffix(h.config.Issuer, "/") + "/.well-known/openid-configuration"
+		err := getJSON(context.WithoutCancel(ctx), h.config.Client, wellKnown, &doc)
+		if err == nil && doc.Issuer != h.config.Issuer {
+			err = fmt.Errorf("issuer mismatch %q", doc.Issuer)
+		}
+		if err != nil {
+			h.discoverErr, h.retryAt = fmt.Errorf("openid discovery: %w", err), now.Add(discoverRetryInterval)
+			return h.discoverErr
+		}
+		h.config.AuthURL = cmp.Or(h.config.AuthURL, doc.AuthURL)
+		h.config.TokenURL = cmp.Or(h.config.TokenURL, doc.TokenURL)
+		h.config.JWKSURL = cmp.Or(h.config.JWKSURL, doc.JWKSURL)
+	}
+	h.keys = &keySet{url: h.config.JWKSURL, client: h.config.Client}
+	h.discovered, h.discoverErr = true, nil
+	return nil
+}
+
+// exchange exchanges an authorization code for an ID token.
+func (h *Handler) exchange(ctx context.Context, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {h.config.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(h.config.ClientID), url.QueryEscape(h.config.ClientSecret))
+
+	res, err := h.config.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request: unexpected status %d", res.StatusCode)
+	}
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("token response: %w", err)
+	}
+	if token.IDToken == "" {
+		return "", errors.New("token response: missing id_token")
+	}
+	return token.IDToken, nil
+}
+
+// localPath returns p if it is a local path, or "/" otherwise, to prevent open redirects.
+func localPath(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.HasPrefix(p, "/\\") {
+		return "/"
+	}
+	return p
+}
+
+func randomString() string {
+	b := make([]byte, 32)
+	rand.Read(b) // never returns an error
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,334 @@
+package rakudaoidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda"
+	"github.com/podhmo/rakuda/internal/signedcookie"
+)
+
+// fakeProvider is a minimal OpenID provider for tests.
+type fakeProvider struct {
+	*httptest.Server
+	key   *rsa.PrivateKey
+	nonce string // the nonce of the last authorization request
+	// claims overrides the ID token claims.
+	claims func(c map[string]any)
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() failed: %v", err)
+	}
+	p := &fakeProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.FormValue("code") != "code-1" || r.FormValue("code_verifier") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		claims := map[string]any{
+			"iss":   p.URL,
+			"sub":   "user-1",
+			"aud":   "client",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": p.nonce,
+			"email": "user@example.com",
+		}
+		if p.claims != nil {
+			p.claims(claims)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": p.sign(t, claims)})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *fakeProvider) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "key-1"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("rsa.SignPKCS1v15() failed: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestLoginFlow(t *testing.T) {
+	provider := newFakeProvider(t)
+
+	tests := []struct {
+		name     string
+		claims   func(c map[string]any)
+		wantCode int
+	}{
+		{name: "ok", wantCode: http.StatusFound},
+		{name: "wrong audience", claims: func(c map[string]any) { c["aud"] = "other" }, wantCode: http.StatusUnauthorized},
+		{name: "expired", claims: func(c map[string]any) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, wantCode: http.StatusUnauthorized},
+		{name: "nonce mismatch", claims: func(c map[string]any) { c["nonce"] = "replayed" }, wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider.claims = tt.claims
+
+			auth, err := New(Config{
+				Issuer:        provider.URL,
+				ClientID:      "client",
+				ClientSecret:  "secret",
+				RedirectURL:   "http://app.example.com/auth/callback",
+				SessionSecret: []byte("session-secret"),
+			})
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			b := rakuda.NewBuilder()
+			auth.Mount(b, "/auth")
+			b.Group(func(b *rakuda.Builder) {
+				b.Use(auth.Require)
+				b.Get("/me", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					p, _ := rakuda.PrincipalFromContext(r.Context())
+					json.NewEncoder(w).Encode(p)
+				}))
+			})
+			router, err := b.Build()
+			if err != nil {
+				t.Fatalf("b.Build() failed: %v", err)
+			}
+
+			// login
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/auth/login?return_to=/me", nil))
+			if rr.Code != http.StatusFound {
+				t.Fatalf("login: status code: got %d, want %d, body: %s", rr.Code, http.StatusFound, rr.Body.String())
+			}
+			location, err := url.Parse(rr.Header().Get("Location"))
+			if err != nil {
+				t.Fatalf("invalid redirect: %v", err)
+			}
+			if got, want := location.Scheme+"://"+location.Host+location.Path, provider.URL+"/authorize"; got != want {
+				t.Errorf("login redirect: got %q, want %q", got, want)
+			}
+			provider.nonce = location.Query().Get("nonce")
+			loginCookies := rr.Result().Cookies()
+
+			// callback
+			req := httptest.NewRequest(http.MethodGet, "/auth/callback?code=code-1&state="+url.QueryEscape(location.Query().Get("state")), nil)
+			for _, c := range loginCookies {
+				req.AddCookie(c)
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tt.wantCode {
+				t.Fatalf("callback: status code: got %d, want %d, body: %s", rr.Code, tt.wantCode, rr.Body.String())
+			}
+			if tt.wantCode != http.StatusFound {
+				return
+			}
+			if got, want := rr.Header().Get("Location"), "/me"; got != want {
+				t.Errorf("callback redirect: got %q, want %q", got, want)
+			}
+
+			// authenticated request
+			req = httptest.NewRequest(http.MethodGet, "/me", nil)
+			for _, c := range rr.Result().Cookies() {
+				if c.Value != "" {
+					req.AddCookie(c)
+				}
+			}
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("me: status code: got %d, want %d, body: %s", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			var got rakuda.Principal
+			json.NewDecoder(rr.Body).Decode(&got)
+			want := rakuda.Principal{ID: "user-1", Kind: "user", Attributes: map[string]string{"email": "user@example.com"}}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("principal mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCallback_StateMismatch(t *testing.T) {
+	auth, err := New(Config{
+		Issuer:        "http://issuer.example.com",
+		ClientID:      "client",
+		RedirectURL:   "http://app.example.com/auth/callback",
+		SessionSecret: []byte("session-secret"),
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	value, _ := signedcookie.Encode(auth.config.SessionSecret, loginPurpose, loginState{State: "expected", Expires: time.Now().Add(time.Minute)})
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/callback?code=code-1&state=forged", nil)
+	req.AddCookie(&http.Cookie{Name: auth.stateCookieName(), Value: value})
+	rr := httptest.NewRecorder()
+	auth.callback(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("status code: got %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestRequire_TamperedSession(t *testing.T) {
+	auth, err := New(Config{
+		Issuer:        "http://issuer.example.com",
+		ClientID:      "client",
+		RedirectURL:   "http://app.example.com/auth/callback",
+		SessionSecret: []byte("session-secret"),
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	value, _ := signedcookie.Encode([]byte("other-secret"), sessionPurpose, Session{Subject: "admin", Expires: time.Now().Add(time.Hour)})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "rakuda_session", Value: value})
+	rr := httptest.NewRecorder()
+	auth.Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("status code: got %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestSession_Replay(t *testing.T) {
+	auth, err := New(Config{
+		Issuer:        "http://issuer.example.com",
+		ClientID:      "client",
+		RedirectURL:   "http://app.example.com/auth/callback",
+		SessionSecret: []byte("session-secret"),
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	loginValue, _ := signedcookie.Encode(auth.config.SessionSecret, loginPurpose, loginState{State: "s", Expires: time.Now().Add(time.Minute)})
+	anonymousValue, _ := signedcookie.Encode(auth.config.SessionSecret, sessionPurpose, Session{Expires: time.Now().Add(time.Hour)})
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"login state as session", loginValue},
+		{"session without subject", anonymousValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.AddCookie(&http.Cookie{Name: "rakuda_session", Value: tt.value})
+			if session, ok := auth.Session(req); ok {
+				t.Errorf("expected no session, got %+v", session)
+			}
+		})
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	for input, want := range map[string]string{
+		"/dashboard":           "/dashboard",
+		"":                     "/",
+		"https://evil.example": "/",
+		"//evil.example":       "/",
+		`/\evil.example`:       "/",
+	} {
+		if got := localPath(input); got != want {
+			t.Errorf("localPath(%q): got %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	_, err := New(Config{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "SessionSecret is required") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDiscover_RetriesAfterFailure(t *testing.T) {
+	provider := newFakeProvider(t)
+	down := true
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		provider.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	auth, err := New(Config{
+		Issuer:        provider.URL,
+		ClientID:      "client",
+		RedirectURL:   "http://app.example.com/auth/callback",
+		SessionSecret: []byte("session-secret"),
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	auth.config.Issuer = server.URL // discovered through the flaky server
+	provider.URL = server.URL       // so that the document names the same issuer
+
+	now := time.Now()
+	ctx := rakuda.NewContextWithClock(t.Context(), rakuda.ClockFunc(func() time.Time { return now }))
+	if err := auth.discover(ctx); err == nil {
+		t.Fatal("expected the discovery to fail while the provider is down")
+	}
+	down = false
+	if err := auth.discover(ctx); err == nil || requests != 1 {
+		t.Errorf("expected the failure to be cached until the retry interval, got %v after %d requests", err, requests)
+	}
+	now = now.Add(discoverRetryInterval)
+	if err := auth.discover(ctx); err != nil {
+		t.Fatalf("expected the discovery to be retried, got %v", err)
+	}
+	if err := auth.discover(ctx); err != nil || requests != 2 {
+		t.Errorf("expected the successful discovery to be kept, got %v after %d requests", err, requests)
+	}
+}
@@ -0,0 +1,27 @@
+package rakudaoidc
+
+import "time"
+
+// Session is the login session issued after a successful callback.
+type Session struct {
+	Subject string    `json:"sub"`
+	Email   string    `json:"email,omitempty"`
+	Name    string    `json:"name,omitempty"`
+	Expires time.Time `json:"exp"`
+}
+
+// loginState is kept in a short-lived cookie between the login and callback requests.
+type loginState struct {
+	State    string    `json:"state"`
+	Nonce    string    `json:"nonce"`
+	Verifier string    `json:"verifier"`
+	ReturnTo string    `json:"return_to"`
+	Expires  time.Time `json:"exp"`
+}
+
+// The purposes of the cookies, bound into their signatures, so that a login state
+// cannot be replayed as a session.
+const (
+	sessionPurpose = "rakudaoidc.session"
+	loginPurpose   = "rakudaoidc.login"
+)
@@ -0,0 +1,138 @@
+package rakudatemplate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/podhmo/rakuda"
+)
+
+// IsHTMX reports whether the request was made by htmx (the "HX-Request" header).
+func IsHTMX(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
+
+// wantsPartial reports whether the request expects a fragment rather than a full page.
+// Boosted requests (hx-boost) swap the whole body, so they get the full page.
+func wantsPartial(r *http.Request) bool {
+	return IsHTMX(r) && r.Header.Get("HX-Boosted") != "true"
+}
+
+// RenderHTMX renders the partial for htmx requests and the page with the layout otherwise,
+// so that a single handler serves both the initial page load and the fragment swaps.
+// The response has "Vary: HX-Request, HX-Boosted", so that caches keep the two apart.
+func (r *Renderer) RenderHTMX(w http.ResponseWriter, req *http.Request, code int, page, partial string, data any) {
+	w.Header().Add("Vary", "HX-Request, HX-Boosted")
+	if wantsPartial(req) {
+		r.RenderPartial(w, req, code, partial, data)
+		return
+	}
+	r.Render(w, req, code, page, data)
+}
+
+// Response is the return value of an action lifted by Renderer.Lift.
+type Response struct {
+	// Page and Partial are the templates rendered for full-page and htmx requests (see RenderHTMX).
+	// If Partial is empty, the page is always rendered; if Page is empty, the partial is.
+	Page    string
+	Partial string
+	// Data is passed to the template as View.Data.
+	Data any
+	// Status is the status code. Default is 200.
+	Status int
+	// Redirect makes the client navigate to the URL. For htmx requests, it is sent
+	// as the "HX-Redirect" header; otherwise, as a 303 See Other redirect.
+	Redirect string
+	// Trigger lists client-side events to trigger, sent as the "HX-Trigger" header.
+	// Event details are sent as JSON if any value is non-nil.
+	Trigger map[string]any
+}
+
+// Lift converts an action returning a *Response into an http.Handler.
+// Errors are handled as in rakuda.Lift; for htmx requests, a *rakuda.RedirectError
+// is sent as the "HX-Redirect" header, because htmx cannot observe 3xx responses.
+func (r *Renderer) Lift(action func(*http.Request) (*Response, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		res, err := action(req)
+		if err != nil {
+			var redirectErr *rakuda.RedirectError
+			if errors.As(err, &redirectErr) {
+				code := redirectErr.Code
+				if code == 0 {
+					code = http.StatusFound
+				}
+				r.redirect(w, req, redirectErr.URL, code)
+				return
+			}
+			var sc interface{ StatusCode() int }
+			if errors.As(err, &sc) {
+				r.responder.Error(w, req, sc.StatusCode(), err)
+				return
+			}
+			r.responder.Error(w, req, http.StatusInternalServerError, err)
+			return
+		}
+		if res == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if len(res.Trigger) > 0 {
+			w.Header().Set("HX-Trigger", triggerHeader(res.Trigger))
+		}
+		if res.Redirect != "" {
+			r.redirect(w, req, res.Redirect, http.StatusSeeOther)
+			return
+		}
+
+		code := res.Status
+		if code == 0 {
+			code = http.StatusOK
+		}
+		switch {
+		case res.Partial == "":
+			r.Render(w, req, code, res.Page, res.Data)
+		case res.Page == "":
+			r.RenderPartial(w, req, code, res.Partial, res.Data)
+		default:
+			r.RenderHTMX(w, req, code, res.Page, res.Partial, res.Data)
+		}
+	})
+}
+
+func (r *Renderer) redirect(w http.ResponseWriter, req *http.Request, url string, code int) {
+	w.Header().Add("Vary", "HX-Request")
+	if IsHTMX(req) {
+		w.Header().Set("HX-Redirect", url)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	r.responder.Redirect(w, req, url, code)
+}
+
+// triggerHeader encodes events as a comma-separated list of names,
+// or as a JSON object if any event has details.
+func triggerHeader(events map[string]any) string {
+	detailed := false
+	for _, v := range events {
+		if v != nil {
+			detailed = true
+			break
+		}
+	}
+	if detailed {
+		if b, err := json.Marshal(events); err == nil {
+			return string(b)
+		}
+	}
+	names := make([]string, 0, len(events))
+	for name := range events {
+		names = append(names, name)
+	}
+	// Sort for a stable header value.
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,128 @@
+package rakudatemplate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/podhmo/rakuda"
+)
+
+func TestLift_HTMX(t *testing.T) {
+	renderer, err := New(Config{FS: testFS})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		action      func(*http.Request) (*Response, error)
+		wantCode    int
+		wantBody    string
+		wantHeaders map[string]string
+	}{
+		{
+			name: "full page",
+			action: func(*http.Request) (*Response, error) {
+				return &Response{Page: "users/me", Partial: "user", Data: "bob"}, nil
+			},
+			wantCode:    http.StatusOK,
+			wantBody:    `<title>Me</title>me: bob`,
+			wantHeaders: map[string]string{"Vary": "HX-Request, HX-Boosted"},
+		},
+		{
+			name:    "htmx partial",
+			headers: map[string]string{"HX-Request": "true"},
+			action: func(*http.Request) (*Response, error) {
+				return &Response{Page: "users/me", Partial: "user", Data: "bob"}, nil
+			},
+			wantCode:    http.StatusOK,
+			wantBody:    `<li>bob</li>`,
+			wantHeaders: map[string]string{"Vary": "HX-Request, HX-Boosted"},
+		},
+		{
+			name:    "boosted gets the full page",
+			headers: map[string]string{"HX-Request": "true", "HX-Boosted": "true"},
+			action: func(*http.Request) (*Response, error) {
+				return &Response{Page: "users/me", Partial: "user", Data: "bob"}, nil
+			},
+			wantCode: http.StatusOK,
+			wantBody: `<title>Me</title>me: bob`,
+		},
+		{
+			name:    "trigger",
+			headers: map[string]string{"HX-Request": "true"},
+			action: func(*http.Request) (*Response, error) {
+				return &Response{Partial: "user", Data: "bob", Status: http.StatusCreated, Trigger: map[string]any{"userCreated": nil, "refresh": nil}}, nil
+			},
+			wantCode:    http.StatusCreated,
+			wantBody:    `<li>bob</li>`,
+			wantHeaders: map[string]string{"HX-Trigger": "refresh, userCreated", "Vary": ""},
+		},
+		{
+			name:    "trigger with details",
+			headers: map[string]string{"HX-Request": "true"},
+			action: func(*http.Request) (*Response, error) {
+				return &Response{Partial: "user", Data: "bob", Trigger: map[string]any{"userCreated": map[string]int{"id": 1}}}, nil
+			},
+			wantCode:    http.StatusOK,
+			wantBody:    `<li>bob</li>`,
+			wantHeaders: map[string]string{"HX-Trigger": `{"userCreated":{"id":1}}`},
+		},
+		{
+			name:        "htmx redirect",
+			headers:     map[string]string{"HX-Request": "true"},
+			action:      func(*http.Request) (*Response, error) { return &Response{Redirect: "/users/1"}, nil },
+			wantCode:    http.StatusOK,
+			wantHeaders: map[string]string{"HX-Redirect": "/users/1", "Vary": "HX-Request"},
+		},
+		{
+			name:        "redirect",
+			action:      func(*http.Request) (*Response, error) { return &Response{Redirect: "/users/1"}, nil },
+			wantCode:    http.StatusSeeOther,
+			wantHeaders: map[string]string{"Location": "/users/1", "Vary": "HX-Request"},
+		},
+		{
+			name:    "redirect error",
+			headers: map[string]string{"HX-Request": "true"},
+			action: func(*http.Request) (*Response, error) {
+				return nil, &rakuda.RedirectError{URL: "/login"}
+			},
+			wantCode:    http.StatusOK,
+			wantHeaders: map[string]string{"HX-Redirect": "/login"},
+		},
+		{
+			name: "api error",
+			action: func(*http.Request) (*Response, error) {
+				return nil, rakuda.NewAPIError(http.StatusNotFound, errors.New("not found"))
+			},
+			wantCode: http.StatusNotFound,
+			wantBody: `{"error":"not found"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			renderer.Lift(tt.action).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+				t.Errorf("body: got %q, want %q", rr.Body.String(), tt.wantBody)
+			}
+			for k, want := range tt.wantHeaders {
+				if got := rr.Header().Get(k); got != want {
+					t.Errorf("header %s: got %q, want %q", k, got, want)
+				}
+			}
+		})
+	}
+}
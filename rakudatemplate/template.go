@@ -0,0 +1,204 @@
+// Package rakudatemplate renders html/template pages with layouts and partials.
+//
+// Templates are organized in three directories of an fs.FS:
+//
+//	layouts/*.html   define the page skeleton; the "layout" template calls {{template "content" .}}
+//	partials/*.html  define reusable fragments, available to all pages and layouts
+//	pages/*.html     define "content" (and any blocks the layout expects) for each page
+//
+// Pages are rendered with the layout; partials can also be rendered alone
+// (e.g., for HTMX responses). Templates receive a View, which carries the
+// handler's data together with per-request values such as flash messages.
+//
+// In development, set Reload to parse templates on every request, typically with
+// os.DirFS, while serving from an embed.FS in production:
+//
+//	var fsys fs.FS = embedded
+//	if dev {
+//		fsys = os.DirFS("templates")
+//	}
+//	renderer, err := rakudatemplate.New(rakudatemplate.Config{FS: fsys, Reload: dev})
+package rakudatemplate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/podhmo/rakuda"
+)
+
+// View is the data passed to templates.
+type View struct {
+	// Data is the value passed by the handler.
+	Data any
+	// Flashes are the flash messages of the request (see rakuda.FlashesFromContext).
+	Flashes []rakuda.Flash
+	// Values holds per-request values returned by Config.Inject (e.g., a CSRF token).
+	Values map[string]any
+	// Request is the current request.
+	Request *http.Request
+}
+
+// Config holds the configuration for a Renderer.
+type Config struct {
+	// FS holds the templates. It is required.
+	FS fs.FS
+	// Layout is the name of the template executed for pages. Default is "layout".
+	Layout string
+	// Funcs are added to all templates.
+	Funcs template.FuncMap
+	// Inject returns per-request values to be exposed as View.Values.
+	Inject func(r *http.Request) map[string]any
+	// Reload parses the templates on every render, so that changes on disk are picked up.
+	Reload bool
+	// Responder is used to write responses. Default is rakuda.NewResponder().
+	Responder *rakuda.Responder
+}
+
+// Renderer renders pages and partials.
+type Renderer struct {
+	config    Config
+	responder *rakuda.Responder
+	set       *templateSet // parsed at startup; unused if Reload is set
+}
+
+// templateSet is the parsed form of the templates.
+type templateSet struct {
+	pages    map[string]*template.Template // by page name, e.g. "users/index"
+	partials *template.Template
+}
+
+// New creates a Renderer and parses the templates, so that errors are reported at startup.
+func New(config Config) (*Renderer, error) {
+	if config.FS == nil {
+		return nil, fmt.Errorf("rakudatemplate: FS is required")
+	}
+	if config.Layout == "" {
+		config.Layout = "layout"
+	}
+	r := &Renderer{config: config, responder: config.Responder}
+	if r.responder == nil {
+		r.responder = rakuda.NewResponder()
+	}
+	set, err := r.parse()
+	if err != nil {
+		return nil, err
+	}
+	r.set = set
+	return r, nil
+}
+
+// Render renders the page with the layout. The name is the path of the page
+// under pages/ without the extension (e.g., "users/index").
+func (r *Renderer) Render(w http.ResponseWriter, req *http.Request, code int, name string, data any) {
+	set, err := r.templates()
+	if err != nil {
+		r.responder.Error(w, req, http.StatusInternalServerError, err)
+		return
+	}
+	tmpl, ok := set.pages[name]
+	if !ok {
+		r.responder.Error(w, req, http.StatusInternalServerError, fmt.Errorf("rakudatemplate: page %q not found", name))
+		return
+	}
+	r.execute(w, req, code, tmpl, r.config.Layout, data)
+}
+
+// RenderPartial renders a template defined in partials/ without the layout.
+func (r *Renderer) RenderPartial(w http.ResponseWriter, req *http.Request, code int, name string, data any) {
+	set, err := r.templates()
+	if err != nil {
+		r.responder.Error(w, req, http.StatusInternalServerError, err)
+		return
+	}
+	r.execute(w, req, code, set.partials, name, data)
+}
+
+func (r *Renderer) execute(w http.ResponseWriter, req *http.Request, code int, tmpl *template.Template, name string, data any) {
+	view := View{
+		Data:    data,
+		Flashes: rakuda.FlashesFromContext(req.Context()),
+		Request: req,
+	}
+	if r.config.Inject != nil {
+		view.Values = r.config.Inject(req)
+	}
+
+	// Render into a buffer, so that a failing template does not produce a half-written page.
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, view); err != nil {
+		r.responder.Error(w, req, http.StatusInternalServerError, fmt.Errorf("rakudatemplate: render %q: %w", name, err))
+		return
+	}
+	r.responder.HTML(w, req, code, buf.Bytes())
+}
+
+func (r *Renderer) templates() (*templateSet, error) {
+	if r.config.Reload {
+		return r.parse()
+	}
+	return r.set, nil
+}
+
+func (r *Renderer) parse() (*templateSet, error) {
+	base := template.New("").Funcs(r.config.Funcs)
+	for _, dir := range []string{"partials", "layouts"} {
+		files, err := templateFiles(r.config.FS, dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			continue
+		}
+		if base, err = base.ParseFS(r.config.FS, files...); err != nil {
+			return nil, fmt.Errorf("rakudatemplate: parse %s: %w", dir, err)
+		}
+	}
+
+	pages, err := templateFiles(r.config.FS, "pages")
+	if err != nil {
+		return nil, err
+	}
+	set := &templateSet{pages: map[string]*template.Template{}, partials: base}
+	for _, file := range pages {
+		// Each page gets its own copy of the layouts, so that pages can define the same blocks.
+		tmpl, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if tmpl, err = tmpl.ParseFS(r.config.FS, file); err != nil {
+			return nil, fmt.Errorf("rakudatemplate: parse %s: %w", file, err)
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(file, "pages/"), path.Ext(file))
+		set.pages[name] = tmpl
+	}
+	return set, nil
+}
+
+// templateFiles returns the .html files under dir, recursively.
+// A missing directory is not an error.
+func templateFiles(fsys fs.FS, dir string) ([]string, error) {
+	var files []string
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() && path.Ext(p) == ".html" {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rakudatemplate: list %s: %w", dir, err)
+	}
+	return files, nil
+}
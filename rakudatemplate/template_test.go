@@ -0,0 +1,123 @@
+package rakudatemplate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda"
+)
+
+var testFS = fstest.MapFS{
+	"layouts/base.html":   {Data: []byte(`{{define "layout"}}<title>{{template "title" .}}</title>{{range .Flashes}}[{{.Message}}]{{end}}{{template "content" .}}{{end}}`)},
+	"partials/user.html":  {Data: []byte(`{{define "user"}}<li>{{.Data}}</li>{{end}}`)},
+	"pages/index.html":    {Data: []byte(`{{define "title"}}Home{{end}}{{define "content"}}token={{.Values.csrf}} {{template "user" .}}{{end}}`)},
+	"pages/users/me.html": {Data: []byte(`{{define "title"}}Me{{end}}{{define "content"}}me: {{.Data}}{{end}}`)},
+}
+
+func TestRenderer(t *testing.T) {
+	renderer, err := New(Config{
+		FS:     testFS,
+		Inject: func(r *http.Request) map[string]any { return map[string]any{"csrf": "token-1"} },
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		render   func(w http.ResponseWriter, r *http.Request)
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "page",
+			render: func(w http.ResponseWriter, r *http.Request) {
+				r = r.WithContext(rakuda.NewContextWithFlashes(r.Context(), []rakuda.Flash{{Message: "saved"}}))
+				renderer.Render(w, r, http.StatusOK, "index", "<alice>")
+			},
+			wantCode: http.StatusOK,
+			wantBody: `<title>Home</title>[saved]token=token-1 <li>&lt;alice&gt;</li>`,
+		},
+		{
+			name: "nested page",
+			render: func(w http.ResponseWriter, r *http.Request) {
+				renderer.Render(w, r, http.StatusOK, "users/me", "bob")
+			},
+			wantCode: http.StatusOK,
+			wantBody: `<title>Me</title>me: bob`,
+		},
+		{
+			name: "partial",
+			render: func(w http.ResponseWriter, r *http.Request) {
+				renderer.RenderPartial(w, r, http.StatusCreated, "user", "carol")
+			},
+			wantCode: http.StatusCreated,
+			wantBody: `<li>carol</li>`,
+		},
+		{
+			name: "unknown page",
+			render: func(w http.ResponseWriter, r *http.Request) {
+				renderer.Render(w, r, http.StatusOK, "missing", nil)
+			},
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"error":"Internal Server Error"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tt.render(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rr.Code != tt.wantCode {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantCode)
+			}
+			if diff := cmp.Diff(tt.wantBody, rr.Body.String()); diff != "" {
+				t.Errorf("body mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRenderer_Reload(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "pages", "index.html")
+	if err := os.MkdirAll(filepath.Dir(page), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(content string) {
+		if err := os.WriteFile(page, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{{define "layout"}}v1{{end}}`)
+
+	renderer, err := New(Config{FS: os.DirFS(dir), Reload: true})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	render := func() string {
+		rr := httptest.NewRecorder()
+		renderer.Render(rr, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, "index", nil)
+		return rr.Body.String()
+	}
+
+	if got := render(); got != "v1" {
+		t.Errorf("got %q, want %q", got, "v1")
+	}
+	write(`{{define "layout"}}v2{{end}}`)
+	if got := render(); got != "v2" {
+		t.Errorf("after change: got %q, want %q", got, "v2")
+	}
+}
+
+func TestNew_ParseError(t *testing.T) {
+	_, err := New(Config{FS: fstest.MapFS{"pages/broken.html": {Data: []byte(`{{define "content"}}`)}}})
+	if err == nil {
+		t.Fatal("expected a parse error")
+	}
+}
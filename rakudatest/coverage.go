@@ -0,0 +1,123 @@
+package rakudatest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/podhmo/rakuda"
+)
+
+// Coverage is an http.Handler that tracks which routes of a built handler are exercised
+// by the requests of a test run, so that each endpoint gets at least a smoke test.
+// A route counts as exercised when a request matches it, whatever the response.
+//
+//	var coverage = rakudatest.NewCoverage(mustBuild())
+//
+//	func TestUsers(t *testing.T) {
+//		rakudatest.Do[[]User](t, coverage, httptest.NewRequest("GET", "/users", nil), http.StatusOK)
+//	}
+//
+//	func TestMain(m *testing.M) {
+//		code := m.Run()
+//		if coverage.Ratio() < 1.0 {
+//			coverage.Report(os.Stderr)
+//			code = 1
+//		}
+//		os.Exit(code)
+//	}
+//
+// Within a single test, Check fails the test if too few routes are exercised.
+//
+// It is safe for concurrent use.
+type Coverage struct {
+	handler http.Handler
+	routes  []rakuda.RouteInfo
+	mux     *http.ServeMux // matches requests to the keys of the routes
+
+	mu   sync.Mutex
+	hits map[string]int
+}
+
+// NewCoverage wraps h, the handler returned by Build, which must implement rakuda.RouteTable.
+func NewCoverage(h http.Handler) *Coverage {
+	table, ok := h.(rakuda.RouteTable)
+	if !ok {
+		panic(fmt.Sprintf("rakudatest: %T does not implement rakuda.RouteTable", h))
+	}
+	c := &Coverage{handler: h, routes: table.Routes(), mux: http.NewServeMux(), hits: map[string]int{}}
+	for _, r := range c.routes {
+		c.mux.Handle(routeKey(r), http.NotFoundHandler())
+	}
+	return c
+}
+
+// ServeHTTP records the route matched by r and serves it with the wrapped handler.
+func (c *Coverage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := c.mux.Handler(r); pattern != "" {
+		c.mu.Lock()
+		c.hits[pattern]++
+		c.mu.Unlock()
+	}
+	c.handler.ServeHTTP(w, r)
+}
+
+// Uncovered returns the routes that no request has matched, in registration order.
+func (c *Coverage) Uncovered() []rakuda.RouteInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var uncovered []rakuda.RouteInfo
+	for _, r := range c.routes {
+		if c.hits[routeKey(r)] == 0 {
+			uncovered = append(uncovered, r)
+		}
+	}
+	return uncovered
+}
+
+// Ratio returns the fraction of the routes matched by at least one request.
+// It is 1 if there are no routes.
+func (c *Coverage) Ratio() float64 {
+	if len(c.routes) == 0 {
+		return 1
+	}
+	return float64(len(c.routes)-len(c.Uncovered())) / float64(len(c.routes))
+}
+
+// Report writes the number of requests that matched each route, in registration order.
+func (c *Coverage) Report(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, r := range c.routes {
+		if _, err := fmt.Fprintf(w, "%5d  %s\n", c.hits[routeKey(r)], routeKey(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check fails the test if the ratio of exercised routes is below min (e.g., 1.0 for
+// all routes), listing the uncovered routes with their registration locations.
+func (c *Coverage) Check(t *testing.T, min float64) {
+	t.Helper()
+	if ratio := c.Ratio(); ratio < min {
+		var lines []string
+		for _, r := range c.Uncovered() {
+			lines = append(lines, fmt.Sprintf("  %s (%s)", routeKey(r), r.Source))
+		}
+		t.Errorf("route coverage %.1f%% is below %.1f%%; uncovered routes:\n%s", ratio*100, min*100, strings.Join(lines, "\n"))
+	}
+}
+
+// routeKey returns the ServeMux pattern of a route.
+func routeKey(r rakuda.RouteInfo) string {
+	if r.Method == "" {
+		return r.Pattern
+	}
+	return r.Method + " " + r.Pattern
+}
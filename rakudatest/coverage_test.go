@@ -0,0 +1,57 @@
+package rakudatest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda"
+)
+
+func TestCoverage(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	})
+	b := rakuda.NewBuilder()
+	b.Get("/users", ok)
+	b.Get("/users/{id}", ok)
+	b.Post("/users", ok)
+	b.Route("/admin", func(b *rakuda.Builder) {
+		b.Handle("/stats", ok)
+	})
+	h, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+
+	coverage := NewCoverage(h)
+	Do[map[string]any](t, coverage, httptest.NewRequest(http.MethodGet, "/users", nil), http.StatusOK)
+	Do[map[string]any](t, coverage, httptest.NewRequest(http.MethodGet, "/users/1", nil), http.StatusOK)
+	Do[map[string]any](t, coverage, httptest.NewRequest(http.MethodGet, "/users/2", nil), http.StatusOK)
+	Do[map[string]any](t, coverage, httptest.NewRequest(http.MethodGet, "/missing", nil), http.StatusNotFound)
+
+	var uncovered []string
+	for _, r := range coverage.Uncovered() {
+		uncovered = append(uncovered, routeKey(r))
+	}
+	if diff := cmp.Diff([]string{"POST /users", "/admin/stats"}, uncovered); diff != "" {
+		t.Errorf("Uncovered() mismatch (-want +got):\n%s", diff)
+	}
+	if got, want := coverage.Ratio(), 0.5; got != want {
+		t.Errorf("Ratio(): got %v, want %v", got, want)
+	}
+
+	var report strings.Builder
+	if err := coverage.Report(&report); err != nil {
+		t.Fatalf("Report() failed: %v", err)
+	}
+	want := "    1  GET /users\n    2  GET /users/{id}\n    0  POST /users\n    0  /admin/stats\n"
+	if diff := cmp.Diff(want, report.String()); diff != "" {
+		t.Errorf("Report() mismatch (-want +got):\n%s", diff)
+	}
+
+	coverage.Check(t, 0.5)
+}
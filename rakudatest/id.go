@@ -0,0 +1,21 @@
+package rakudatest
+
+import (
+	"encoding/binary"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/podhmo/rakuda"
+)
+
+// SeedIDs makes the IDs generated with rakuda.NewID (e.g., recording IDs and experiment keys)
+// deterministic for the rest of the test: the same seed produces the same sequence of IDs,
+// so that golden-file snapshots are stable. The crypto/rand source is restored on cleanup.
+// The source is global, so the test must not run in parallel with other tests that generate IDs.
+func SeedIDs(t *testing.T, seed uint64) {
+	t.Helper()
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], seed)
+	restore := rakuda.SetIDSource(rand.NewChaCha8(key))
+	t.Cleanup(restore)
+}
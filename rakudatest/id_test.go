@@ -0,0 +1,34 @@
+package rakudatest
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda"
+)
+
+func TestSeedIDs(t *testing.T) {
+	generate := func(seed uint64) []string {
+		var ids []string
+		t.Run("seeded", func(t *testing.T) {
+			SeedIDs(t, seed)
+			ids = []string{rakuda.NewID(8), rakuda.NewID(16)}
+		})
+		return ids
+	}
+
+	first := generate(42)
+	if diff := cmp.Diff(first, generate(42)); diff != "" {
+		t.Errorf("same seed produced different IDs (-first +second):\n%s", diff)
+	}
+	if diff := cmp.Diff(first, generate(43)); diff == "" {
+		t.Error("different seeds produced the same IDs")
+	}
+	if got := len(first[1]); got != 32 {
+		t.Errorf("len(NewID(16)): got %d, want 32", got)
+	}
+	// The source is restored after the subtests.
+	if rakuda.NewID(8) == first[0] {
+		t.Error("expected a random ID after cleanup")
+	}
+}
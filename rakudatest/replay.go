@@ -0,0 +1,53 @@
+package rakudatest
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda"
+	"github.com/podhmo/rakuda/rakudamiddleware"
+)
+
+// Replay sends a request captured by rakudamiddleware.Record to h and fails the test
+// if the status code or the body differs from the recorded response. The body is not
+// compared if the recording is truncated. Redacted headers are sent as recorded, so
+// tests should restore them on rec.RequestHeader first if the handler requires them.
+//
+// After the comparison, any provided ResponseAssertion functions are executed.
+func Replay(t *testing.T, h http.Handler, rec *rakudamiddleware.Recording, assertions ...ResponseAssertion) {
+	t.Helper()
+
+	req, err := rec.Request()
+	if err != nil {
+		t.Fatalf("replay %s: %v", rec.ID, err)
+	}
+	testLogger := slog.New(NewTHandler(t, slog.LevelDebug))
+	req = req.WithContext(rakuda.NewContextWithLogger(req.Context(), testLogger))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("replay %s %s %s: failed to read response body: %v", rec.ID, rec.Method, rec.URL, err)
+	}
+
+	if res.StatusCode != rec.Status {
+		t.Errorf("replay %s %s %s: expected status code %d, got %d\nresponse body:\n%s", rec.ID, rec.Method, rec.URL, rec.Status, res.StatusCode, string(body))
+	}
+	if !rec.Truncated {
+		if diff := cmp.Diff(rec.ResponseBody, string(body)); diff != "" {
+			t.Errorf("replay %s %s %s: response body mismatch (-recorded +replayed):\n%s", rec.ID, rec.Method, rec.URL, diff)
+		}
+	}
+
+	for _, assert := range assertions {
+		assert(t, res, body)
+	}
+}
@@ -0,0 +1,40 @@
+package rakudatest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/podhmo/rakuda/rakudamiddleware"
+)
+
+func TestReplay(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"echo":` + string(body) + `,"q":"` + r.URL.Query().Get("q") + `"}`))
+	})
+
+	store := &rakudamiddleware.MemoryRecordingStore{}
+	recorded := rakudamiddleware.Record(rakudamiddleware.RecordConfig{Store: store})(handler)
+	recorded.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/items?q=x", strings.NewReader(`"hello"`)))
+
+	recordings := store.Recordings()
+	if len(recordings) != 1 {
+		t.Fatalf("expected 1 recording, got %d", len(recordings))
+	}
+
+	called := false
+	Replay(t, handler, recordings[0], func(t *testing.T, res *http.Response, body []byte) {
+		called = true
+		if got, want := res.Header.Get("Content-Type"), "application/json"; got != want {
+			t.Errorf("Content-Type: got %q, want %q", got, want)
+		}
+	})
+	if !called {
+		t.Error("expected the assertion to be called")
+	}
+}
@@ -0,0 +1,182 @@
+package rakudaupload
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/podhmo/rakuda"
+)
+
+// FileStore is a Store that keeps uploads in a directory on the local filesystem.
+// Each upload is stored as two files: "<id>.bin" holds the content and
+// "<id>.json" holds the Info.
+type FileStore struct {
+	dir string
+
+	mu    sync.Mutex
+	locks map[string]*uploadLock // per-upload locks, guarded by mu
+}
+
+// uploadLock serializes the writes to one upload.
+type uploadLock struct {
+	mu   sync.Mutex
+	refs int // the number of goroutines holding or waiting for mu, guarded by FileStore.mu
+}
+
+var _ Store = (*FileStore)(nil)
+
+// NewFileStore creates a FileStore in dir. The directory is created if it does not exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create upload directory: %w", err)
+	}
+	return &FileStore{dir: dir, locks: map[string]*uploadLock{}}, nil
+}
+
+// Path returns the path of the content file of the upload.
+func (s *FileStore) Path(id string) string {
+	return filepath.Join(s.dir, id+".bin")
+}
+
+// Create implements Store.
+func (s *FileStore) Create(ctx context.Context, length int64, metadata map[string]string) (*Info, error) {
+	id := rakuda.NewID(16)
+	info := &Info{ID: id, Length: length, Metadata: metadata}
+
+	f, err := os.OpenFile(s.Path(id), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("create upload file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := s.save(info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Get implements Store.
+func (s *FileStore) Get(ctx context.Context, id string) (*Info, error) {
+	return s.load(id)
+}
+
+// Write implements Store. Writes to the same upload are serialized, while writes to
+// different uploads run concurrently.
+func (s *FileStore) Write(ctx context.Context, id string, offset int64, r io.Reader) (*Info, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	unlock := s.lock(id)
+	defer unlock()
+
+	info, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != info.Offset {
+		return info, fmt.Errorf("%w: expected %d, got %d", ErrOffsetMismatch, info.Offset, offset)
+	}
+
+	f, err := os.OpenFile(s.Path(id), os.O_WRONLY, 0)
+	if err != nil {
+		return info, fmt.Errorf("open upload file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return info, err
+	}
+
+	// Read one extra byte to detect content beyond the declared length.
+	remaining := info.Length - info.Offset
+	n, copyErr := io.Copy(f, io.LimitReader(r, remaining+1))
+	if n > remaining {
+		n = remaining
+		copyErr = fmt.Errorf("%w: the declared length is %d bytes", ErrTooLarge, info.Length)
+		if err := f.Truncate(info.Length); err != nil {
+			return info, err
+		}
+	}
+
+	// Keep what was written, even if the connection dropped, so that the client can resume.
+	info.Offset += n
+	if err := s.save(info); err != nil {
+		return info, err
+	}
+	return info, copyErr
+}
+
+// lock acquires the lock of the upload and returns the function to release it.
+func (s *FileStore) lock(id string) (unlock func()) {
+	s.mu.Lock()
+	l, ok := s.locks[id]
+	if !ok {
+		l = &uploadLock{}
+		s.locks[id] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		s.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(s.locks, id)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *FileStore) infoPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *FileStore) load(id string) (*Info, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	b, err := os.ReadFile(s.infoPath(id))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	var info Info
+	if err := json.Unmarshal(b, &info); err != nil {
+		return nil, fmt.Errorf("decode upload info: %w", err)
+	}
+	return &info, nil
+}
+
+func (s *FileStore) save(info *Info) error {
+	b, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file and rename it, so that a crash never leaves a partial info file.
+	tmp := s.infoPath(info.ID) + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("write upload info: %w", err)
+	}
+	return os.Rename(tmp, s.infoPath(info.ID))
+}
+
+// validID reports whether id looks like an ID generated by rakuda.NewID(16),
+// so that it cannot be used for path traversal.
+func validID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
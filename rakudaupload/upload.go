@@ -0,0 +1,262 @@
+// Package rakudaupload provides resumable uploads for large-file ingestion APIs.
+//
+// The protocol is tus-like: a client creates an upload with its total length,
+// then sends the content in one or more PATCH requests, each starting at the
+// offset reported by the server. If a connection drops, the client asks for the
+// current offset and resumes from there.
+//
+//	POST  /uploads       Upload-Length: 1000          -> 201, Location: /uploads/{id}
+//	PATCH /uploads/{id}  Upload-Offset: 0    (bytes)  -> 204, Upload-Offset: 500
+//	GET   /uploads/{id}                               -> 200, Upload-Offset: 500
+//	PATCH /uploads/{id}  Upload-Offset: 500  (bytes)  -> 204, Upload-Offset: 1000
+//
+// Instead of Upload-Offset, a PATCH request may use a Content-Range header
+// (e.g., "bytes 500-999/1000").
+package rakudaupload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/podhmo/rakuda"
+	"github.com/podhmo/rakuda/binding"
+)
+
+var (
+	// ErrNotFound is returned by a Store when the upload does not exist.
+	ErrNotFound = errors.New("upload not found")
+	// ErrOffsetMismatch is returned by a Store when a chunk does not start at the current offset.
+	ErrOffsetMismatch = errors.New("upload offset mismatch")
+	// ErrTooLarge is returned when the content exceeds the declared or allowed length.
+	ErrTooLarge = errors.New("upload too large")
+)
+
+// Info describes the state of an upload.
+type Info struct {
+	ID       string            `json:"id"`
+	Length   int64             `json:"length"`
+	Offset   int64             `json:"offset"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Done reports whether all the content has been received.
+func (i *Info) Done() bool {
+	return i.Offset >= i.Length
+}
+
+// Store persists uploads and their content.
+type Store interface {
+	// Create starts a new upload with the given total length.
+	Create(ctx context.Context, length int64, metadata map[string]string) (*Info, error)
+	// Get returns the current state of the upload.
+	Get(ctx context.Context, id string) (*Info, error)
+	// Write appends the content of r at offset, which must equal the current offset.
+	// It returns the updated state, even if only a part of r was written.
+	Write(ctx context.Context, id string, offset int64, r io.Reader) (*Info, error)
+}
+
+// Config holds the configuration for the upload routes.
+type Config struct {
+	// MaxSize is the maximum total length of an upload. Zero means unlimited.
+	MaxSize int64
+	// OnComplete is called when the last chunk of an upload has been written.
+	OnComplete func(ctx context.Context, info *Info)
+	// Responder is used to write responses. Default is rakuda.NewResponder().
+	Responder *rakuda.Responder
+}
+
+// Mount registers the upload routes under prefix:
+// POST prefix creates an upload, GET prefix/{id} reports its offset (HEAD is also
+// served), and PATCH prefix/{id} appends a chunk.
+func Mount(b *rakuda.Builder, prefix string, store Store, config *Config) {
+	if config == nil {
+		config = &Config{}
+	}
+	h := &handler{store: store, config: config, responder: config.Responder}
+	if h.responder == nil {
+		h.responder = rakuda.NewResponder()
+	}
+
+	prefix = strings.TrimSuffix(prefix, "/")
+	b.Post(prefix, http.HandlerFunc(h.create))
+	b.Get(prefix+"/{id}", http.HandlerFunc(h.status))
+	b.Patch(prefix+"/{id}", http.HandlerFunc(h.write))
+}
+
+type handler struct {
+	store     Store
+	config    *Config
+	responder *rakuda.Responder
+}
+
+func (h *handler) create(w http.ResponseWriter, r *http.Request) {
+	var length int64
+	if err := binding.Join(Length(binding.New(r, r.PathValue), &length)); err != nil {
+		h.responder.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if h.config.MaxSize > 0 && length > h.config.MaxSize {
+		h.responder.Error(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("%w: the maximum size is %d bytes", ErrTooLarge, h.config.MaxSize))
+		return
+	}
+
+	info, err := h.store.Create(r.Context(), length, parseMetadata(r.Header.Get("Upload-Metadata")))
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+info.ID)
+	setHeaders(w, info)
+	h.responder.JSON(w, r, http.StatusCreated, info)
+}
+
+func (h *handler) status(w http.ResponseWriter, r *http.Request) {
+	info, err := h.store.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	setHeaders(w, info)
+	h.responder.JSON(w, r, http.StatusOK, info)
+}
+
+func (h *handler) write(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	b := binding.New(r, r.PathValue)
+
+	var offset int64
+	var body io.Reader = r.Body
+	if _, ok := b.Lookup(binding.Header, "Content-Range"); ok {
+		var rng Range
+		if err := binding.Join(ContentRange(b, &rng)); err != nil {
+			h.responder.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		offset = rng.Start
+		body = io.LimitReader(r.Body, rng.End-rng.Start+1)
+	} else if err := binding.Join(Offset(b, &offset)); err != nil {
+		h.responder.Error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	info, err := h.store.Write(ctx, r.PathValue("id"), offset, body)
+	if info != nil {
+		setHeaders(w, info)
+	}
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	if info.Done() && h.config.OnComplete != nil {
+		h.config.OnComplete(ctx, info)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		h.responder.Error(w, r, http.StatusNotFound, err)
+	case errors.Is(err, ErrOffsetMismatch):
+		h.responder.Error(w, r, http.StatusConflict, err)
+	case errors.Is(err, ErrTooLarge):
+		h.responder.Error(w, r, http.StatusRequestEntityTooLarge, err)
+	default:
+		h.responder.Error(w, r, http.StatusInternalServerError, err)
+	}
+}
+
+func setHeaders(w http.ResponseWriter, info *Info) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(info.Length, 10))
+}
+
+// parseMetadata parses a header of the form "key1 value1,key2 value2".
+func parseMetadata(s string) map[string]string {
+	if s == "" {
+		return nil
+	}
+	metadata := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key != "" {
+			metadata[key] = value
+		}
+	}
+	return metadata
+}
+
+// ParseSize parses a non-negative byte count.
+func ParseSize(s string) (int64, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, errors.New("must not be negative")
+	}
+	return n, nil
+}
+
+// Offset binds the required Upload-Offset header.
+func Offset(b *binding.Binding, dest *int64) error {
+	return binding.One(b, dest, binding.Header, "Upload-Offset", ParseSize, binding.Required)
+}
+
+// Length binds the required Upload-Length header.
+func Length(b *binding.Binding, dest *int64) error {
+	return binding.One(b, dest, binding.Header, "Upload-Length", ParseSize, binding.Required)
+}
+
+// Range is a parsed Content-Range header. End is inclusive.
+// Total is -1 if the total length is unknown ("*").
+type Range struct {
+	Start int64
+	End   int64
+	Total int64
+}
+
+// ParseContentRange parses a Content-Range header of the form "bytes start-end/total".
+func ParseContentRange(s string) (Range, error) {
+	spec, ok := strings.CutPrefix(s, "bytes ")
+	if !ok {
+		return Range{}, errors.New(`must start with "bytes "`)
+	}
+	span, total, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Range{}, errors.New("missing total length")
+	}
+	start, end, ok := strings.Cut(span, "-")
+	if !ok {
+		return Range{}, errors.New("missing range end")
+	}
+
+	var rng Range
+	var err error
+	if rng.Start, err = ParseSize(start); err != nil {
+		return Range{}, fmt.Errorf("invalid range start: %w", err)
+	}
+	if rng.End, err = ParseSize(end); err != nil {
+		return Range{}, fmt.Errorf("invalid range end: %w", err)
+	}
+	if rng.End < rng.Start {
+		return Range{}, errors.New("range end is before range start")
+	}
+	if total == "*" {
+		rng.Total = -1
+	} else if rng.Total, err = ParseSize(total); err != nil {
+		return Range{}, fmt.Errorf("invalid total length: %w", err)
+	}
+	return rng, nil
+}
+
+// ContentRange binds the required Content-Range header.
+func ContentRange(b *binding.Binding, dest *Range) error {
+	return binding.One(b, dest, binding.Header, "Content-Range", ParseContentRange, binding.Required)
+}
@@ -0,0 +1,213 @@
+package rakudaupload
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda"
+)
+
+func TestResumableUpload(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore() failed: %v", err)
+	}
+
+	var completed *Info
+	b := rakuda.NewBuilder()
+	Mount(b, "/uploads", store, &Config{
+		MaxSize:    100,
+		OnComplete: func(ctx context.Context, info *Info) { completed = info },
+	})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	do := func(t *testing.T, method, path string, headers map[string]string, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// create
+	rr := do(t, http.MethodPost, "/uploads", map[string]string{"Upload-Length": "11", "Upload-Metadata": "filename hello.txt"}, "")
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create: status code: got %d, want %d, body: %s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	location := rr.Header().Get("Location")
+	id := strings.TrimPrefix(location, "/uploads/")
+
+	// first chunk
+	rr = do(t, http.MethodPatch, location, map[string]string{"Upload-Offset": "0"}, "hello")
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("first chunk: status code: got %d, want %d, body: %s", rr.Code, http.StatusNoContent, rr.Body.String())
+	}
+	if got, want := rr.Header().Get("Upload-Offset"), "5"; got != want {
+		t.Errorf("first chunk: Upload-Offset: got %q, want %q", got, want)
+	}
+
+	// resending from a stale offset is a conflict
+	rr = do(t, http.MethodPatch, location, map[string]string{"Upload-Offset": "0"}, "hello")
+	if rr.Code != http.StatusConflict {
+		t.Errorf("stale offset: status code: got %d, want %d", rr.Code, http.StatusConflict)
+	}
+
+	// status (HEAD)
+	rr = do(t, http.MethodHead, location, nil, "")
+	if got, want := rr.Header().Get("Upload-Offset"), "5"; got != want {
+		t.Errorf("status: Upload-Offset: got %q, want %q", got, want)
+	}
+
+	// last chunk with Content-Range
+	rr = do(t, http.MethodPatch, location, map[string]string{"Content-Range": "bytes 5-10/11"}, " world")
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("last chunk: status code: got %d, want %d, body: %s", rr.Code, http.StatusNoContent, rr.Body.String())
+	}
+
+	want := &Info{ID: id, Length: 11, Offset: 11, Metadata: map[string]string{"filename": "hello.txt"}}
+	if diff := cmp.Diff(want, completed); diff != "" {
+		t.Errorf("OnComplete info mismatch (-want +got):\n%s", diff)
+	}
+	content, err := os.ReadFile(store.Path(id))
+	if err != nil {
+		t.Fatalf("failed to read upload: %v", err)
+	}
+	if got, want := string(content), "hello world"; got != want {
+		t.Errorf("content: got %q, want %q", got, want)
+	}
+}
+
+func TestResumableUpload_Errors(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore() failed: %v", err)
+	}
+	info, err := store.Create(context.Background(), 3, nil)
+	if err != nil {
+		t.Fatalf("store.Create() failed: %v", err)
+	}
+
+	b := rakuda.NewBuilder()
+	Mount(b, "/uploads", store, &Config{MaxSize: 10})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		body    string
+		want    int
+	}{
+		{"missing length", http.MethodPost, "/uploads", nil, "", http.StatusBadRequest},
+		{"exceeds max size", http.MethodPost, "/uploads", map[string]string{"Upload-Length": "11"}, "", http.StatusRequestEntityTooLarge},
+		{"unknown upload", http.MethodGet, "/uploads/unknown", nil, "", http.StatusNotFound},
+		{"missing offset", http.MethodPatch, "/uploads/" + info.ID, nil, "abc", http.StatusBadRequest},
+		{"invalid content range", http.MethodPatch, "/uploads/" + info.ID, map[string]string{"Content-Range": "bytes 2-1/3"}, "abc", http.StatusBadRequest},
+		{"exceeds declared length", http.MethodPatch, "/uploads/" + info.ID, map[string]string{"Upload-Offset": "0"}, "abcd", http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("status code: got %d, want %d, body: %s", rr.Code, tt.want, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestFileStore_ConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore() failed: %v", err)
+	}
+	slow, err := store.Create(ctx, 3, nil)
+	if err != nil {
+		t.Fatalf("store.Create() failed: %v", err)
+	}
+	fast, err := store.Create(ctx, 3, nil)
+	if err != nil {
+		t.Fatalf("store.Create() failed: %v", err)
+	}
+
+	// A write whose body is still arriving must not block the other uploads.
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		_, err := store.Write(ctx, slow.ID, 0, pr)
+		done <- err
+	}()
+	if _, err := pw.Write([]byte("ab")); err != nil {
+		t.Fatalf("pw.Write() failed: %v", err)
+	}
+
+	if info, err := store.Write(ctx, fast.ID, 0, strings.NewReader("xyz")); err != nil || info.Offset != 3 {
+		t.Fatalf("store.Write() = %+v, %v, want offset 3", info, err)
+	}
+	if _, err := store.Create(ctx, 1, nil); err != nil {
+		t.Fatalf("store.Create() failed: %v", err)
+	}
+	if _, err := store.Get(ctx, slow.ID); err != nil {
+		t.Fatalf("store.Get() failed: %v", err)
+	}
+
+	pw.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("store.Write() failed: %v", err)
+	}
+	info, err := store.Get(ctx, slow.ID)
+	if err != nil {
+		t.Fatalf("store.Get() failed: %v", err)
+	}
+	if info.Offset != 2 {
+		t.Errorf("offset: got %d, want 2", info.Offset)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Range
+		wantErr bool
+	}{
+		{"bytes 0-99/200", Range{Start: 0, End: 99, Total: 200}, false},
+		{"bytes 100-199/*", Range{Start: 100, End: 199, Total: -1}, false},
+		{"0-99/200", Range{}, true},
+		{"bytes 0-99", Range{}, true},
+		{"bytes 99-0/200", Range{}, true},
+		{"bytes a-99/200", Range{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseContentRange(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseContentRange(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseContentRange(%q) mismatch (-want +got):\n%s", tt.input, diff)
+			}
+		})
+	}
+}
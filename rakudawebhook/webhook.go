@@ -0,0 +1,376 @@
+// Package rakudawebhook delivers events to webhook endpoints.
+//
+// A Dispatcher takes deliveries from a Queue, POSTs the event payload to the
+// endpoint with an HMAC signature, retries failed deliveries with backoff, and
+// records every attempt in a DeliveryLog. Receivers check the signature with Verify.
+package rakudawebhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/podhmo/rakuda"
+)
+
+const (
+	// SignatureHeader holds the signature of a delivery, "t=<unix time>,v1=<hex HMAC-SHA256>".
+	SignatureHeader = "Webhook-Signature"
+	// IDHeader holds the event ID, so that receivers can deduplicate retried deliveries.
+	IDHeader = "Webhook-Id"
+	// EventHeader holds the event type.
+	EventHeader = "Webhook-Event"
+)
+
+// Event is an event to be delivered.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// NewEvent creates an event with a random ID, marshaling payload to JSON.
+func NewEvent(typ string, payload any) (*Event, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal webhook payload: %w", err)
+	}
+	return &Event{ID: rakuda.NewID(16), Type: typ, Payload: b, CreatedAt: time.Now()}, nil
+}
+
+// Endpoint is a webhook receiver.
+type Endpoint struct {
+	URL    string
+	Secret string
+}
+
+// Delivery is an event scheduled for delivery to an endpoint.
+type Delivery struct {
+	ID       string
+	Event    *Event
+	Endpoint Endpoint
+	// Attempt is the number of attempts made so far.
+	Attempt int
+	// NextAttempt is the time when the delivery is due.
+	NextAttempt time.Time
+}
+
+// Queue holds pending deliveries.
+type Queue interface {
+	// Enqueue adds a delivery. It must not be returned by Dequeue before its NextAttempt.
+	Enqueue(ctx context.Context, d *Delivery) error
+	// Dequeue blocks until a delivery is due or ctx is done.
+	Dequeue(ctx context.Context) (*Delivery, error)
+}
+
+// Attempt is a record of a single delivery attempt.
+type Attempt struct {
+	DeliveryID string
+	EventID    string
+	URL        string
+	Attempt    int
+	Status     int    // zero if no response was received
+	Error      string // empty on success
+	Duration   time.Duration
+	At         time.Time
+}
+
+// DeliveryLog records delivery attempts.
+type DeliveryLog interface {
+	Record(ctx context.Context, a Attempt) error
+}
+
+// Config holds the configuration for a Dispatcher.
+type Config struct {
+	// Queue holds pending deliveries. Default is a MemoryQueue.
+	Queue Queue
+	// Log records delivery attempts. Optional.
+	Log DeliveryLog
+	// Client sends the requests. Default is a client with a 10 second timeout.
+	Client *http.Client
+	// MaxAttempts is the maximum number of attempts per delivery. Default is 5.
+	MaxAttempts int
+	// Backoff returns the delay before the next attempt, given the number of attempts made so far.
+	// Default is exponential backoff starting at 1 second, capped at 1 hour.
+	Backoff func(attempt int) time.Duration
+	// Logger is used for logging. Default is slog.Default().
+	Logger *slog.Logger
+}
+
+// Dispatcher delivers events to webhook endpoints.
+type Dispatcher struct {
+	queue       Queue
+	log         DeliveryLog
+	client      *http.Client
+	maxAttempts int
+	backoff     func(int) time.Duration
+	logger      *slog.Logger
+}
+
+// NewDispatcher creates a Dispatcher. If config is nil, it uses the default settings.
+func NewDispatcher(config *Config) *Dispatcher {
+	if config == nil {
+		config = &Config{}
+	}
+	d := &Dispatcher{
+		queue:       config.Queue,
+		log:         config.Log,
+		client:      config.Client,
+		maxAttempts: config.MaxAttempts,
+		backoff:     config.Backoff,
+		logger:      config.Logger,
+	}
+	if d.queue == nil {
+		d.queue = NewMemoryQueue()
+	}
+	if d.client == nil {
+		d.client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if d.maxAttempts <= 0 {
+		d.maxAttempts = 5
+	}
+	if d.backoff == nil {
+		d.backoff = ExponentialBackoff(time.Second, time.Hour)
+	}
+	if d.logger == nil {
+		d.logger = slog.Default()
+	}
+	return d
+}
+
+// ExponentialBackoff returns a backoff function doubling the delay from base, capped at max.
+func ExponentialBackoff(base, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		delay := base
+		for i := 1; i < attempt && delay < max; i++ {
+			delay *= 2
+		}
+		return min(delay, max)
+	}
+}
+
+// Dispatch schedules the event for delivery to the endpoint.
+func (d *Dispatcher) Dispatch(ctx context.Context, endpoint Endpoint, event *Event) error {
+	return d.queue.Enqueue(ctx, &Delivery{ID: rakuda.NewID(16), Event: event, Endpoint: endpoint, NextAttempt: time.Now()})
+}
+
+// Run delivers queued events until ctx is done. It returns nil when ctx is canceled.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	for {
+		delivery, err := d.queue.Dequeue(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("dequeue webhook delivery: %w", err)
+		}
+		d.process(ctx, delivery)
+	}
+}
+
+func (d *Dispatcher) process(ctx context.Context, delivery *Delivery) {
+	delivery.Attempt++
+	start := time.Now()
+	status, err := d.deliver(ctx, delivery)
+
+	attempt := Attempt{
+		DeliveryID: delivery.ID,
+		EventID:    delivery.Event.ID,
+		URL:        delivery.Endpoint.URL,
+		Attempt:    delivery.Attempt,
+		Status:     status,
+		Duration:   time.Since(start),
+		At:         start,
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	if d.log != nil {
+		if err := d.log.Record(ctx, attempt); err != nil {
+			d.logger.ErrorContext(ctx, "failed to record webhook delivery", "error", err, "delivery", delivery.ID)
+		}
+	}
+	if err == nil {
+		return
+	}
+
+	if delivery.Attempt >= d.maxAttempts {
+		d.logger.ErrorContext(ctx, "webhook delivery failed", "error", err, "delivery", delivery.ID, "url", delivery.Endpoint.URL, "attempts", delivery.Attempt)
+		return
+	}
+	delivery.NextAttempt = time.Now().Add(d.backoff(delivery.Attempt))
+	d.logger.WarnContext(ctx, "webhook delivery will be retried", "error", err, "delivery", delivery.ID, "url", delivery.Endpoint.URL, "attempt", delivery.Attempt, "next_attempt", delivery.NextAttempt)
+	if err := d.queue.Enqueue(ctx, delivery); err != nil {
+		d.logger.ErrorContext(ctx, "failed to requeue webhook delivery", "error", err, "delivery", delivery.ID)
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *Delivery) (int, error) {
+	body := delivery.Event.Payload
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IDHeader, delivery.Event.ID)
+	req.Header.Set(EventHeader, delivery.Event.Type)
+	if delivery.Endpoint.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(delivery.Endpoint.Secret, time.Now(), body))
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// Drain (a bounded part of) the body, so that the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// Sign returns the signature header value for body, signed at t.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + signature(secret, ts, body)
+}
+
+func signature(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ErrInvalidSignature is returned by Verify when the signature does not match.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Verify checks the signature header value of a received webhook.
+// If tolerance is positive, signatures older (or newer) than tolerance are rejected to prevent replay.
+func Verify(secret string, header string, body []byte, tolerance time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+	if ts == "" || sig == "" {
+		return fmt.Errorf("%w: malformed header", ErrInvalidSignature)
+	}
+	if tolerance > 0 {
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: malformed timestamp", ErrInvalidSignature)
+		}
+		if d := time.Since(time.Unix(unix, 0)); d > tolerance || d < -tolerance {
+			return fmt.Errorf("%w: timestamp is out of tolerance", ErrInvalidSignature)
+		}
+	}
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ErrQueueClosed is returned by the methods of a closed MemoryQueue.
+var ErrQueueClosed = errors.New("webhook queue is closed")
+
+// MemoryQueue is an in-memory Queue. Pending deliveries are lost when the process exits.
+type MemoryQueue struct {
+	ch        chan *Delivery
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+var _ Queue = (*MemoryQueue)(nil)
+
+// NewMemoryQueue creates a MemoryQueue.
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{ch: make(chan *Delivery, 64), closed: make(chan struct{})}
+}
+
+// Enqueue implements Queue. Deliveries that are not yet due are held by a timer,
+// and dropped if ctx is done or the queue is closed by the time they are due.
+func (q *MemoryQueue) Enqueue(ctx context.Context, d *Delivery) error {
+	delay := time.Until(d.NextAttempt)
+	if delay <= 0 {
+		select {
+		case q.ch <- d:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-q.closed:
+			return ErrQueueClosed
+		}
+	}
+
+	time.AfterFunc(delay, func() {
+		select {
+		case q.ch <- d:
+		case <-ctx.Done():
+		case <-q.closed:
+		}
+	})
+	return nil
+}
+
+// Dequeue implements Queue.
+func (q *MemoryQueue) Dequeue(ctx context.Context) (*Delivery, error) {
+	select {
+	case d := <-q.ch:
+		return d, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-q.closed:
+		return nil, ErrQueueClosed
+	}
+}
+
+// Close closes the queue, releasing the deliveries held by timers.
+func (q *MemoryQueue) Close() error {
+	q.closeOnce.Do(func() { close(q.closed) })
+	return nil
+}
+
+// MemoryLog is an in-memory DeliveryLog.
+type MemoryLog struct {
+	mu       sync.Mutex
+	attempts []Attempt
+}
+
+var _ DeliveryLog = (*MemoryLog)(nil)
+
+// Record implements DeliveryLog.
+func (l *MemoryLog) Record(ctx context.Context, a Attempt) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.attempts = append(l.attempts, a)
+	return nil
+}
+
+// Attempts returns the recorded attempts.
+func (l *MemoryLog) Attempts() []Attempt {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Attempt(nil), l.attempts...)
+}
@@ -0,0 +1,168 @@
+package rakudawebhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDispatcher(t *testing.T) {
+	const secret = "s3cret"
+
+	var calls atomic.Int32
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify(secret, r.Header.Get(SignatureHeader), body, time.Minute); err != nil {
+			t.Errorf("Verify() failed: %v", err)
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable) // fail the first attempt
+			return
+		}
+		received <- r.Header.Get(EventHeader) + " " + string(body)
+	}))
+	defer server.Close()
+
+	log := &MemoryLog{}
+	d := NewDispatcher(&Config{
+		Log:     log,
+		Backoff: func(int) time.Duration { return time.Millisecond },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+
+	event, err := NewEvent("user.created", map[string]string{"name": "foo"})
+	if err != nil {
+		t.Fatalf("NewEvent() failed: %v", err)
+	}
+	if err := d.Dispatch(ctx, Endpoint{URL: server.URL, Secret: secret}, event); err != nil {
+		t.Fatalf("Dispatch() failed: %v", err)
+	}
+
+	select {
+	case got := <-received:
+		if want := `user.created {"name":"foo"}`; got != want {
+			t.Errorf("received: got %q, want %q", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for delivery")
+	}
+
+	// Wait for the successful attempt to be recorded before stopping the dispatcher.
+	for deadline := time.Now().Add(5 * time.Second); len(log.Attempts()) < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() returned an error: %v", err)
+	}
+
+	type summary struct {
+		Attempt int
+		Status  int
+		Failed  bool
+	}
+	var got []summary
+	for _, a := range log.Attempts() {
+		got = append(got, summary{Attempt: a.Attempt, Status: a.Status, Failed: a.Error != ""})
+	}
+	want := []summary{
+		{Attempt: 1, Status: http.StatusServiceUnavailable, Failed: true},
+		{Attempt: 2, Status: http.StatusOK, Failed: false},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("attempts mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"ok":true}`)
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		header  string
+		body    []byte
+		wantErr bool
+	}{
+		{"valid", Sign("secret", now, body), body, false},
+		{"wrong secret", Sign("other", now, body), body, true},
+		{"tampered body", Sign("secret", now, body), []byte(`{"ok":false}`), true},
+		{"too old", Sign("secret", now.Add(-time.Hour), body), body, true},
+		{"malformed", "v1=abc", body, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify("secret", tt.header, tt.body, 5*time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("expected ErrInvalidSignature, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(time.Second, 5*time.Second)
+	var got []time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		got = append(got, backoff(attempt))
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("backoff mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMemoryQueue(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	if err := q.Enqueue(ctx, &Delivery{ID: "later", NextAttempt: time.Now().Add(10 * time.Millisecond)}); err != nil {
+		t.Fatalf("Enqueue() failed: %v", err)
+	}
+	if err := q.Enqueue(ctx, &Delivery{ID: "now"}); err != nil {
+		t.Fatalf("Enqueue() failed: %v", err)
+	}
+	var got []string
+	for range 2 {
+		d, err := q.Dequeue(ctx)
+		if err != nil {
+			t.Fatalf("Dequeue() failed: %v", err)
+		}
+		got = append(got, d.ID)
+	}
+	if diff := cmp.Diff([]string{"now", "later"}, got); diff != "" {
+		t.Errorf("deliveries mismatch (-want +got):\n%s", diff)
+	}
+
+	t.Run("closed", func(t *testing.T) {
+		q := NewMemoryQueue()
+		for range cap(q.ch) {
+			if err := q.Enqueue(ctx, &Delivery{}); err != nil {
+				t.Fatalf("Enqueue() failed: %v", err)
+			}
+		}
+		q.Close()
+		if err := q.Enqueue(ctx, &Delivery{}); !errors.Is(err, ErrQueueClosed) {
+			t.Errorf("Enqueue() on a full, closed queue: got %v, want ErrQueueClosed", err)
+		}
+		// A held delivery must not block its timer forever once the queue is closed.
+		if err := q.Enqueue(ctx, &Delivery{NextAttempt: time.Now().Add(time.Millisecond)}); err != nil {
+			t.Errorf("Enqueue() failed: %v", err)
+		}
+	})
+}
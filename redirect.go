@@ -0,0 +1,69 @@
+package rakuda
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redirect registers a route that redirects requests for the pattern from to the URL to,
+// with the status code (e.g., http.StatusMovedPermanently), so that moved endpoints need
+// no handler of their own. The route matches all methods; use 307 or 308 to keep the
+// method and body of non-GET requests.
+//
+// Wildcards of from can be used in to, and the query string of the request is kept
+// unless to has one:
+//
+//	b.Redirect("/old/users/{id}", "/users/{id}", http.StatusMovedPermanently)
+//	// GET /old/users/1?tab=posts -> 301 Location: /users/1?tab=posts
+//
+// It panics if code is not a 3xx status or if to uses a wildcard that from does not have.
+func (b *Builder) Redirect(from, to string, code int, meta ...Meta) {
+	source := callerSource(2)
+	if code < 300 || code > 399 {
+		panic(fmt.Sprintf("rakuda: Redirect from %q at %s has the status %d, which is not a redirect", from, source, code))
+	}
+	for _, m := range wildcardPattern.FindAllStringSubmatch(to, -1) {
+		if !strings.Contains(from, "{"+m[1]+"}") && !strings.Contains(from, "{"+m[1]+"...}") {
+			panic(fmt.Sprintf("rakuda: Redirect to %q at %s uses {%s}, which %q does not have", to, source, m[1], from))
+		}
+	}
+	h := &redirectHandler{to: to, code: code, rest: map[string]bool{}}
+	for _, m := range wildcardPattern.FindAllStringSubmatch(from, -1) {
+		h.rest[m[1]] = m[2] != ""
+	}
+	b.addHandler("", from, h, source, meta...)
+}
+
+// wildcardPattern matches the wildcards of a pattern, such as {id} and {path...}.
+var wildcardPattern = regexp.MustCompile(`\{(\w+)(\.\.\.)?\}`)
+
+// redirectHandler is the handler registered by Redirect.
+type redirectHandler struct {
+	to   string
+	code int
+	rest map[string]bool // whether each wildcard of the pattern is a {name...} one
+}
+
+func (h *redirectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target := wildcardPattern.ReplaceAllStringFunc(h.to, func(wildcard string) string {
+		name := wildcardPattern.FindStringSubmatch(wildcard)[1]
+		value := r.PathValue(name)
+		if h.rest[name] {
+			// {name...} spans segments: their slashes are kept, but each is escaped, so that
+			// e.g. an encoded "?" or "#" in the request path does not become a query or fragment.
+			segments := strings.Split(value, "/")
+			for i, segment := range segments {
+				segments[i] = url.PathEscape(segment)
+			}
+			return strings.Join(segments, "/")
+		}
+		return url.PathEscape(value)
+	})
+	if r.URL.RawQuery != "" && !strings.Contains(target, "?") {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, h.code)
+}
@@ -0,0 +1,63 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRedirect(t *testing.T) {
+	b := NewBuilder()
+	b.Redirect("/old", "/new", http.StatusMovedPermanently)
+	b.Redirect("/old/users/{id}", "/users/{id}", http.StatusPermanentRedirect)
+	b.Redirect("/docs/{path...}", "https://docs.example.com/{path}?from=app", http.StatusFound)
+	b.Redirect("/files/{rest...}", "/storage/{rest}", http.StatusMovedPermanently)
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		method       string
+		path         string
+		wantStatus   int
+		wantLocation string
+	}{
+		{http.MethodGet, "/old", http.StatusMovedPermanently, "/new"},
+		{http.MethodGet, "/old?page=2", http.StatusMovedPermanently, "/new?page=2"},
+		{http.MethodPost, "/old/users/a%20b", http.StatusPermanentRedirect, "/users/a%20b"},
+		{http.MethodGet, "/docs/guide/install?x=1", http.StatusFound, "https://docs.example.com/guide/install?from=app"},
+		{http.MethodGet, "/files/a%3Fb?x=1", http.StatusMovedPermanently, "/storage/a%3Fb?x=1"},
+		{http.MethodGet, "/files/dir/a%23b%20c", http.StatusMovedPermanently, "/storage/dir/a%23b%20c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+			if diff := cmp.Diff(tt.wantStatus, rr.Code); diff != "" {
+				t.Errorf("status mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantLocation, rr.Header().Get("Location")); diff != "" {
+				t.Errorf("Location mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		for name, register := range map[string]func(b *Builder){
+			"status":   func(b *Builder) { b.Redirect("/old", "/new", http.StatusOK) },
+			"wildcard": func(b *Builder) { b.Redirect("/old", "/users/{id}", http.StatusFound) },
+		} {
+			t.Run(name, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("expected a panic, got none")
+					}
+				}()
+				register(NewBuilder())
+			})
+		}
+	})
+}
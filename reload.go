@@ -0,0 +1,61 @@
+package rakuda
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// ReloadableHandler is an http.Handler that serves with a built router that can be replaced
+// at runtime, so that plugins or feature flags can add or remove routes without restarting
+// the server. Requests in flight continue against the router they started with.
+//
+//	handler, err := rakuda.NewReloadableHandler(b)
+//	...
+//	next := b.Clone() // b is frozen after Build
+//	next.Get("/beta", betaHandler)
+//	if err := handler.Swap(next); err != nil { ... } // the current router is kept
+//
+// It implements RouteTable with the routes of the current router.
+type ReloadableHandler struct {
+	current atomic.Pointer[reloadableState]
+}
+
+// reloadableState boxes the current handler, because atomic.Pointer needs a concrete type.
+type reloadableState struct {
+	handler http.Handler
+}
+
+var _ RouteTable = (*ReloadableHandler)(nil)
+
+// NewReloadableHandler builds b and returns a ReloadableHandler serving with it.
+func NewReloadableHandler(b *Builder) (*ReloadableHandler, error) {
+	h := &ReloadableHandler{}
+	if err := h.Swap(b); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Swap builds b and atomically replaces the current router with it. If Build fails,
+// the error is returned and the current router keeps serving.
+func (h *ReloadableHandler) Swap(b *Builder) error {
+	handler, err := b.Build()
+	if err != nil {
+		return err
+	}
+	h.current.Store(&reloadableState{handler: handler})
+	return nil
+}
+
+// ServeHTTP serves the request with the current router.
+func (h *ReloadableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.current.Load().handler.ServeHTTP(w, r)
+}
+
+// Routes implements RouteTable with the routes of the current router.
+func (h *ReloadableHandler) Routes() []RouteInfo {
+	if table, ok := h.current.Load().handler.(RouteTable); ok {
+		return table.Routes()
+	}
+	return nil
+}
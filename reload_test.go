@@ -0,0 +1,93 @@
+package rakuda
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReloadableHandler(t *testing.T) {
+	text := func(s string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(s)) })
+	}
+	get := func(h http.Handler, path string) (int, string) {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr.Code, rr.Body.String()
+	}
+
+	b := NewBuilder()
+	b.Get("/users", text("v1"))
+	h, err := NewReloadableHandler(b)
+	if err != nil {
+		t.Fatalf("NewReloadableHandler() failed: %v", err)
+	}
+	if code, body := get(h, "/users"); code != http.StatusOK || body != "v1" {
+		t.Errorf("GET /users: got %d %q", code, body)
+	}
+	if code, _ := get(h, "/beta"); code != http.StatusNotFound {
+		t.Errorf("GET /beta: got %d, want %d", code, http.StatusNotFound)
+	}
+
+	t.Run("swap", func(t *testing.T) {
+		next := b.Clone()
+		next.Get("/beta", text("beta"))
+		if err := h.Swap(next); err != nil {
+			t.Fatalf("Swap() failed: %v", err)
+		}
+		if code, body := get(h, "/beta"); code != http.StatusOK || body != "beta" {
+			t.Errorf("GET /beta: got %d %q", code, body)
+		}
+		if got := len(h.Routes()); got != 2 {
+			t.Errorf("len(Routes()): got %d, want 2", got)
+		}
+	})
+
+	t.Run("failed build keeps the current router", func(t *testing.T) {
+		broken := NewBuilder(WithOnConflict(func(b *Builder, key string) error {
+			return errors.New("conflict")
+		}))
+		broken.Get("/users", text("v2"))
+		broken.Get("/users", text("v2"))
+		if err := h.Swap(broken); err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+		if code, body := get(h, "/beta"); code != http.StatusOK || body != "beta" {
+			t.Errorf("GET /beta: got %d %q", code, body)
+		}
+	})
+
+	t.Run("requests in flight keep the old router", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		slow := NewBuilder()
+		slow.Get("/slow", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			w.Write([]byte("old"))
+		}))
+		h, err := NewReloadableHandler(slow)
+		if err != nil {
+			t.Fatalf("NewReloadableHandler() failed: %v", err)
+		}
+
+		done := make(chan string)
+		go func() {
+			_, body := get(h, "/slow")
+			done <- body
+		}()
+		<-started
+		fast := NewBuilder()
+		fast.Get("/slow", text("new"))
+		if err := h.Swap(fast); err != nil {
+			t.Fatalf("Swap() failed: %v", err)
+		}
+		close(release)
+		if got := <-done; got != "old" {
+			t.Errorf("in-flight request: got %q, want %q", got, "old")
+		}
+		if _, body := get(h, "/slow"); body != "new" {
+			t.Errorf("new request: got %q, want %q", body, "new")
+		}
+	})
+}
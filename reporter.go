@@ -0,0 +1,22 @@
+package rakuda
+
+import (
+	"context"
+	"net/http"
+)
+
+// ErrorReporter receives server errors, so that error trackers such as Sentry or Bugsnag
+// can be plugged in without wrapping every middleware. It is called by Responder.Error
+// for 5xx responses when set as Responder.Reporter, and for panics recovered by the
+// Recovery middleware in rakudamiddleware when set as its ErrorReporter.
+type ErrorReporter interface {
+	Report(ctx context.Context, err error, req *http.Request)
+}
+
+// ErrorReporterFunc is an adapter to allow the use of ordinary functions as an ErrorReporter.
+type ErrorReporterFunc func(ctx context.Context, err error, req *http.Request)
+
+// Report calls f(ctx, err, req).
+func (f ErrorReporterFunc) Report(ctx context.Context, err error, req *http.Request) {
+	f(ctx, err, req)
+}
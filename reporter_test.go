@@ -0,0 +1,37 @@
+package rakuda
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorReporter(t *testing.T) {
+	var reported []error
+	responder := &Responder{Reporter: ErrorReporterFunc(func(ctx context.Context, err error, req *http.Request) {
+		reported = append(reported, err)
+	})}
+	errDB := errors.New("db down")
+	for _, tt := range []struct {
+		status int
+		err    error
+	}{
+		{http.StatusBadRequest, errors.New("bad input")},
+		{http.StatusInternalServerError, errDB},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		responder.Error(httptest.NewRecorder(), req, tt.status, tt.err)
+	}
+
+	if len(reported) != 1 || reported[0] != errDB {
+		t.Errorf("expected only the 5xx error to be reported, got %v", reported)
+	}
+
+	// Other responders are not affected.
+	NewResponder().Error(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), http.StatusInternalServerError, errDB)
+	if len(reported) != 1 {
+		t.Errorf("expected no report from a responder without a Reporter, got %v", reported)
+	}
+}
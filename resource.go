@@ -0,0 +1,73 @@
+package rakuda
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Resource is a set of handlers for the conventional REST routes of a resource.
+// Nil handlers are not registered.
+type Resource struct {
+	// Index handles GET {pattern} (list).
+	Index http.Handler
+	// Show handles GET {pattern}/{id}.
+	Show http.Handler
+	// Create handles POST {pattern}.
+	Create http.Handler
+	// Update handles PUT {pattern}/{id} and PATCH {pattern}/{id}.
+	Update http.Handler
+	// Delete handles DELETE {pattern}/{id}.
+	Delete http.Handler
+
+	// IDParam is the name of the path parameter identifying a single item.
+	// Default is "id".
+	IDParam string
+}
+
+// Resource registers the conventional REST routes for the given resource:
+//
+//	GET    /users        -> Index
+//	POST   /users        -> Create
+//	GET    /users/{id}   -> Show
+//	PUT    /users/{id}   -> Update
+//	PATCH  /users/{id}   -> Update
+//	DELETE /users/{id}   -> Delete
+func (b *Builder) Resource(pattern string, res Resource) {
+	source := callerSource(2)
+	for _, r := range resourceRoutes(pattern, res) {
+		b.addHandler(r.method, r.pattern, r.handler, source)
+	}
+}
+
+// resourceRoute is a single route derived from a Resource.
+type resourceRoute struct {
+	method  string
+	pattern string
+	handler http.Handler
+}
+
+// resourceRoutes returns the routes for the non-nil handlers of the resource.
+func resourceRoutes(pattern string, res Resource) []resourceRoute {
+	idParam := res.IDParam
+	if idParam == "" {
+		idParam = "id"
+	}
+	collection := "/" + strings.Trim(pattern, "/")
+	item := strings.TrimSuffix(collection, "/") + "/{" + idParam + "}"
+
+	candidates := []resourceRoute{
+		{http.MethodGet, collection, res.Index},
+		{http.MethodPost, collection, res.Create},
+		{http.MethodGet, item, res.Show},
+		{http.MethodPut, item, res.Update},
+		{http.MethodPatch, item, res.Update},
+		{http.MethodDelete, item, res.Delete},
+	}
+	routes := make([]resourceRoute, 0, len(candidates))
+	for _, r := range candidates {
+		if r.handler != nil {
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
@@ -0,0 +1,81 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResource(t *testing.T) {
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name + ":" + r.PathValue("id")))
+		})
+	}
+
+	b := NewBuilder()
+	b.Route("/api", func(b *Builder) {
+		b.Resource("/users", Resource{
+			Index:  handler("index"),
+			Show:   handler("show"),
+			Create: handler("create"),
+			Update: handler("update"),
+			Delete: handler("delete"),
+		})
+		b.Resource("/tags", Resource{Index: handler("tags")})
+	})
+
+	t.Run("walk", func(t *testing.T) {
+		var got [][2]string
+		b.Walk(func(method, pattern string) {
+			got = append(got, [2]string{method, pattern})
+		})
+		want := [][2]string{
+			{http.MethodGet, "/api/users"},
+			{http.MethodPost, "/api/users"},
+			{http.MethodGet, "/api/users/{id}"},
+			{http.MethodPut, "/api/users/{id}"},
+			{http.MethodPatch, "/api/users/{id}"},
+			{http.MethodDelete, "/api/users/{id}"},
+			{http.MethodGet, "/api/tags"},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Walk() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		method   string
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{http.MethodGet, "/api/users", http.StatusOK, "index:"},
+		{http.MethodPost, "/api/users", http.StatusOK, "create:"},
+		{http.MethodGet, "/api/users/1", http.StatusOK, "show:1"},
+		{http.MethodPut, "/api/users/1", http.StatusOK, "update:1"},
+		{http.MethodPatch, "/api/users/1", http.StatusOK, "update:1"},
+		{http.MethodDelete, "/api/users/1", http.StatusOK, "delete:1"},
+		{http.MethodGet, "/api/tags/1", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+				t.Errorf("body: got %q, want %q", rr.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
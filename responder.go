@@ -1,18 +1,90 @@
 package rakuda
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"runtime"
+	"strconv"
+	"sync"
 
 	"github.com/podhmo/rakuda/binding"
 )
 
 // Responder handles writing JSON responses.
-type Responder struct{}
+type Responder struct {
+	// Verbose exposes the messages of 5xx errors to the client, e.g., for internal APIs.
+	// By default, they are replaced with a generic message.
+	Verbose bool
+	// Pretty indents all JSON responses, as the "pretty" query parameter does for a single request.
+	Pretty bool
+	// Strict makes misuses of the Responder panic, so that they fail loudly in development:
+	// responding twice to a request served by a route (e.g., JSON after HTML), a body with a status that
+	// does not allow one (1xx, 204, 304), and a status argument that disagrees with the
+	// StatusCode method of the data. By default, they are written as requested.
+	Strict bool
+	// Buffered encodes JSON responses into a pooled buffer before writing them, so that
+	// JSON and HTML responses carry Content-Length, and a JSON encoding error is answered
+	// with 500 instead of a truncated body. See NewContextWithChunked for streaming responses.
+	Buffered bool
+	// Reporter receives the errors of 5xx responses written by Error, e.g., to send them
+	// to an error tracker. By default, errors are not reported.
+	Reporter ErrorReporter
+	// FlashSecret is the key that signs the flash cookie set by RedirectWithFlash.
+	// It is required to send flash messages.
+	FlashSecret []byte
+	// FlashSecureCookie marks the flash cookie Secure even for requests not received over
+	// TLS, e.g., behind a TLS-terminating proxy.
+	FlashSecureCookie bool
+}
+
+// responseState records the Responder method that responded to a request, so that a
+// strict Responder can detect responding twice. It is stored in the context of each
+// request served by a route (see withResponseState).
+type responseState struct {
+	mu     sync.Mutex
+	method string
+}
+
+// withResponseState returns a handler that stores a fresh responseState in the request context.
+func withResponseState(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), responseStateKey, &responseState{})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// assert panics if the response is a misuse of the Responder. It is a no-op unless Strict is set.
+func (r *Responder) assert(w http.ResponseWriter, req *http.Request, method string, statusCode int, hasBody bool) {
+	if !r.Strict {
+		return
+	}
+	if hasBody && !bodyAllowedForStatus(statusCode) {
+		panic(fmt.Sprintf("rakuda: Responder.%s writes a body with status %d, which does not allow one (%s %s)", method, statusCode, req.Method, req.URL.Path))
+	}
+	state, ok := req.Context().Value(responseStateKey).(*responseState)
+	if !ok {
+		return
+	}
+	state.mu.Lock()
+	prev := state.method
+	if prev == "" {
+		state.method = method
+	}
+	state.mu.Unlock()
+	if prev != "" {
+		panic(fmt.Sprintf("rakuda: Responder.%s is called after Responder.%s for the same response (%s %s)", method, prev, req.Method, req.URL.Path))
+	}
+}
+
+// bodyAllowedForStatus reports whether a response with the status can have a body (RFC 9110).
+func bodyAllowedForStatus(statusCode int) bool {
+	return statusCode >= http.StatusOK && statusCode != http.StatusNoContent && statusCode != http.StatusNotModified
+}
 
 // NewResponder creates a new Responder.
 func NewResponder() *Responder {
@@ -23,8 +95,10 @@ func NewResponder() *Responder {
 // It logs errors only under specific conditions:
 // - If the status code is >= 500.
 // - If the logger's level is Debug or lower.
-// For 5xx errors, it sends a generic message to the client.
+// For 5xx errors, it sends a generic message to the client (unless Verbose is set) and
+// reports the error to the Reporter, if any.
 func (r *Responder) Error(w http.ResponseWriter, req *http.Request, statusCode int, err error) {
+	r.assert(w, req, "Error", statusCode, true)
 	ctx := req.Context()
 	logger := LoggerFromContext(ctx)
 
@@ -51,54 +125,197 @@ func (r *Responder) Error(w http.ResponseWriter, req *http.Request, statusCode i
 		}
 		logger.LogAttrs(ctx, slog.LevelError, err.Error(), attrs...)
 	}
+	if statusCode >= http.StatusInternalServerError && r.Reporter != nil {
+		r.Reporter.Report(ctx, err, req)
+	}
 
 	var vErrs *binding.ValidationErrors
 	if errors.As(err, &vErrs) {
-		r.JSON(w, req, statusCode, vErrs)
+		r.writeJSON(w, req, statusCode, vErrs)
 		return
 	}
 
+	var pErr *PreconditionFailedError
+	if errors.As(err, &pErr) {
+		r.writeJSON(w, req, statusCode, pErr)
+		return
+	}
+
+	var mtErr *UnsupportedMediaTypeError
+	if errors.As(err, &mtErr) {
+		r.writeJSON(w, req, statusCode, mtErr)
+		return
+	}
+
+	var naErr *NotAcceptableError
+	if errors.As(err, &naErr) {
+		r.writeJSON(w, req, statusCode, naErr)
+		return
+	}
+
+	if statusCode < http.StatusInternalServerError {
+		// Multiple errors (rakuda.Errors or errors.Join) are rendered as a list.
+		var errs *Errors
+		if errors.As(err, &errs) {
+			r.writeJSON(w, req, statusCode, errs)
+			return
+		}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			r.writeJSON(w, req, statusCode, errorList(joined.Unwrap()))
+			return
+		}
+	}
+
 	errMsg := err.Error()
-	if statusCode >= http.StatusInternalServerError {
+	if statusCode >= http.StatusInternalServerError && !r.Verbose {
 		// Do not expose internal error details to the client
 		errMsg = "Internal Server Error"
 	}
 
-	r.JSON(w, req, statusCode, map[string]string{"error": errMsg})
+	r.writeJSON(w, req, statusCode, map[string]string{"error": errMsg})
+}
+
+// NewContextWithResponder returns a new context with the provided Responder.
+// Lift uses it instead of its own responder. See Builder.UseResponder.
+func NewContextWithResponder(ctx context.Context, responder *Responder) context.Context {
+	return context.WithValue(ctx, responderKey, responder)
+}
+
+// ResponderFromContext returns the Responder set with NewContextWithResponder, if any.
+func ResponderFromContext(ctx context.Context) (*Responder, bool) {
+	responder, ok := ctx.Value(responderKey).(*Responder)
+	return responder, ok
+}
+
+// UseResponder makes the routes of the builder's node, including those of its groups,
+// respond with responder: Lift uses it instead of the responder it was created with,
+// so that, e.g., an /internal group can return verbose errors while others are redacted.
+//
+//	b.Route("/internal", func(b *rakuda.Builder) {
+//		b.UseResponder(&rakuda.Responder{Verbose: true})
+//		b.Get("/jobs", rakuda.Lift(responder, listJobs))
+//	})
+//
+// Like Use, it adds a middleware, which sets the responder in the request context.
+func (b *Builder) UseResponder(responder *Responder) {
+	source := callerSource(2)
+	b.checkFrozen(source)
+	b.node.actions = append(b.node.actions, middlewareAction{
+		middleware: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(NewContextWithResponder(r.Context(), responder)))
+			})
+		},
+		source: source,
+	})
+}
+
+// Warn surfaces binding warnings that do not fail the request.
+// Each warning is logged at warn level and added as a "Warning" response header
+// (code 299, RFC 7234). If any warning is about a deprecated parameter
+// (see binding.Deprecated), the "Deprecation: true" header is also set.
+// It must be called before the response is written.
+func (r *Responder) Warn(w http.ResponseWriter, req *http.Request, warnings ...*binding.Error) {
+	ctx := req.Context()
+	logger := LoggerFromContext(ctx)
+
+	for _, warning := range warnings {
+		msg := fmt.Sprintf("%s %s: %v", warning.Source, warning.Key, warning.Err)
+		logger.WarnContext(ctx, "binding warning",
+			"source", warning.Source,
+			"key", warning.Key,
+			"value", warning.Value,
+			"error", warning.Err,
+		)
+		w.Header().Add("Warning", fmt.Sprintf("299 - %q", msg))
+		if errors.Is(warning.Err, binding.ErrDeprecated) {
+			w.Header().Set("Deprecation", "true")
+		}
+	}
 }
 
 // JSON marshals the 'data' payload to JSON and writes it to the response.
 func (r *Responder) JSON(w http.ResponseWriter, req *http.Request, statusCode int, data any) {
+	r.assert(w, req, "JSON", statusCode, data != nil)
+	if sc, ok := data.(interface{ StatusCode() int }); ok && r.Strict && sc.StatusCode() != statusCode {
+		panic(fmt.Sprintf("rakuda: Responder.JSON is called with status %d, but the data has status %d (%s %s)", statusCode, sc.StatusCode(), req.Method, req.URL.Path))
+	}
+	r.writeJSON(w, req, statusCode, data)
+}
+
+func (r *Responder) writeJSON(w http.ResponseWriter, req *http.Request, statusCode int, data any) {
 	ctx := req.Context()
 
 	if err := ctx.Err(); err != nil {
 		return // Client disconnected
 	}
 
+	// Partial responses: keep only the selected fields of successful responses.
+	if fields := fieldsFromContext(ctx); len(fields) > 0 && data != nil && statusCode < http.StatusMultipleChoices {
+		filtered, err := FilterFields(data, fields)
+		if err != nil {
+			logger := LoggerFromContext(ctx)
+			logger.ErrorContext(ctx, "failed to filter json response fields", "error", err)
+		} else {
+			data = filtered
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(statusCode)
+	// Easter egg: if the querystring includes "pretty", indent the JSON output.
+	_, pretty := req.URL.Query()["pretty"]
+	pretty = pretty || r.Pretty
+
+	chunked := chunkedFromContext(ctx)
+	if r.Buffered && !chunked && data != nil {
+		buf := getBuffer()
+		defer putBuffer(buf)
+		if err := encodeJSON(buf, data, pretty); err != nil {
+			logger := LoggerFromContext(ctx)
+			logger.ErrorContext(ctx, "failed to encode json response", "error", err)
+			statusCode = http.StatusInternalServerError
+			buf.Reset()
+			buf.WriteString(`{"error":"Internal Server Error"}` + "\n")
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+		w.WriteHeader(statusCode)
+		if _, err := buf.WriteTo(w); err != nil {
+			logger := LoggerFromContext(ctx)
+			logger.ErrorContext(ctx, "failed to write json response", "error", err)
+		}
+		return
+	}
 
+	w.WriteHeader(statusCode)
+	if chunked {
+		flushHeader(w)
+	}
 	if data != nil {
-		enc := json.NewEncoder(w)
-		// Easter egg: if the querystring includes "pretty", indent the JSON output.
-		if _, ok := req.URL.Query()["pretty"]; ok {
-			enc.SetIndent("", "  ")
-		}
-		if err := enc.Encode(data); err != nil {
+		if err := encodeJSON(w, data, pretty); err != nil {
 			logger := LoggerFromContext(ctx)
 			logger.ErrorContext(ctx, "failed to encode json response", "error", err)
 		}
 	}
 }
 
+func encodeJSON(w io.Writer, data any, pretty bool) error {
+	enc := json.NewEncoder(w)
+	if pretty {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(data)
+}
+
 // Redirect performs an HTTP redirect.
 func (r *Responder) Redirect(w http.ResponseWriter, req *http.Request, url string, code int) {
+	r.assert(w, req, "Redirect", code, false)
 	http.Redirect(w, req, url, code)
 }
 
 // HTML sends an HTML response to the client. This method is intended for use in
 // standard http.Handlers, not with Lift, which is designed for JSON APIs.
 func (r *Responder) HTML(w http.ResponseWriter, req *http.Request, code int, html []byte) {
+	r.assert(w, req, "HTML", code, len(html) > 0)
 	ctx := req.Context()
 
 	if err := ctx.Err(); err != nil {
@@ -106,7 +323,14 @@ func (r *Responder) HTML(w http.ResponseWriter, req *http.Request, code int, htm
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	chunked := chunkedFromContext(ctx)
+	if r.Buffered && !chunked && len(html) > 0 {
+		w.Header().Set("Content-Length", strconv.Itoa(len(html)))
+	}
 	w.WriteHeader(code)
+	if chunked {
+		flushHeader(w)
+	}
 	if _, err := w.Write(html); err != nil {
 		logger := LoggerFromContext(ctx)
 		logger.ErrorContext(ctx, "failed to write html response", "error", err)
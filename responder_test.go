@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -12,6 +14,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda/binding"
 )
 
 func TestResponder_HTML(t *testing.T) {
@@ -432,3 +435,194 @@ func TestResponder_JSON(t *testing.T) {
 		})
 	}
 }
+
+func TestResponder_Warn(t *testing.T) {
+	handler := &testHandler{level: slog.LevelInfo}
+	req := httptest.NewRequest(http.MethodGet, "/?limit=10", nil)
+	req = req.WithContext(NewContextWithLogger(req.Context(), slog.New(handler)))
+	rr := httptest.NewRecorder()
+
+	b := binding.New(req, nil)
+	b.Warn(binding.Query, "limit", "10", errors.New("use page_size instead"))
+
+	r := NewResponder()
+	r.Warn(rr, req, b.Warnings()...)
+	r.JSON(rr, req, http.StatusOK, map[string]string{"ok": "true"})
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	want := []string{`299 - "query limit: use page_size instead"`}
+	if diff := cmp.Diff(want, rr.Header().Values("Warning")); diff != "" {
+		t.Errorf("Warning header mismatch (-want +got):\n%s", diff)
+	}
+	if handler.record == nil || handler.record.Level != slog.LevelWarn {
+		t.Errorf("expected a warn log record, got %v", handler.record)
+	}
+	if got := rr.Header().Get("Deprecation"); got != "" {
+		t.Errorf("expected no Deprecation header, got %q", got)
+	}
+}
+
+func TestResponder_Warn_Deprecated(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?limit=10", nil)
+	rr := httptest.NewRecorder()
+
+	b := binding.New(req, nil)
+	binding.Deprecated(b, binding.Query, "limit", "use page_size instead")
+	NewResponder().Warn(rr, req, b.Warnings()...)
+
+	if got, want := rr.Header().Get("Deprecation"), "true"; got != want {
+		t.Errorf("Deprecation header: got %q, want %q", got, want)
+	}
+}
+
+func TestUseResponder(t *testing.T) {
+	failing := Lift(NewResponder(), func(r *http.Request) (map[string]string, error) {
+		return nil, errors.New("database is down")
+	})
+
+	b := NewBuilder(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	b.Route("/internal", func(b *Builder) {
+		b.UseResponder(&Responder{Verbose: true})
+		b.Get("/jobs", failing)
+		b.Route("/admin", func(b *Builder) {
+			b.Get("/jobs", failing)
+		})
+	})
+	b.Route("/public", func(b *Builder) {
+		b.Get("/jobs", failing)
+	})
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/internal/jobs", want: `{"error":"database is down"}` + "\n"},
+		{path: "/internal/admin/jobs", want: `{"error":"database is down"}` + "\n"},
+		{path: "/public/jobs", want: `{"error":"Internal Server Error"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if got, want := rr.Code, http.StatusInternalServerError; got != want {
+				t.Errorf("status code: got %d, want %d", got, want)
+			}
+			if diff := cmp.Diff(tt.want, rr.Body.String()); diff != "" {
+				t.Errorf("body mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+type createdResponse struct {
+	ID int `json:"id"`
+}
+
+func (createdResponse) StatusCode() int { return http.StatusCreated }
+
+func TestResponder_Strict(t *testing.T) {
+	tests := []struct {
+		name      string
+		respond   func(responder *Responder, w http.ResponseWriter, req *http.Request)
+		wantPanic string // a substring of the panic message; empty if no panic is expected
+	}{
+		{
+			name: "once",
+			respond: func(responder *Responder, w http.ResponseWriter, req *http.Request) {
+				responder.JSON(w, req, http.StatusCreated, createdResponse{ID: 1})
+			},
+		},
+		{
+			name: "no content without body",
+			respond: func(responder *Responder, w http.ResponseWriter, req *http.Request) {
+				responder.JSON(w, req, http.StatusNoContent, nil)
+			},
+		},
+		{
+			name: "json after html",
+			respond: func(responder *Responder, w http.ResponseWriter, req *http.Request) {
+				responder.HTML(w, req, http.StatusOK, []byte("<p>ok</p>"))
+				responder.JSON(w, req, http.StatusOK, map[string]int{"id": 1})
+			},
+			wantPanic: "Responder.JSON is called after Responder.HTML",
+		},
+		{
+			name: "error after json",
+			respond: func(responder *Responder, w http.ResponseWriter, req *http.Request) {
+				responder.JSON(w, req, http.StatusOK, map[string]int{"id": 1})
+				responder.Error(w, req, http.StatusBadRequest, errors.New("bad"))
+			},
+			wantPanic: "Responder.Error is called after Responder.JSON",
+		},
+		{
+			name: "body on 204",
+			respond: func(responder *Responder, w http.ResponseWriter, req *http.Request) {
+				responder.JSON(w, req, http.StatusNoContent, map[string]int{"id": 1})
+			},
+			wantPanic: "with status 204",
+		},
+		{
+			name: "body on 304",
+			respond: func(responder *Responder, w http.ResponseWriter, req *http.Request) {
+				responder.HTML(w, req, http.StatusNotModified, []byte("<p>ok</p>"))
+			},
+			wantPanic: "with status 304",
+		},
+		{
+			name: "conflicting status",
+			respond: func(responder *Responder, w http.ResponseWriter, req *http.Request) {
+				responder.JSON(w, req, http.StatusOK, createdResponse{ID: 1})
+			},
+			wantPanic: "called with status 200, but the data has status 201",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := func() (msg string) {
+				defer func() {
+					if r := recover(); r != nil {
+						msg = fmt.Sprint(r)
+					}
+				}()
+				responder := &Responder{Strict: true}
+				withResponseState(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					tt.respond(responder, w, r)
+				})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+				return ""
+			}()
+			if tt.wantPanic == "" && got != "" {
+				t.Errorf("unexpected panic: %s", got)
+			}
+			if !strings.Contains(got, tt.wantPanic) {
+				t.Errorf("panic %q does not contain %q", got, tt.wantPanic)
+			}
+
+			// Without Strict, the misuses are written as requested.
+			tt.respond(NewResponder(), httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+	}
+
+	t.Run("state is per request", func(t *testing.T) {
+		responder := &Responder{Strict: true}
+		b := NewBuilder()
+		b.Get("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			responder.JSON(w, r, http.StatusOK, map[string]int{"id": 1})
+		}))
+		router, err := b.Build()
+		if err != nil {
+			t.Fatalf("b.Build() failed: %v", err)
+		}
+		// The same writer serving two requests is not responding twice.
+		rr := httptest.NewRecorder()
+		for range 2 {
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+	})
+}
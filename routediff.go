@@ -0,0 +1,134 @@
+package rakuda
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteChangeKind is the kind of a RouteChange.
+type RouteChangeKind string
+
+// Kinds of route changes.
+const (
+	RouteAdded   RouteChangeKind = "added"
+	RouteRemoved RouteChangeKind = "removed"
+	RouteChanged RouteChangeKind = "changed"
+)
+
+// RouteChange is a difference between two route tables, reported by DiffRoutes.
+type RouteChange struct {
+	Kind    RouteChangeKind
+	Method  string
+	Pattern string
+	// Old is the route before the change. It is nil for added routes.
+	Old *RouteInfo
+	// New is the route after the change. It is nil for removed routes.
+	New *RouteInfo
+	// Details describes what changed, e.g., "handler: pkg.listUsers -> pkg.listUsersV2".
+	// It is empty for added and removed routes.
+	Details []string
+}
+
+// DiffRoutes compares the routes of two builders, e.g., of two releases, so that CI can
+// flag endpoint changes. Routes are identified by method and pattern. A route is changed
+// if its handler, number of middlewares, or metadata differ; registration sources are ignored.
+// Changes are sorted by pattern and method.
+func DiffRoutes(old, new *Builder) []RouteChange {
+	index := func(routes []RouteInfo) map[string]*RouteInfo {
+		m := make(map[string]*RouteInfo, len(routes))
+		for i := range routes {
+			r := &routes[i]
+			if _, ok := m[r.Method+" "+r.Pattern]; !ok { // the first one wins, as in Build
+				m[r.Method+" "+r.Pattern] = r
+			}
+		}
+		return m
+	}
+	oldRoutes := index(old.Routes())
+	newRoutes := index(new.Routes())
+
+	var changes []RouteChange
+	for key, o := range oldRoutes {
+		n, ok := newRoutes[key]
+		if !ok {
+			changes = append(changes, RouteChange{Kind: RouteRemoved, Method: o.Method, Pattern: o.Pattern, Old: o})
+			continue
+		}
+		if details := diffRouteInfo(o, n); len(details) > 0 {
+			changes = append(changes, RouteChange{Kind: RouteChanged, Method: o.Method, Pattern: o.Pattern, Old: o, New: n, Details: details})
+		}
+	}
+	for key, n := range newRoutes {
+		if _, ok := oldRoutes[key]; !ok {
+			changes = append(changes, RouteChange{Kind: RouteAdded, Method: n.Method, Pattern: n.Pattern, New: n})
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b RouteChange) int {
+		if c := strings.Compare(a.Pattern, b.Pattern); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+	return changes
+}
+
+func diffRouteInfo(o, n *RouteInfo) []string {
+	var details []string
+	if o.Handler != n.Handler {
+		details = append(details, fmt.Sprintf("handler: %s -> %s", o.Handler, n.Handler))
+	}
+	if o.Middlewares != n.Middlewares {
+		details = append(details, fmt.Sprintf("middlewares: %d -> %d", o.Middlewares, n.Middlewares))
+	}
+	if o.Meta.Name != n.Meta.Name {
+		details = append(details, fmt.Sprintf("name: %q -> %q", o.Meta.Name, n.Meta.Name))
+	}
+	if o.Meta.Description != n.Meta.Description {
+		details = append(details, fmt.Sprintf("description: %q -> %q", o.Meta.Description, n.Meta.Description))
+	}
+	if !slices.Equal(o.Meta.Tags, n.Meta.Tags) {
+		details = append(details, fmt.Sprintf("tags: %v -> %v", o.Meta.Tags, n.Meta.Tags))
+	}
+	if !slices.Equal(o.Meta.Scopes, n.Meta.Scopes) {
+		details = append(details, fmt.Sprintf("scopes: %v -> %v", o.Meta.Scopes, n.Meta.Scopes))
+	}
+	if !slices.Equal(o.Meta.Consumes, n.Meta.Consumes) {
+		details = append(details, fmt.Sprintf("consumes: %v -> %v", o.Meta.Consumes, n.Meta.Consumes))
+	}
+	if !slices.Equal(o.Meta.Produces, n.Meta.Produces) {
+		details = append(details, fmt.Sprintf("produces: %v -> %v", o.Meta.Produces, n.Meta.Produces))
+	}
+	if !reflect.DeepEqual(o.Meta.Extra, n.Meta.Extra) {
+		details = append(details, fmt.Sprintf("extra: %v -> %v", o.Meta.Extra, n.Meta.Extra))
+	}
+	return details
+}
+
+// PrintRouteChanges prints a report of route changes, one route per line,
+// prefixed with "+" (added), "-" (removed), or "~" (changed), followed by the details.
+//
+//	rakuda.PrintRouteChanges(os.Stdout, rakuda.DiffRoutes(old, new))
+//	// +  POST  /users
+//	// ~  GET   /users/{id}
+//	//            handler: main.getUser -> main.getUserV2
+func PrintRouteChanges(w io.Writer, changes []RouteChange) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+
+	marks := map[RouteChangeKind]string{RouteAdded: "+", RouteRemoved: "-", RouteChanged: "~"}
+	for _, c := range changes {
+		method := c.Method
+		if method == "" {
+			method = "*" // method-agnostic, as in PrintRoutes
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", marks[c.Kind], method, c.Pattern)
+		for _, d := range c.Details {
+			fmt.Fprintf(tw, "\t\t  %s\n", d)
+		}
+	}
+}
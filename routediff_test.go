@@ -0,0 +1,52 @@
+package rakuda
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func getUser(w http.ResponseWriter, r *http.Request)   {}
+func getUserV2(w http.ResponseWriter, r *http.Request) {}
+
+func TestDiffRoutes(t *testing.T) {
+	handler := http.HandlerFunc(healthHandler)
+
+	old := NewBuilder()
+	old.Get("/health", handler)
+	old.Get("/users/{id}", http.HandlerFunc(getUser))
+	old.Delete("/users/{id}", handler)
+
+	new := NewBuilder()
+	new.Get("/health", handler)
+	new.Get("/users/{id}", http.HandlerFunc(getUserV2), Meta{Tags: []string{"users"}})
+	new.Post("/users", handler)
+
+	changes := DiffRoutes(old, new)
+	want := []RouteChange{
+		{Kind: RouteAdded, Method: "POST", Pattern: "/users"},
+		{Kind: RouteRemoved, Method: "DELETE", Pattern: "/users/{id}"},
+		{Kind: RouteChanged, Method: "GET", Pattern: "/users/{id}", Details: []string{
+			"handler: github.com/podhmo/rakuda.getUser -> github.com/podhmo/rakuda.getUserV2",
+			"tags: [] -> [users]",
+		}},
+	}
+	if diff := cmp.Diff(want, changes, cmpopts.IgnoreFields(RouteChange{}, "Old", "New")); diff != "" {
+		t.Errorf("DiffRoutes() mismatch (-want +got):\n%s", diff)
+	}
+
+	var buf bytes.Buffer
+	PrintRouteChanges(&buf, changes)
+	wantReport := `+  POST    /users
+-  DELETE  /users/{id}
+~  GET     /users/{id}
+             handler: github.com/podhmo/rakuda.getUser -> github.com/podhmo/rakuda.getUserV2
+             tags: [] -> [users]
+`
+	if diff := cmp.Diff(wantReport, buf.String()); diff != "" {
+		t.Errorf("PrintRouteChanges() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,94 @@
+package rakuda
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+)
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	// Method is the HTTP method, or empty for method-agnostic routes (see Handle and Mount).
+	Method string
+	// Pattern is the full pattern, including the prefixes of the enclosing groups
+	// and the host of an enclosing Host group (e.g., "api.example.com/users/{id}").
+	Pattern string
+	// Prefix is the path prefix of the enclosing groups ("/" for top-level routes).
+	Prefix string
+	// Middlewares is the number of middleware layers wrapping the handler.
+	Middlewares int
+	// Chain describes the middleware layers, outermost first, as "name (file:line)".
+	Chain []string
+	// Handler identifies the handler: the function name for http.HandlerFunc, or the type name.
+	Handler string
+	// Meta is the metadata attached at registration.
+	Meta Meta
+	// Source is the registration location (file:line).
+	Source string
+	// AliasOf is the full pattern of the route this one is an alias of (see Builder.Alias), or empty.
+	AliasOf string
+}
+
+// RouteTable is implemented by the handler returned by Build, so that code holding only
+// the http.Handler, such as operational tooling, can enumerate the route table:
+//
+//	if rt, ok := handler.(rakuda.RouteTable); ok {
+//		for _, r := range rt.Routes() { ... }
+//	}
+type RouteTable interface {
+	http.Handler
+	// Routes returns a snapshot of the registered routes, in registration (DFS) order.
+	// Routes skipped as conflicts are not included.
+	Routes() []RouteInfo
+}
+
+var _ RouteTable = (*router)(nil)
+
+// Routes implements RouteTable. It returns a copy, so the route table cannot be modified.
+func (rt *router) Routes() []RouteInfo {
+	routes := make([]RouteInfo, len(rt.routes))
+	for i, r := range rt.routes {
+		r.Chain = slices.Clone(r.Chain)
+		r.Meta = r.Meta.clone()
+		routes[i] = r
+	}
+	return routes
+}
+
+// info returns the RouteInfo of the route.
+func (rt route) info() RouteInfo {
+	return RouteInfo{
+		Method:      rt.method,
+		Pattern:     rt.host + rt.pattern,
+		Prefix:      rt.prefix,
+		Middlewares: len(rt.middlewares),
+		Chain:       rt.describe(),
+		Handler:     funcName(rt.handler),
+		Meta:        rt.meta.clone(),
+		Source:      rt.source,
+		AliasOf:     rt.aliasOf,
+	}
+}
+
+// Routes returns the registered routes as a machine-readable table, in DFS order,
+// e.g., to diff the routes of two versions or to export them.
+// Unlike RouteTable.Routes of the built handler, it includes routes that Build skips as conflicts.
+func (b *Builder) Routes() []RouteInfo {
+	var routes []RouteInfo
+	_ = b.walk(func(rt route) error {
+		routes = append(routes, rt.info())
+		return nil
+	})
+	return routes
+}
+
+// clone returns a copy of m that does not share slices and maps with it.
+// The values of Extra are copied shallowly.
+func (m Meta) clone() Meta {
+	m.Tags = slices.Clone(m.Tags)
+	m.Scopes = slices.Clone(m.Scopes)
+	m.Consumes = slices.Clone(m.Consumes)
+	m.Produces = slices.Clone(m.Produces)
+	m.Extra = maps.Clone(m.Extra)
+	return m
+}
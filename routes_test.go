@@ -0,0 +1,71 @@
+package rakuda
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestRouteTable(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	b := NewBuilder()
+	b.Get("/health", handler, Meta{Tags: []string{"public"}})
+	b.Route("/users", func(b *Builder) {
+		b.Get("/{id}", handler)
+	})
+	b.Handle("/legacy", handler)
+	h, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	rt, ok := h.(RouteTable)
+	if !ok {
+		t.Fatalf("expected the built handler to implement RouteTable, got %T", h)
+	}
+	routes := rt.Routes()
+	want := []RouteInfo{
+		{Method: "GET", Pattern: "/health", Meta: Meta{Tags: []string{"public"}}},
+		{Method: "", Pattern: "/legacy"},
+		{Method: "GET", Pattern: "/users/{id}"},
+	}
+	if diff := cmp.Diff(want, routes, cmpopts.IgnoreFields(RouteInfo{}, "Source", "Prefix", "Middlewares", "Chain", "Handler")); diff != "" {
+		t.Errorf("Routes() mismatch (-want +got):\n%s", diff)
+	}
+	if routes[0].Source == "" {
+		t.Error("expected the registration source to be set")
+	}
+
+	// The snapshot cannot be modified through the returned value.
+	routes[0].Meta.Tags[0] = "modified"
+	if got := rt.Routes()[0].Meta.Tags[0]; got != "public" {
+		t.Errorf("expected the route table to be unchanged, got tag %q", got)
+	}
+}
+
+func TestBuilder_Routes(t *testing.T) {
+	mw := func(next http.Handler) http.Handler { return next }
+
+	b := NewBuilder()
+	b.Use(mw)
+	b.Get("/health", http.HandlerFunc(healthHandler))
+	b.Route("/api", func(b *Builder) {
+		b.Use(mw)
+		b.Route("/users", func(b *Builder) {
+			b.Post("/", http.RedirectHandler("/", http.StatusFound))
+		})
+	})
+
+	want := []RouteInfo{
+		{Method: "GET", Pattern: "/health", Prefix: "/", Middlewares: 1, Handler: "github.com/podhmo/rakuda.healthHandler"},
+		{Method: "POST", Pattern: "/api/users/{$}", Prefix: "/api/users", Middlewares: 2, Handler: "*http.redirectHandler"},
+	}
+	if diff := cmp.Diff(want, b.Routes(), cmpopts.IgnoreFields(RouteInfo{}, "Source", "Chain")); diff != "" {
+		t.Errorf("Routes() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func healthHandler(w http.ResponseWriter, r *http.Request) {}
@@ -0,0 +1,24 @@
+package rakuda
+
+import "net/http"
+
+// FromServeMux creates a Builder that serves the routes of an existing http.ServeMux,
+// to ease migrating a legacy mux-based service into the builder model.
+// See Builder.AbsorbMux.
+func FromServeMux(mux *http.ServeMux, options ...func(*BuilderConfig)) *Builder {
+	b := NewBuilder(options...)
+	b.AbsorbMux(mux)
+	return b
+}
+
+// AbsorbMux mounts an existing http.ServeMux as a fallback: requests that match no route
+// of the builder are served by the mux if one of its patterns matches, and by the
+// NotFound handler otherwise. Routes registered on the builder take precedence, so that
+// they can be moved over one at a time.
+//
+// The patterns of a ServeMux cannot be introspected, so they do not appear in Walk
+// or PrintRoutes, and only the middlewares of the root builder are applied to them.
+func (b *Builder) AbsorbMux(mux *http.ServeMux) {
+	b.checkFrozen(callerSource(2))
+	b.state.fallbacks = append(b.state.fallbacks, mux)
+}
@@ -0,0 +1,61 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromServeMux(t *testing.T) {
+	legacy := http.NewServeMux()
+	legacy.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("legacy user " + r.PathValue("id")))
+	})
+	legacy.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("legacy health"))
+	})
+
+	var middlewareCalls int
+	b := FromServeMux(legacy)
+	b.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			middlewareCalls++
+			next.ServeHTTP(w, r)
+		})
+	})
+	// migrated route takes precedence
+	b.Get("/health", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("new health"))
+	}))
+
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{"/health", http.StatusOK, "new health"},
+		{"/users/1", http.StatusOK, "legacy user 1"},
+		{"/missing", http.StatusNotFound, `{"error":"not found"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			middlewareCalls = 0
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rr.Code != tt.wantCode {
+				t.Errorf("status code: got %d, want %d", rr.Code, tt.wantCode)
+			}
+			if rr.Body.String() != tt.wantBody {
+				t.Errorf("body: got %q, want %q", rr.Body.String(), tt.wantBody)
+			}
+			if tt.wantCode == http.StatusOK && middlewareCalls != 1 {
+				t.Errorf("expected the root middleware to be called once, got %d", middlewareCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package rakuda
+
+import (
+	"io/fs"
+	"net/http"
+)
+
+// SPAFallback serves the named file of fsys (e.g., "index.html") for unmatched GET and HEAD
+// requests that accept text/html, so that a single-page application gets its entry document
+// when a client-side route is reloaded, while API clients still get the response of the
+// NotFound handler (JSON by default):
+//
+//	b.Static("/assets", assets)
+//	b.SPAFallback(assets, "index.html")
+//
+// Only an explicit text/html in the Accept header counts, as sent by browsers for navigations;
+// "*/*" alone does not. Like NotFound, it must be called on the root builder.
+func (b *Builder) SPAFallback(fsys fs.FS, name string) {
+	b.checkFrozen(callerSource(2))
+	b.spaFallback = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if (r.Method != http.MethodGet && r.Method != http.MethodHead) || !acceptsHTML(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			serveDocument(w, r, fsys, name)
+		})
+	}
+}
+
+// acceptsHTML reports whether the Accept header of r lists text/html explicitly.
+func acceptsHTML(r *http.Request) bool {
+	for _, ar := range parseAccept(r.Header.Get("Accept")) {
+		if ar.typ == "text" && ar.subtype == "html" && ar.q > 0 {
+			return true
+		}
+	}
+	return false
+}
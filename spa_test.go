@@ -0,0 +1,57 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSPAFallback(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html": {Data: []byte("<h1>app</h1>")},
+	}
+	b := NewBuilder()
+	b.Get("/api/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("users")) }))
+	b.SPAFallback(fsys, "index.html")
+	h, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+
+	const browser = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		accept     string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "client-side route", method: http.MethodGet, path: "/users/42", accept: browser, wantStatus: http.StatusOK, wantBody: "<h1>app</h1>"},
+		{name: "head", method: http.MethodHead, path: "/users/42", accept: browser, wantStatus: http.StatusOK},
+		{name: "registered route", method: http.MethodGet, path: "/api/users", accept: browser, wantStatus: http.StatusOK, wantBody: "users"},
+		{name: "api client", method: http.MethodGet, path: "/api/missing", accept: "application/json", wantStatus: http.StatusNotFound, wantBody: `{"error":"not found"}` + "\n"},
+		{name: "any type", method: http.MethodGet, path: "/users/42", accept: "*/*", wantStatus: http.StatusNotFound, wantBody: `{"error":"not found"}` + "\n"},
+		{name: "html refused", method: http.MethodGet, path: "/users/42", accept: "text/html;q=0", wantStatus: http.StatusNotFound, wantBody: `{"error":"not found"}` + "\n"},
+		{name: "post", method: http.MethodPost, path: "/users/42", accept: browser, wantStatus: http.StatusNotFound, wantBody: `{"error":"not found"}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Accept", tt.accept)
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status code: got %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" {
+				if got := rr.Body.String(); got != tt.wantBody {
+					t.Errorf("body: got %q, want %q", got, tt.wantBody)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package rakuda
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// StoredEvent is a Server-Sent Event kept by an EventStore.
+type StoredEvent struct {
+	// ID is assigned by the store when the event is appended. It is sent as the "id" field,
+	// so that reconnecting clients report it in the Last-Event-ID header.
+	ID string
+	// Name is the event name. If empty, it will be omitted.
+	Name string
+	// Data is the JSON-encoded payload.
+	Data json.RawMessage
+}
+
+// EventStore keeps published events, so that an SSEHub can replay the events
+// a client missed while it was disconnected.
+type EventStore interface {
+	// Append stores an event and returns it with its ID assigned.
+	Append(ctx context.Context, name string, data json.RawMessage) (StoredEvent, error)
+	// Since returns the events appended after the event with the given ID, oldest first.
+	// If lastID is empty or no longer retained, it returns all retained events.
+	Since(ctx context.Context, lastID string) ([]StoredEvent, error)
+}
+
+// MemoryEventStore is an in-memory EventStore that retains the most recent events
+// in a ring buffer. IDs are increasing integers. Events are not shared across processes.
+type MemoryEventStore struct {
+	mu     sync.Mutex
+	events []StoredEvent // ring buffer
+	start  int           // index of the oldest event
+	size   int
+	nextID uint64
+}
+
+var _ EventStore = (*MemoryEventStore)(nil)
+
+// NewMemoryEventStore creates a new MemoryEventStore that retains up to capacity events.
+func NewMemoryEventStore(capacity int) *MemoryEventStore {
+	if capacity <= 0 {
+		panic("rakuda: MemoryEventStore capacity must be positive")
+	}
+	return &MemoryEventStore{events: make([]StoredEvent, capacity), nextID: 1}
+}
+
+// Append implements EventStore.
+func (s *MemoryEventStore) Append(ctx context.Context, name string, data json.RawMessage) (StoredEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ev := StoredEvent{ID: strconv.FormatUint(s.nextID, 10), Name: name, Data: data}
+	s.nextID++
+	if s.size < len(s.events) {
+		s.events[(s.start+s.size)%len(s.events)] = ev
+		s.size++
+	} else {
+		s.events[s.start] = ev // overwrite the oldest
+		s.start = (s.start + 1) % len(s.events)
+	}
+	return ev, nil
+}
+
+// Since implements EventStore.
+func (s *MemoryEventStore) Since(ctx context.Context, lastID string) ([]StoredEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// An ID that was never issued (e.g., from before a restart reset the counter)
+	// is not retained either, so all events are returned.
+	var after uint64
+	if lastID != "" {
+		if id, err := strconv.ParseUint(lastID, 10, 64); err == nil && id < s.nextID {
+			after = id
+		}
+	}
+	var events []StoredEvent
+	for i := 0; i < s.size; i++ {
+		ev := s.events[(s.start+i)%len(s.events)]
+		if id, _ := strconv.ParseUint(ev.ID, 10, 64); id > after {
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
+
+// SSEHub broadcasts events to Server-Sent Events clients. Events are appended to
+// an EventStore before they are sent, and clients that reconnect with the
+// Last-Event-ID header first receive the events they missed, so every event is
+// delivered at least once as long as the store retains it.
+//
+//	hub := rakuda.NewSSEHub(rakuda.NewMemoryEventStore(1000))
+//	b.Get("/events", hub)
+//	hub.Publish(ctx, "message", msg)
+type SSEHub struct {
+	store EventStore
+
+	mu          sync.Mutex
+	subscribers map[chan StoredEvent]struct{}
+}
+
+// subscriberBuffer is the number of events buffered for each client.
+// A client that falls further behind is disconnected, so that it reconnects and replays.
+const subscriberBuffer = 64
+
+// NewSSEHub creates a new SSEHub. If store is nil, a MemoryEventStore retaining 1024 events is used.
+func NewSSEHub(store EventStore) *SSEHub {
+	if store == nil {
+		store = NewMemoryEventStore(1024)
+	}
+	return &SSEHub{store: store, subscribers: map[chan StoredEvent]struct{}{}}
+}
+
+// Publish stores an event and sends it to the connected clients.
+// data is encoded as JSON.
+func (h *SSEHub) Publish(ctx context.Context, name string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal event data: %w", err)
+	}
+	ev, err := h.store.Append(ctx, name, b)
+	if err != nil {
+		return fmt.Errorf("append event: %w", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			// The client is too slow, so drop it. It catches up from the store after reconnecting.
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+	}
+	return nil
+}
+
+// ServeHTTP streams events to the client, starting with the events after Last-Event-ID.
+func (h *SSEHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := LoggerFromContext(ctx)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		err := fmt.Errorf("Streaming unsupported")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logger.ErrorContext(ctx, "ResponseWriter does not support flushing", "error", err)
+		return
+	}
+
+	// Subscribe before reading the store, so that no event falls between the two.
+	ch := make(chan StoredEvent, subscriberBuffer)
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		if _, ok := h.subscribers[ch]; ok {
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+		h.mu.Unlock()
+	}()
+
+	var missed []StoredEvent
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		var err error
+		if missed, err = h.store.Since(ctx, lastID); err != nil {
+			responder, ok := ResponderFromContext(ctx)
+			if !ok {
+				responder = NewResponder()
+			}
+			responder.Error(w, r, http.StatusInternalServerError, fmt.Errorf("read missed events: %w", err))
+			return
+		}
+	}
+
+	writeSSEHeader(w)
+	replayed := make(map[string]struct{}, len(missed))
+	for _, ev := range missed {
+		if err := writeStoredEvent(w, ev); err != nil {
+			logger.ErrorContext(ctx, "failed to write SSE event", "error", err)
+			return
+		}
+		replayed[ev.ID] = struct{}{}
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Client disconnected
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				// Dropped by Publish
+				return
+			}
+			if _, dup := replayed[ev.ID]; dup {
+				continue
+			}
+			if err := writeStoredEvent(w, ev); err != nil {
+				logger.ErrorContext(ctx, "failed to write SSE event", "error", err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeSSEHeader(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeStoredEvent(w http.ResponseWriter, ev StoredEvent) error {
+	if _, err := fmt.Fprintf(w, "id: %s\n", ev.ID); err != nil {
+		return err
+	}
+	if ev.Name != "" {
+		if _, err := fmt.Fprintf(w, "event: %s\n", ev.Name); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "data: %s\n\n", ev.Data)
+	return err
+}
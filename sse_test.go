@@ -0,0 +1,123 @@
+package rakuda
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMemoryEventStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryEventStore(3)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		if _, err := store.Append(ctx, name, []byte(`{}`)); err != nil {
+			t.Fatalf("Append() failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		lastID string
+		want   []string
+	}{
+		{name: "empty returns all retained", lastID: "", want: []string{"b", "c", "d"}},
+		{name: "since retained ID", lastID: "2", want: []string{"c", "d"}},
+		{name: "evicted ID returns all retained", lastID: "1", want: []string{"b", "c", "d"}},
+		{name: "latest", lastID: "4", want: nil},
+		{name: "ID never issued returns all retained", lastID: "99", want: []string{"b", "c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := store.Since(ctx, tt.lastID)
+			if err != nil {
+				t.Fatalf("Since() failed: %v", err)
+			}
+			var got []string
+			for _, ev := range events {
+				got = append(got, ev.Name)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Since() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSSEHub_Replay(t *testing.T) {
+	ctx := context.Background()
+	hub := NewSSEHub(NewMemoryEventStore(10))
+	for _, msg := range []string{"one", "two", "three"} {
+		if err := hub.Publish(ctx, "message", map[string]string{"msg": msg}); err != nil {
+			t.Fatalf("Publish() failed: %v", err)
+		}
+	}
+
+	server := httptest.NewServer(hub)
+	defer server.Close()
+
+	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(reqCtx, http.MethodGet, server.URL, nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got, want := resp.Header.Get("Content-Type"), "text/event-stream"; got != want {
+		t.Errorf("Content-Type: got %q, want %q", got, want)
+	}
+
+	// The missed events are replayed, then live events follow.
+	if err := hub.Publish(ctx, "", map[string]string{"msg": "four"}); err != nil {
+		t.Fatalf("Publish() failed: %v", err)
+	}
+	want := []string{
+		"id: 2", "event: message", `data: {"msg":"two"}`, "",
+		"id: 3", "event: message", `data: {"msg":"three"}`, "",
+		"id: 4", `data: {"msg":"four"}`, "",
+	}
+	var got []string
+	scanner := bufio.NewScanner(resp.Body)
+	for len(got) < len(want) && scanner.Scan() {
+		got = append(got, strings.TrimSpace(scanner.Text()))
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("stream mismatch (-want +got):\n%s", diff)
+	}
+}
+
+type failingEventStore struct{ EventStore }
+
+func (failingEventStore) Since(ctx context.Context, lastID string) ([]StoredEvent, error) {
+	return nil, errors.New("store is down")
+}
+
+func TestSSEHub_StoreError(t *testing.T) {
+	hub := NewSSEHub(failingEventStore{NewMemoryEventStore(1)})
+	var reported error
+	responder := &Responder{Reporter: ErrorReporterFunc(func(ctx context.Context, err error, req *http.Request) {
+		reported = err
+	})}
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	req = req.WithContext(NewContextWithResponder(req.Context(), responder))
+	rr := httptest.NewRecorder()
+	hub.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("status code: got %d, want %d", rr.Code, http.StatusInternalServerError)
+	}
+	if reported == nil {
+		t.Error("expected the error to be reported by the responder of the context")
+	}
+}
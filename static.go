@@ -0,0 +1,202 @@
+package rakuda
+
+import (
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// precompressed lists the encodings of precompressed siblings, in order of preference.
+var precompressed = []struct {
+	encoding string
+	ext      string
+}{
+	{encoding: "br", ext: ".br"},
+	{encoding: "gzip", ext: ".gz"},
+}
+
+// FileServer returns a handler that serves files from fsys, like http.FileServerFS,
+// but serves a precompressed sibling ("app.js.br" or "app.js.gz" for "app.js") when it
+// exists and the client accepts its encoding. Files without a sibling are served as is,
+// so they can still be compressed on the fly by rakudamiddleware.Compress, which passes
+// through responses that already have a Content-Encoding.
+//
+//	//go:embed static
+//	var static embed.FS
+//
+//	assets, _ := fs.Sub(static, "static")
+//	b.Get("/static/{path...}", http.StripPrefix("/static/", rakuda.FileServer(assets)))
+func FileServer(fsys fs.FS) http.Handler {
+	files := http.FileServerFS(fsys)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" || strings.HasSuffix(r.URL.Path, "/") || !servePrecompressed(w, r, fsys, name) {
+			files.ServeHTTP(w, r)
+		}
+	})
+}
+
+// servePrecompressed serves a precompressed sibling of name and reports whether it did.
+func servePrecompressed(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string) bool {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		return false // the type would be sniffed from the compressed bytes
+	}
+	if info, err := fs.Stat(fsys, name); err != nil || info.IsDir() {
+		return false
+	}
+
+	varies := false
+	for _, p := range precompressed {
+		f, err := fsys.Open(name + p.ext)
+		if err != nil {
+			continue
+		}
+		if !varies {
+			w.Header().Add("Vary", "Accept-Encoding")
+			varies = true
+		}
+		if !acceptsEncoding(r, p.encoding) {
+			f.Close()
+			continue
+		}
+		info, err := f.Stat()
+		content, ok := f.(io.ReadSeeker)
+		if err != nil || !ok {
+			f.Close()
+			continue
+		}
+		defer f.Close()
+
+		h := w.Header()
+		h.Set("Content-Type", contentType)
+		h.Set("Content-Encoding", p.encoding)
+		http.ServeContent(w, r, name, info.ModTime(), content)
+		return true
+	}
+	return false
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header of r allows encoding.
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.TrimSpace(coding)
+		if (strings.EqualFold(coding, encoding) || coding == "*") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// StaticConfig holds the configuration for Builder.Static.
+type StaticConfig struct {
+	// CacheControl is the Cache-Control header of the served files. The default is
+	// "public, max-age=3600". Index and fallback documents are always served with "no-cache",
+	// so that clients pick up new releases.
+	CacheControl string
+	// Index serves "index.html" for requests to a directory. Directory listings are never served.
+	Index bool
+	// Fallback is the file served, with 200, for paths that do not exist, e.g., "index.html"
+	// for a single-page application whose routes are resolved on the client.
+	Fallback string
+}
+
+// StaticCacheControl sets the Cache-Control header of the files served by Builder.Static,
+// e.g., "public, max-age=31536000, immutable" for fingerprinted assets.
+func StaticCacheControl(value string) func(*StaticConfig) {
+	return func(c *StaticConfig) {
+		c.CacheControl = value
+	}
+}
+
+// StaticIndex makes Builder.Static serve "index.html" for requests to a directory.
+func StaticIndex() func(*StaticConfig) {
+	return func(c *StaticConfig) {
+		c.Index = true
+	}
+}
+
+// StaticSPA makes Builder.Static serve the fallback file (e.g., "index.html") for paths
+// that do not exist, so that a single-page application can resolve its routes on the client.
+func StaticSPA(fallback string) func(*StaticConfig) {
+	return func(c *StaticConfig) {
+		c.Fallback = fallback
+	}
+}
+
+// Static registers a GET route that serves the files of fsys under prefix, with
+// FileServer, so that precompressed siblings are served too:
+//
+//	//go:embed static
+//	var static embed.FS
+//
+//	assets, _ := fs.Sub(static, "static")
+//	b.Static("/static", assets)
+//	b.Static("/", assets, rakuda.StaticSPA("index.html"))
+//
+// The prefix is stripped from the request path, and it is relative to the enclosing group,
+// like the patterns of other routes. Missing files and directories are answered with 404,
+// unless StaticIndex or StaticSPA is given.
+func (b *Builder) Static(prefix string, fsys fs.FS, options ...func(*StaticConfig)) {
+	config := &StaticConfig{CacheControl: "public, max-age=3600"}
+	for _, opt := range options {
+		opt(config)
+	}
+	pattern := strings.TrimSuffix(prefix, "/") + "/{path...}"
+	b.addHandler(http.MethodGet, pattern, staticHandler(fsys, config), callerSource(2))
+}
+
+// staticWildcard is the name of the wildcard of the routes registered by Builder.Static.
+const staticWildcard = "path"
+
+func staticHandler(fsys fs.FS, config *StaticConfig) http.Handler {
+	files := FileServer(fsys)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest := r.PathValue(staticWildcard)
+		name := path.Clean(rest) // "." for the prefix itself
+
+		// Any error, e.g., fs.ErrNotExist or fs.ErrInvalid, is treated as a missing file.
+		info, err := fs.Stat(fsys, name)
+		switch {
+		case err == nil && !info.IsDir():
+			w.Header().Set("Cache-Control", config.CacheControl)
+			files.ServeHTTP(w, stripStaticPrefix(r, "/"+rest))
+			return
+		case err == nil && config.Index:
+			if _, err := fs.Stat(fsys, path.Join(name, "index.html")); err == nil {
+				// FileServer serves the index, or redirects to add the trailing slash.
+				w.Header().Set("Cache-Control", "no-cache")
+				files.ServeHTTP(w, stripStaticPrefix(r, "/"+rest))
+				return
+			}
+		}
+
+		if config.Fallback == "" {
+			http.NotFound(w, r)
+			return
+		}
+		serveDocument(w, r, fsys, config.Fallback)
+	})
+}
+
+// serveDocument serves the named file of fsys as an entry document, e.g., the index.html
+// of a single-page application, which must not be cached so that new releases are picked up.
+func serveDocument(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string) {
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeFileFS(w, r, fsys, name)
+}
+
+// stripStaticPrefix returns a shallow copy of r whose URL path is p, as http.StripPrefix does.
+func stripStaticPrefix(r *http.Request, p string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	u := *r.URL
+	u.Path = p
+	u.RawPath = ""
+	r2.URL = &u
+	return r2
+}
@@ -0,0 +1,186 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFileServer(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":       {Data: []byte("console.log(1)")},
+		"app.js.br":    {Data: []byte("BR")},
+		"app.js.gz":    {Data: []byte("GZ")},
+		"style.css":    {Data: []byte("body{}")},
+		"style.css.gz": {Data: []byte("GZ")},
+		"plain.txt":    {Data: []byte("plain")},
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		wantEncoding   string
+		wantType       string
+		wantVary       string
+		wantBody       string
+	}{
+		{name: "brotli preferred", path: "/app.js", acceptEncoding: "gzip, br", wantEncoding: "br", wantType: "text/javascript; charset=utf-8", wantVary: "Accept-Encoding", wantBody: "BR"},
+		{name: "gzip", path: "/app.js", acceptEncoding: "gzip", wantEncoding: "gzip", wantType: "text/javascript; charset=utf-8", wantVary: "Accept-Encoding", wantBody: "GZ"},
+		{name: "refused encoding", path: "/style.css", acceptEncoding: "gzip;q=0", wantType: "text/css; charset=utf-8", wantVary: "Accept-Encoding", wantBody: "body{}"},
+		{name: "no encoding accepted", path: "/app.js", wantType: "text/javascript; charset=utf-8", wantVary: "Accept-Encoding", wantBody: "console.log(1)"},
+		{name: "no sibling", path: "/plain.txt", acceptEncoding: "gzip, br", wantType: "text/plain; charset=utf-8", wantBody: "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rr := httptest.NewRecorder()
+			FileServer(fsys).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status code: got %d, want %d", rr.Code, http.StatusOK)
+			}
+			if got := rr.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding: got %q, want %q", got, tt.wantEncoding)
+			}
+			if got := rr.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type: got %q, want %q", got, tt.wantType)
+			}
+			if got := rr.Header().Get("Vary"); got != tt.wantVary {
+				t.Errorf("Vary: got %q, want %q", got, tt.wantVary)
+			}
+			if got := rr.Body.String(); got != tt.wantBody {
+				t.Errorf("body: got %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestBuilderStatic(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":      {Data: []byte("<h1>app</h1>")},
+		"app.js":          {Data: []byte("console.log(1)")},
+		"docs/index.html": {Data: []byte("<h1>docs</h1>")},
+		"empty/.keep":     {Data: []byte("")},
+	}
+
+	tests := []struct {
+		name             string
+		setup            func(b *Builder)
+		path             string
+		wantStatus       int
+		wantBody         string
+		wantCacheControl string
+	}{
+		{
+			name:             "file",
+			setup:            func(b *Builder) { b.Static("/static", fsys) },
+			path:             "/static/app.js",
+			wantStatus:       http.StatusOK,
+			wantBody:         "console.log(1)",
+			wantCacheControl: "public, max-age=3600",
+		},
+		{
+			name:       "missing file",
+			setup:      func(b *Builder) { b.Static("/static", fsys) },
+			path:       "/static/missing.js",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "no directory listing",
+			setup:      func(b *Builder) { b.Static("/static", fsys) },
+			path:       "/static/empty/",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:             "custom cache control",
+			setup:            func(b *Builder) { b.Static("/static", fsys, StaticCacheControl("public, max-age=31536000, immutable")) },
+			path:             "/static/app.js",
+			wantStatus:       http.StatusOK,
+			wantBody:         "console.log(1)",
+			wantCacheControl: "public, max-age=31536000, immutable",
+		},
+		{
+			name: "in a group",
+			setup: func(b *Builder) {
+				b.Route("/assets", func(b *Builder) { b.Static("/v1", fsys) })
+			},
+			path:             "/assets/v1/app.js",
+			wantStatus:       http.StatusOK,
+			wantBody:         "console.log(1)",
+			wantCacheControl: "public, max-age=3600",
+		},
+		{
+			name:             "directory index",
+			setup:            func(b *Builder) { b.Static("/static", fsys, StaticIndex()) },
+			path:             "/static/docs/",
+			wantStatus:       http.StatusOK,
+			wantBody:         "<h1>docs</h1>",
+			wantCacheControl: "no-cache",
+		},
+		{
+			name:             "directory index without trailing slash",
+			setup:            func(b *Builder) { b.Static("/static", fsys, StaticIndex()) },
+			path:             "/static/docs",
+			wantStatus:       http.StatusMovedPermanently,
+			wantCacheControl: "no-cache",
+		},
+		{
+			name:             "spa root",
+			setup:            func(b *Builder) { b.Static("/", fsys, StaticSPA("index.html")) },
+			path:             "/",
+			wantStatus:       http.StatusOK,
+			wantBody:         "<h1>app</h1>",
+			wantCacheControl: "no-cache",
+		},
+		{
+			name:             "spa fallback",
+			setup:            func(b *Builder) { b.Static("/", fsys, StaticSPA("index.html")) },
+			path:             "/users/42",
+			wantStatus:       http.StatusOK,
+			wantBody:         "<h1>app</h1>",
+			wantCacheControl: "no-cache",
+		},
+		{
+			name: "spa does not shadow other routes",
+			setup: func(b *Builder) {
+				b.Static("/", fsys, StaticSPA("index.html"))
+				b.Get("/api/health", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) }))
+			},
+			path:       "/api/health",
+			wantStatus: http.StatusOK,
+			wantBody:   "ok",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuilder()
+			tt.setup(b)
+			h, err := b.Build()
+			if err != nil {
+				t.Fatalf("Build() failed: %v", err)
+			}
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status code: got %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" {
+				if got := rr.Body.String(); got != tt.wantBody {
+					t.Errorf("body: got %q, want %q", got, tt.wantBody)
+				}
+			}
+			if got := rr.Header().Get("Cache-Control"); got != tt.wantCacheControl {
+				t.Errorf("Cache-Control: got %q, want %q", got, tt.wantCacheControl)
+			}
+		})
+	}
+}
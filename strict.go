@@ -0,0 +1,62 @@
+package rakuda
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// checkStrict reports suspicious configurations that are rejected in strict mode.
+// All violations are collected and returned together.
+func (b *Builder) checkStrict() error {
+	var errs []error
+
+	// Groups registered with Route and an empty pattern.
+	var traverse func(*node, string)
+	traverse = func(n *node, prefix string) {
+		for _, child := range n.children {
+			if child.isRoute && child.pattern == "" {
+				errs = append(errs, fmt.Errorf("strict: Route under %q is registered with an empty pattern at %s; use Group instead", prefix, child.source))
+			}
+			traverse(child, path.Join(prefix, child.pattern))
+		}
+	}
+	traverse(b.node, "/")
+
+	// Routes with conflicting wildcards. A catch-all route ({name...}) never shadows a more
+	// specific route, whatever the registration order, because of the precedence of ServeMux.
+	var seen []route
+	_ = b.walk(func(rt route) error {
+		for _, prev := range seen {
+			if prev.method != rt.method || prev.host != rt.host || isAliasPair(prev, rt) {
+				continue
+			}
+			if rt.pattern != prev.pattern && patternShape(rt.pattern) == patternShape(prev.pattern) {
+				errs = append(errs, fmt.Errorf("strict: route %s %s (%s) conflicts with the wildcard route %s %s registered earlier (%s)",
+					rt.method, rt.pattern, rt.source, prev.method, prev.pattern, prev.source))
+			}
+		}
+		seen = append(seen, rt)
+		return nil
+	})
+
+	return errors.Join(errs...)
+}
+
+// patternShape returns the pattern with wildcard names removed, so that patterns
+// matching the same set of paths (e.g., /users/{id} and /users/{name}) have the same shape.
+func patternShape(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, seg := range segments {
+		if seg == "{$}" || !strings.HasPrefix(seg, "{") || !strings.HasSuffix(seg, "}") {
+			continue
+		}
+		if strings.HasSuffix(seg, "...}") {
+			segments[i] = "{...}"
+		} else {
+			segments[i] = "{}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,91 @@
+package rakuda
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStrict(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name      string
+		configure func(b *Builder)
+		wantErr   string // substring; empty means no error
+	}{
+		{
+			name: "valid",
+			configure: func(b *Builder) {
+				b.Get("/users", h)
+				b.Get("/users/{id}", h)
+				b.Group(func(b *Builder) {
+					b.Post("/users", h)
+				})
+			},
+		},
+		{
+			name: "route registered after a catch-all is allowed",
+			configure: func(b *Builder) {
+				b.Get("/files/{path...}", h)
+				b.Get("/files/readme", h) // more specific, so it is served under ServeMux precedence
+			},
+		},
+		{
+			name: "catch-all registered later is allowed",
+			configure: func(b *Builder) {
+				b.Get("/files/readme", h)
+				b.Get("/files/{path...}", h)
+			},
+		},
+		{
+			name: "conflicting wildcard",
+			configure: func(b *Builder) {
+				b.Get("/users/{id}", h)
+				b.Route("/users", func(b *Builder) {
+					b.Get("/{name}", h)
+				})
+			},
+			wantErr: "conflicts with the wildcard route GET /users/{id}",
+		},
+		{
+			name: "empty route pattern",
+			configure: func(b *Builder) {
+				b.Route("", func(b *Builder) {
+					b.Get("/users", h)
+				})
+			},
+			wantErr: "is registered with an empty pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBuilder(WithStrict())
+			tt.configure(b)
+
+			_, err := b.Build()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected an error containing %q, got nil", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error mismatch:\ngot:  %q\nwant: %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("not strict", func(t *testing.T) {
+		b := NewBuilder()
+		b.Get("/files/{path...}", h)
+		b.Get("/files/readme", h)
+		if _, err := b.Build(); err != nil {
+			t.Errorf("expected no error without strict mode, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package rakuda
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// WithNotFoundSuggestions makes the default NotFound handler suggest up to n registered
+// patterns close to the request path ("did you mean"), in the JSON body and the log record:
+//
+//	GET /usres/1
+//	{"error":"not found","suggestions":["/users/{id}"]}
+//
+// Paths are compared segment by segment: wildcards match any segment, and the edit
+// distances of the literal segments are added up. Only patterns with as many segments
+// as the path (or fewer, for {name...}) and a small distance are suggested.
+// It has no effect if a NotFound handler is set.
+func WithNotFoundSuggestions(n int) func(*BuilderConfig) {
+	return func(c *BuilderConfig) {
+		c.NotFoundSuggestions = n
+	}
+}
+
+// notFoundWithSuggestions returns the default NotFound handler with suggestions from the paths of routes.
+func notFoundWithSuggestions(routes []RouteInfo, n int) http.Handler {
+	var patterns []string
+	for _, r := range routes {
+		p := r.Pattern[strings.Index(r.Pattern, "/"):] // drop the host
+		if !slices.Contains(patterns, p) {
+			patterns = append(patterns, p)
+		}
+	}
+	responder := NewResponder()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suggestions := suggestPatterns(patterns, r.URL.Path, n)
+		if len(suggestions) == 0 {
+			responder.JSON(w, r, http.StatusNotFound, map[string]string{"error": "not found"})
+			return
+		}
+		ctx := r.Context()
+		LoggerFromContext(ctx).InfoContext(ctx, "not found", "path", r.URL.Path, "suggestions", suggestions)
+		responder.JSON(w, r, http.StatusNotFound, map[string]any{"error": "not found", "suggestions": suggestions})
+	})
+}
+
+// suggestPatterns returns up to n patterns close to path, closest first.
+func suggestPatterns(patterns []string, path string, n int) []string {
+	type candidate struct {
+		pattern  string
+		distance int
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var candidates []candidate
+	for _, p := range patterns {
+		if d, ok := patternDistance(p, segments); ok {
+			candidates = append(candidates, candidate{pattern: p, distance: d})
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int { return cmp.Compare(a.distance, b.distance) })
+
+	var suggestions []string
+	for _, c := range candidates[:min(n, len(candidates))] {
+		suggestions = append(suggestions, c.pattern)
+	}
+	return suggestions
+}
+
+// patternDistance returns the sum of the edit distances between the literal segments of
+// pattern and the segments of a path, and whether it is small enough to suggest pattern.
+func patternDistance(pattern string, segments []string) (int, bool) {
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(pattern, "{$}"), "/"), "/")
+	distance, length := 0, 0
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "...}") {
+			if len(segments) < i {
+				return 0, false
+			}
+			segments, parts = segments[:i], parts[:i] // the rest is matched by the wildcard
+			break
+		}
+	}
+	if len(parts) != len(segments) {
+		return 0, false
+	}
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			continue
+		}
+		distance += editDistance(part, segments[i])
+		length += len(part)
+	}
+	return distance, distance > 0 && distance <= length/3+1
+}
+
+// editDistance returns the Levenshtein distance between a and b, in bytes.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
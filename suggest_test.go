@@ -0,0 +1,75 @@
+package rakuda
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNotFoundSuggestions(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	var logs strings.Builder
+	b := NewBuilder(
+		WithNotFoundSuggestions(2),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+	b.Get("/users", handler)
+	b.Post("/users", handler)
+	b.Get("/users/{id}", handler)
+	b.Get("/users/{id}/posts", handler)
+	b.Get("/files/{path...}", handler)
+	b.Get("/healthz", handler)
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/usres", want: `{"error":"not found","suggestions":["/users"]}`},
+		{path: "/usres/1", want: `{"error":"not found","suggestions":["/users/{id}"]}`},
+		{path: "/user/1/post", want: `{"error":"not found","suggestions":["/users/{id}/posts"]}`},
+		{path: "/file/a/b/c", want: `{"error":"not found","suggestions":["/files/{path...}"]}`},
+		{path: "/health", want: `{"error":"not found","suggestions":["/healthz"]}`},
+		{path: "/orders", want: `{"error":"not found"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if got, want := rr.Code, http.StatusNotFound; got != want {
+				t.Errorf("status code: got %d, want %d", got, want)
+			}
+			if diff := cmp.Diff(tt.want+"\n", rr.Body.String()); diff != "" {
+				t.Errorf("body mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+	if !strings.Contains(logs.String(), "suggestions=[/users]") {
+		t.Errorf("expected the suggestions in the log, got:\n%s", logs.String())
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "users", b: "users", want: 0},
+		{a: "users", b: "usres", want: 2},
+		{a: "user", b: "users", want: 1},
+		{a: "", b: "abc", want: 3},
+		{a: "kitten", b: "sitting", want: 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q): got %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
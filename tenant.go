@@ -0,0 +1,28 @@
+package rakuda
+
+import "context"
+
+// TenantID identifies the tenant of a request in a multi-tenant service.
+type TenantID string
+
+// NewContextWithTenant returns a new context with the provided tenant.
+// If the context has a ValueBag (see the Values middleware in rakudamiddleware),
+// the tenant is also stored in it, so that outer middlewares such as access logging can see it.
+func NewContextWithTenant(ctx context.Context, tenant TenantID) context.Context {
+	if bag := Values(ctx); bag != nil {
+		bag.Set(tenantKey, tenant)
+	}
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// TenantFromContext retrieves the tenant of the request.
+func TenantFromContext(ctx context.Context) (TenantID, bool) {
+	if tenant, ok := ctx.Value(tenantKey).(TenantID); ok {
+		return tenant, true
+	}
+	if v, ok := Values(ctx).Get(tenantKey); ok {
+		tenant, ok := v.(TenantID)
+		return tenant, ok
+	}
+	return "", false
+}
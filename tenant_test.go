@@ -0,0 +1,29 @@
+package rakuda
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTenantFromContext(t *testing.T) {
+	t.Run("not set", func(t *testing.T) {
+		if _, ok := TenantFromContext(context.Background()); ok {
+			t.Error("expected no tenant")
+		}
+	})
+
+	t.Run("set", func(t *testing.T) {
+		ctx := NewContextWithTenant(context.Background(), "acme")
+		if got, ok := TenantFromContext(ctx); !ok || got != "acme" {
+			t.Errorf("got (%q, %v), want (%q, true)", got, ok, "acme")
+		}
+	})
+
+	t.Run("visible through the value bag", func(t *testing.T) {
+		outer := NewContextWithValues(context.Background())
+		_ = NewContextWithTenant(outer, "acme")
+		if got, ok := TenantFromContext(outer); !ok || got != "acme" {
+			t.Errorf("got (%q, %v), want (%q, true)", got, ok, "acme")
+		}
+	})
+}
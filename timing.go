@@ -0,0 +1,94 @@
+package rakuda
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimingEntry is a named duration of server-side work, such as a database query.
+type TimingEntry struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Timings collects the timing entries of a request. They are sent in the Server-Timing
+// response header by the rakudamiddleware.ServerTiming middleware and logged by
+// rakudamiddleware.HTTPLog. A nil *Timings discards entries, so handlers can
+// call Timing(ctx).Add without checking whether timings are collected.
+type Timings struct {
+	mu      sync.Mutex
+	entries []TimingEntry
+}
+
+// NewContextWithTimings returns a new context that collects timings.
+// If ctx already collects timings, it is returned as is, with its Timings.
+func NewContextWithTimings(ctx context.Context) (context.Context, *Timings) {
+	if t, ok := ctx.Value(timingsKey).(*Timings); ok {
+		return ctx, t
+	}
+	t := &Timings{}
+	return context.WithValue(ctx, timingsKey, t), t
+}
+
+// Timing returns the Timings of the request, or nil if timings are not collected.
+//
+//	start := time.Now()
+//	rows, err := db.QueryContext(ctx, q)
+//	rakuda.Timing(ctx).Add("db", time.Since(start))
+func Timing(ctx context.Context) *Timings {
+	t, _ := ctx.Value(timingsKey).(*Timings)
+	return t
+}
+
+// Add records a duration. Entries with the same name are kept separately.
+func (t *Timings) Add(name string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries = append(t.entries, TimingEntry{Name: name, Duration: d})
+}
+
+// Start starts measuring name and returns a function that records the duration when called.
+//
+//	defer rakuda.Timing(ctx).Start("render")()
+func (t *Timings) Start(name string) func() {
+	start := time.Now()
+	return func() { t.Add(name, time.Since(start)) }
+}
+
+// Entries returns a copy of the recorded entries, in the order they were added.
+func (t *Timings) Entries() []TimingEntry {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TimingEntry(nil), t.entries...)
+}
+
+// FormatServerTiming formats entries as a Server-Timing header value, e.g. "db;dur=12.5, render;dur=3".
+// Durations are in milliseconds.
+func FormatServerTiming(entries []TimingEntry) string {
+	var parts []string
+	for _, e := range entries {
+		dur := strconv.FormatFloat(float64(e.Duration.Microseconds())/1000, 'f', -1, 64)
+		parts = append(parts, e.Name+";dur="+dur)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// LogValue implements slog.LogValuer, logging the entries as a group of durations.
+func (t *Timings) LogValue() slog.Value {
+	entries := t.Entries()
+	attrs := make([]slog.Attr, len(entries))
+	for i, e := range entries {
+		attrs[i] = slog.Duration(e.Name, e.Duration)
+	}
+	return slog.GroupValue(attrs...)
+}
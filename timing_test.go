@@ -0,0 +1,33 @@
+package rakuda
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTiming(t *testing.T) {
+	t.Run("collected", func(t *testing.T) {
+		ctx, timings := NewContextWithTimings(context.Background())
+		Timing(ctx).Add("db", 12500*time.Microsecond)
+		Timing(ctx).Add("render", 3*time.Millisecond)
+
+		if got, want := FormatServerTiming(timings.Entries()), "db;dur=12.5, render;dur=3"; got != want {
+			t.Errorf("FormatServerTiming() = %q, want %q", got, want)
+		}
+		if _, again := NewContextWithTimings(ctx); again != timings {
+			t.Error("expected NewContextWithTimings to reuse the timings in the context")
+		}
+	})
+
+	t.Run("not collected", func(t *testing.T) {
+		ctx := context.Background()
+		Timing(ctx).Add("db", time.Millisecond) // must not panic
+		Timing(ctx).Start("render")()
+		if diff := cmp.Diff([]TimingEntry(nil), Timing(ctx).Entries()); diff != "" {
+			t.Errorf("Entries() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package rakuda
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// TrailingSlashPolicy decides how the router handles a request whose path matches a route
+// only after adding or removing a trailing slash, e.g., "/users/" for a "/users" route.
+// ServeMux treats such paths as distinct, which surprises users of routers such as chi and echo.
+type TrailingSlashPolicy int
+
+const (
+	// TrailingSlashStrict keeps the behavior of ServeMux, and is the default: "/users/" does not
+	// match a "/users" route, while "/items" is redirected with 307 to "/items/" if only the latter matches.
+	TrailingSlashStrict TrailingSlashPolicy = iota
+	// TrailingSlashRedirect redirects with 308 Permanent Redirect to the path that matches,
+	// keeping the method and the query.
+	TrailingSlashRedirect
+	// TrailingSlashStrip rewrites the request to the path that matches, without a round trip.
+	// r.RequestURI keeps the original request target.
+	TrailingSlashStrip
+)
+
+// WithTrailingSlashPolicy sets how requests that match a route only with or without a
+// trailing slash are handled. Requests that match a route as they are, or that match
+// neither variant, are not affected.
+//
+//	b := rakuda.NewBuilder(rakuda.WithTrailingSlashPolicy(rakuda.TrailingSlashRedirect))
+func WithTrailingSlashPolicy(policy TrailingSlashPolicy) func(*BuilderConfig) {
+	return func(c *BuilderConfig) {
+		c.TrailingSlash = policy
+	}
+}
+
+// serveTrailingSlash applies the trailing slash policy to an unmatched request,
+// and reports whether it served the request.
+func (rt *router) serveTrailingSlash(w http.ResponseWriter, r *http.Request) bool {
+	if rt.trailingSlash == TrailingSlashStrict || r.URL.Path == "/" || normalizePath(r.URL.Path) != r.URL.Path {
+		return false // unclean paths are redirected by the mux
+	}
+	p := r.URL.Path + "/"
+	if strings.HasSuffix(r.URL.Path, "/") {
+		p = strings.TrimRight(r.URL.Path, "/")
+		if p == "" {
+			p = "/"
+		}
+	}
+
+	r2 := new(http.Request)
+	*r2 = *r
+	u := *r.URL
+	u.Path = p
+	u.RawPath = ""
+	r2.URL = &u
+	if _, pattern := rt.mux.Handler(r2); pattern == "" {
+		return false
+	}
+
+	if rt.trailingSlash == TrailingSlashRedirect {
+		http.Redirect(w, r, u.RequestURI(), http.StatusPermanentRedirect)
+		return true
+	}
+	rt.mux.ServeHTTP(w, r2)
+	return true
+}
+
+// muxRedirectType is the type of the handlers returned by ServeMux.Handler for redirects.
+var muxRedirectType = reflect.TypeOf(http.RedirectHandler("/", http.StatusTemporaryRedirect))
+
+// isMuxRedirect reports whether h, returned by ServeMux.Handler, is a redirect,
+// e.g., from "/users" to "/users/" when only the latter matches.
+func isMuxRedirect(h http.Handler) bool {
+	return reflect.TypeOf(h) == muxRedirectType
+}
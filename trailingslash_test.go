@@ -0,0 +1,65 @@
+package rakuda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrailingSlashPolicy(t *testing.T) {
+	newHandler := func(t *testing.T, policy TrailingSlashPolicy) http.Handler {
+		b := NewBuilder(WithTrailingSlashPolicy(policy))
+		b.Get("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("users " + r.URL.Path))
+		}))
+		b.Route("/items", func(b *Builder) {
+			b.Post("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("items " + r.URL.Path))
+			}))
+		})
+		h, err := b.Build()
+		if err != nil {
+			t.Fatalf("Build() failed: %v", err)
+		}
+		return h
+	}
+
+	tests := []struct {
+		name         string
+		policy       TrailingSlashPolicy
+		method       string
+		target       string
+		wantStatus   int
+		wantBody     string
+		wantLocation string
+	}{
+		{name: "strict", policy: TrailingSlashStrict, method: http.MethodGet, target: "/users/", wantStatus: http.StatusNotFound},
+		{name: "strict adds slash like ServeMux", policy: TrailingSlashStrict, method: http.MethodPost, target: "/items", wantStatus: http.StatusTemporaryRedirect, wantLocation: "/items/"},
+		{name: "strict exact match", policy: TrailingSlashStrict, method: http.MethodGet, target: "/users", wantStatus: http.StatusOK, wantBody: "users /users"},
+		{name: "redirect removes slash", policy: TrailingSlashRedirect, method: http.MethodGet, target: "/users/?page=2", wantStatus: http.StatusPermanentRedirect, wantLocation: "/users?page=2"},
+		{name: "redirect adds slash", policy: TrailingSlashRedirect, method: http.MethodPost, target: "/items", wantStatus: http.StatusPermanentRedirect, wantLocation: "/items/"},
+		{name: "redirect without a match", policy: TrailingSlashRedirect, method: http.MethodGet, target: "/missing/", wantStatus: http.StatusNotFound},
+		{name: "redirect with another method", policy: TrailingSlashRedirect, method: http.MethodDelete, target: "/users/", wantStatus: http.StatusNotFound},
+		{name: "strip removes slash", policy: TrailingSlashStrip, method: http.MethodGet, target: "/users/", wantStatus: http.StatusOK, wantBody: "users /users"},
+		{name: "strip adds slash", policy: TrailingSlashStrip, method: http.MethodPost, target: "/items", wantStatus: http.StatusOK, wantBody: "items /items/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			newHandler(t, tt.policy).ServeHTTP(rr, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status code: got %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" {
+				if got := rr.Body.String(); got != tt.wantBody {
+					t.Errorf("body: got %q, want %q", got, tt.wantBody)
+				}
+			}
+			if got := rr.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location: got %q, want %q", got, tt.wantLocation)
+			}
+		})
+	}
+}
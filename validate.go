@@ -0,0 +1,136 @@
+package rakuda
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// checkPatterns validates the patterns of the routes under prefix (all routes if prefix is
+// empty), so that a malformed pattern is reported by Build with its registration location
+// instead of making ServeMux panic. The media types declared with Consumes are checked too,
+// since a malformed one would reject every request body. All errors are reported together.
+func (b *Builder) checkPatterns(prefix string) error {
+	var errs []error
+	_ = b.walk(func(rt route) error {
+		if prefix != "" && !underPrefix(rt.pattern, prefix) {
+			return nil
+		}
+		if err := validatePattern(rt.registered, rt.pattern); err != nil {
+			errs = append(errs, fmt.Errorf("rakuda: invalid pattern %q of route %s (%s): %w", rt.registered, rt.key(), rt.source, err))
+		}
+		for _, mediaType := range rt.meta.Consumes {
+			if !validMediaType(mediaType) {
+				errs = append(errs, fmt.Errorf("rakuda: invalid media type %q in Consumes of route %s (%s)", mediaType, rt.key(), rt.source))
+			}
+		}
+		return nil
+	})
+	return errors.Join(errs...)
+}
+
+// checkAliases reports the aliases whose target has no route, or whose wildcards differ
+// from those of the target (see Alias).
+func (b *Builder) checkAliases() error {
+	aliases := b.aliases()
+	if len(aliases) == 0 {
+		return nil
+	}
+	var errs []error
+	for _, a := range aliases {
+		found := false
+		_ = b.walkRoutes(func(rt route) error {
+			found = found || (rt.host == a.host && rt.pattern == a.target)
+			return nil
+		})
+		if !found {
+			errs = append(errs, fmt.Errorf("rakuda: alias %q (%s) has no route %q", a.host+a.pattern, a.source, a.host+a.target))
+		}
+		// The handler reads the path values of the target, which must all be set by the alias.
+		if aliasNames, targetNames := wildcardNames(a.pattern), wildcardNames(a.target); !slices.Equal(aliasNames, targetNames) {
+			errs = append(errs, fmt.Errorf("rakuda: alias %q (%s) has the wildcards %v, but its target %q has %v",
+				a.host+a.pattern, a.source, aliasNames, a.host+a.target, targetNames))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// wildcardNames returns the sorted names of the wildcards of a pattern.
+func wildcardNames(pattern string) []string {
+	var names []string
+	for _, m := range wildcardPattern.FindAllStringSubmatch(pattern, -1) {
+		names = append(names, m[1])
+	}
+	slices.Sort(names)
+	return names
+}
+
+// validatePattern checks the path of a route pattern, as registered and as joined with
+// the prefixes of its groups: no empty segments, wildcards that span whole segments
+// with balanced braces and valid, unique names, and {name...} and {$} only at the end.
+func validatePattern(registered, pattern string) error {
+	if strings.Contains(registered, "//") {
+		return errors.New("empty segment")
+	}
+	seen := map[string]bool{}
+	segments := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		if !strings.ContainsAny(segment, "{}") {
+			continue
+		}
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") || strings.Count(segment, "{") != 1 || strings.Count(segment, "}") != 1 {
+			return fmt.Errorf("segment %q: a wildcard must be a whole segment with balanced braces", segment)
+		}
+		name := segment[1 : len(segment)-1]
+		if name == "$" {
+			if !last {
+				return errors.New("{$} must be at the end")
+			}
+			continue
+		}
+		if base, ok := strings.CutSuffix(name, "..."); ok {
+			if !last {
+				return fmt.Errorf("segment %q: {%s} must be at the end", segment, name)
+			}
+			name = base
+		}
+		if !isWildcardName(name) {
+			return fmt.Errorf("segment %q: %q is not a valid wildcard name", segment, name)
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicate wildcard name %q", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// isWildcardName reports whether name is a valid wildcard name: a Go identifier, as for ServeMux
+// (keywords such as "type" are allowed).
+func isWildcardName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if !unicode.IsLetter(c) && c != '_' && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// handleRoute registers the handler of a route to the mux, reporting a panic of
+// the mux (e.g., ServeMux's for conflicting patterns) as an error with the route's location.
+func handleRoute(mux Mux, rt route, handler http.Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("rakuda: cannot register route %s (%s): %v", rt.key(), rt.source, r)
+		}
+	}()
+	mux.Handle(rt.key(), handler)
+	return nil
+}
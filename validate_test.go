@@ -0,0 +1,100 @@
+package rakuda
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidatePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr string // empty if valid
+	}{
+		{pattern: "/users/{id}"},
+		{pattern: "/files/{path...}"},
+		{pattern: "/{$}"},
+		{pattern: "/items/{type}"},
+		{pattern: "/users//{id}", wantErr: "empty segment"},
+		{pattern: "/users/{id", wantErr: "balanced braces"},
+		{pattern: "/users/id}", wantErr: "balanced braces"},
+		{pattern: "/users/x{id}", wantErr: "whole segment"},
+		{pattern: "/users/{{id}}", wantErr: "balanced braces"},
+		{pattern: "/files/{path...}/raw", wantErr: "must be at the end"},
+		{pattern: "/{$}/users", wantErr: "must be at the end"},
+		{pattern: "/users/{1id}", wantErr: "not a valid wildcard name"},
+		{pattern: "/users/{}", wantErr: "not a valid wildcard name"},
+		{pattern: "/users/{id}/posts/{id}", wantErr: `duplicate wildcard name "id"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			err := validatePattern(tt.pattern, tt.pattern)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %v does not contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildInvalidPatterns(t *testing.T) {
+	t.Run("aggregated", func(t *testing.T) {
+		b := NewBuilder()
+		b.Get("/users/{id", http.HandlerFunc(healthHandler))
+		b.Route("/files", func(b *Builder) {
+			b.Get("/{path...}/raw", http.HandlerFunc(healthHandler))
+		})
+		b.Get("/health", http.HandlerFunc(healthHandler))
+
+		_, err := b.Build()
+		if err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+		for _, want := range []string{`"/users/{id"`, `GET /files/{path...}/raw`, "validate_test.go:"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not contain %q", err, want)
+			}
+		}
+	})
+
+	t.Run("media types", func(t *testing.T) {
+		b := NewBuilder()
+		b.Post("/users", http.HandlerFunc(healthHandler), Consumes("json"))
+		b.Post("/uploads", http.HandlerFunc(healthHandler), Consumes("image/*", "*/png"))
+		b.Post("/forms", http.HandlerFunc(healthHandler), Consumes("application/x-www-form-urlencoded"))
+
+		_, err := b.Build()
+		if err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+		for _, want := range []string{`"json" in Consumes of route POST /users`, `"*/png" in Consumes of route POST /uploads`, "validate_test.go:"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not contain %q", err, want)
+			}
+		}
+		if strings.Contains(err.Error(), "/forms") {
+			t.Errorf("error %q reports the valid route /forms", err)
+		}
+	})
+
+	t.Run("mux conflict", func(t *testing.T) {
+		b := NewBuilder()
+		b.Get("/a/{x}", http.HandlerFunc(healthHandler))
+		b.Get("/{y}/b", http.HandlerFunc(healthHandler))
+
+		_, err := b.Build()
+		if err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+		for _, want := range []string{"cannot register route GET /{y}/b", "validate_test.go:"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not contain %q", err, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package rakuda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/podhmo/rakuda/binding"
+)
+
+// LiftBind is like Lift, but with the binding and validation of the request separated from
+// the action, so that the endpoint registered by Builder.ValidationReport can run the
+// binding alone:
+//
+//	b.Post("/users/{id}", rakuda.LiftBind(nil, bindUpdateUser, updateUser))
+//
+// bind returns the input of the action, typically with a *binding.ValidationErrors error
+// (see binding.Join). If it fails, the error is written as with Lift and the action is not called.
+func LiftBind[I, O any](responder *Responder, bind func(*http.Request) (I, error), action func(*http.Request, I) (O, error)) http.Handler {
+	return &boundHandler{
+		Handler: Lift(responder, func(r *http.Request) (O, error) {
+			input, err := bind(r)
+			if err != nil {
+				var zero O
+				return zero, err
+			}
+			return action(r, input)
+		}),
+		bind: func(r *http.Request) error {
+			_, err := bind(r)
+			return err
+		},
+	}
+}
+
+// boundHandler is the handler returned by LiftBind.
+type boundHandler struct {
+	http.Handler
+	bind func(*http.Request) error
+}
+
+// ValidationReport is the response of the endpoint registered by Builder.ValidationReport.
+type ValidationReport struct {
+	// Route is the matched route, e.g., "POST /users/{id}".
+	Route string `json:"route,omitempty"`
+	// Valid reports whether the request passed the binding of the route.
+	Valid bool `json:"valid"`
+	// Errors are the errors that the route would respond with, if any.
+	Errors []*binding.Error `json:"errors,omitempty"`
+	// Response is the response of the request if it was rejected before the binding,
+	// e.g., 404 if no route matched, or 401 by an authentication middleware.
+	Response *BatchResponse `json:"response,omitempty"`
+}
+
+// ValidationReport registers a POST handler that runs only the binding and validation of
+// a route, without its action, so that frontend developers can check how parameters are
+// handled without side effects. The request body is a BatchRequest describing the request
+// to check, whose path includes the query string:
+//
+//	b.ValidationReport("/_dev/validate")
+//
+//	// POST /_dev/validate {"method": "POST", "path": "/users/x?notify=maybe", "body": {"name": ""}}
+//	// -> 200 {"route": "POST /users/{id}", "valid": false, "errors": [...]}
+//
+// The request is dispatched in-process against the built router, through the middlewares
+// of the route. Only routes registered with LiftBind can be checked; others are rejected
+// with 400 and their handlers are not called. It is a development tool, so register it
+// in development only:
+//
+//	if profile.Name == rakuda.Development.Name {
+//		b.ValidationReport("/_dev/validate")
+//	}
+func (b *Builder) ValidationReport(pattern string) {
+	b.addHandler(http.MethodPost, pattern, &validationReportHandler{responder: NewResponder()}, callerSource(2))
+}
+
+// validationProbe records the outcome of a request dispatched by the validation report handler.
+type validationProbe struct {
+	route     string
+	reached   bool // the request reached the handler of the route
+	supported bool // the handler has a binding phase (see LiftBind)
+	err       error
+}
+
+// validationGuard returns a handler that runs only the binding of handler for requests
+// dispatched by the validation report handler, and handler otherwise.
+func validationGuard(route string, handler http.Handler) http.Handler {
+	bound, _ := handler.(*boundHandler)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probe, ok := r.Context().Value(probeKey).(*validationProbe)
+		if !ok {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		probe.route, probe.reached = route, true
+		if bound != nil {
+			probe.supported = true
+			probe.err = bound.bind(r)
+		}
+	})
+}
+
+// validationReportHandler is the http.Handler registered by Builder.ValidationReport.
+type validationReportHandler struct {
+	responder *Responder
+	router    *routerRef // set by bindRouter
+}
+
+// bindRouter implements the routerAware interface.
+func (h *validationReportHandler) bindRouter(router *routerRef) http.Handler {
+	bound := *h
+	bound.router = router
+	return &bound
+}
+
+func (h *validationReportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.router == nil || h.router.Handler == nil {
+		h.responder.Error(w, r, http.StatusInternalServerError, errors.New("validation report handler is not bound to a router"))
+		return
+	}
+	var item BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		h.responder.Error(w, r, http.StatusBadRequest, fmt.Errorf("invalid validation request: %w", err))
+		return
+	}
+	if item.Method == "" || !strings.HasPrefix(item.Path, "/") {
+		h.responder.Error(w, r, http.StatusBadRequest, errors.New("invalid validation request: method and an absolute path are required"))
+		return
+	}
+
+	probe := &validationProbe{}
+	res := dispatch(context.WithValue(r.Context(), probeKey, probe), r, h.router, item, DefaultBatchInheritHeaders)
+	switch {
+	case !probe.reached:
+		h.responder.JSON(w, r, http.StatusOK, ValidationReport{Response: &res})
+	case !probe.supported:
+		h.responder.Error(w, r, http.StatusBadRequest, fmt.Errorf("route %s has no separate binding phase; register it with LiftBind", probe.route))
+	default:
+		report := ValidationReport{Route: probe.route, Valid: true}
+		var vErrs *binding.ValidationErrors
+		if errors.As(binding.Join(probe.err), &vErrs) { // warnings alone do not fail the request
+			report.Valid, report.Errors = false, vErrs.Errors
+		}
+		h.responder.JSON(w, r, http.StatusOK, report)
+	}
+}
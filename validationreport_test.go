@@ -0,0 +1,130 @@
+package rakuda
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/rakuda/binding"
+)
+
+func TestValidationReport(t *testing.T) {
+	type input struct {
+		ID    int
+		Limit int
+	}
+	var calls int
+
+	b := NewBuilder()
+	b.Get("/users/{id}", LiftBind(nil, func(r *http.Request) (input, error) {
+		var in input
+		bd := binding.New(r, r.PathValue)
+		return in, binding.Join(
+			binding.One(bd, &in.ID, binding.Path, "id", strconv.Atoi, binding.Required),
+			binding.One(bd, &in.Limit, binding.Query, "limit", strconv.Atoi, binding.Optional),
+		)
+	}, func(r *http.Request, in input) (input, error) {
+		calls++
+		return in, nil
+	}))
+	b.Post("/plain", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	b.ValidationReport("/_dev/validate")
+
+	router, err := b.Build()
+	if err != nil {
+		t.Fatalf("b.Build() failed: %v", err)
+	}
+
+	type summary struct {
+		Route  string
+		Valid  bool
+		Keys   []string
+		Status int
+	}
+	cases := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       summary
+	}{
+		{
+			name:       "valid",
+			body:       `{"method": "GET", "path": "/users/1?limit=10"}`,
+			wantStatus: http.StatusOK,
+			want:       summary{Route: "GET /users/{id}", Valid: true},
+		},
+		{
+			name:       "invalid",
+			body:       `{"method": "GET", "path": "/users/x?limit=many"}`,
+			wantStatus: http.StatusOK,
+			want:       summary{Route: "GET /users/{id}", Keys: []string{"id", "limit"}},
+		},
+		{
+			name:       "no route",
+			body:       `{"method": "GET", "path": "/missing"}`,
+			wantStatus: http.StatusOK,
+			want:       summary{Status: http.StatusNotFound},
+		},
+		{
+			name:       "no binding phase",
+			body:       `{"method": "POST", "path": "/plain"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid request",
+			body:       `{"path": "/users/1"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/_dev/validate", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status code: got %d, want %d, body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			var report ValidationReport
+			if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			got := summary{Route: report.Route, Valid: report.Valid}
+			for _, e := range report.Errors {
+				got.Keys = append(got.Keys, e.Key)
+			}
+			if report.Response != nil {
+				got.Status = report.Response.Status
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("report mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if calls != 0 {
+		t.Errorf("actions were called %d times, want 0", calls)
+	}
+
+	t.Run("served normally", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/users/x", nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("status code: got %d, want %d", rr.Code, http.StatusBadRequest)
+		}
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		if rr.Code != http.StatusOK || calls != 1 {
+			t.Errorf("got status %d and %d calls, want %d and 1 call", rr.Code, calls, http.StatusOK)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package rakuda
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ValueBag is a lightweight per-request store that lets handlers and middleware
+// exchange computed values (e.g., a parsed tenant or feature flags) without
+// allocating a new context for each value. It is safe for concurrent use.
+type ValueBag struct {
+	mu     sync.RWMutex
+	values map[any]any
+}
+
+// NewContextWithValues returns a new context with an empty ValueBag.
+// It is typically called once per request by the rakudamiddleware.Values middleware.
+func NewContextWithValues(ctx context.Context) context.Context {
+	return context.WithValue(ctx, valuesKey, &ValueBag{values: map[any]any{}})
+}
+
+// Values retrieves the ValueBag from the context.
+// If no bag is found, it returns nil. Get on a nil bag reports a missing value,
+// and Set on a nil bag panics, because it indicates a misconfiguration.
+func Values(ctx context.Context) *ValueBag {
+	bag, _ := ctx.Value(valuesKey).(*ValueBag)
+	return bag
+}
+
+// Set stores a value under the given key, overwriting any previous value.
+func (b *ValueBag) Set(key, value any) {
+	if b == nil {
+		panic(fmt.Sprintf("rakuda: no value bag in context while setting %v; install the rakudamiddleware.Values middleware", key))
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.values[key] = value
+}
+
+// Get retrieves the value stored under the given key.
+func (b *ValueBag) Get(key any) (any, bool) {
+	if b == nil {
+		return nil, false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	v, ok := b.values[key]
+	return v, ok
+}
+
+// Key is a typed key for values stored in a ValueBag.
+// Using a Key avoids type assertions at every call site.
+//
+//	var tenantKey = rakuda.NewKey[string]("tenant")
+//	tenantKey.Set(ctx, "acme")
+//	tenant, ok := tenantKey.Get(ctx)
+type Key[T any] struct {
+	name string
+}
+
+// NewKey creates a new typed key. The name is used only for diagnostics;
+// two keys created with the same name are still distinct.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// String returns the name of the key.
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+// Set stores the value in the ValueBag of the context.
+func (k *Key[T]) Set(ctx context.Context, value T) {
+	Values(ctx).Set(k, value)
+}
+
+// Get retrieves the value from the ValueBag of the context.
+func (k *Key[T]) Get(ctx context.Context) (T, bool) {
+	v, ok := Values(ctx).Get(k)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	typed, ok := v.(T)
+	return typed, ok
+}
@@ -0,0 +1,50 @@
+package rakuda
+
+import (
+	"context"
+	"testing"
+)
+
+func TestValueBag(t *testing.T) {
+	t.Run("set and get", func(t *testing.T) {
+		ctx := NewContextWithValues(context.Background())
+		Values(ctx).Set("k", 1)
+
+		got, ok := Values(ctx).Get("k")
+		if !ok {
+			t.Fatal("expected value to be found")
+		}
+		if got != 1 {
+			t.Errorf("got %v, want %v", got, 1)
+		}
+	})
+
+	t.Run("typed key", func(t *testing.T) {
+		ctx := NewContextWithValues(context.Background())
+		key := NewKey[string]("tenant")
+		other := NewKey[string]("tenant")
+
+		key.Set(ctx, "acme")
+
+		if got, ok := key.Get(ctx); !ok || got != "acme" {
+			t.Errorf("key.Get(): got (%q, %v), want (%q, true)", got, ok, "acme")
+		}
+		if _, ok := other.Get(ctx); ok {
+			t.Error("expected keys with the same name to be distinct")
+		}
+	})
+
+	t.Run("missing bag", func(t *testing.T) {
+		ctx := context.Background()
+		if _, ok := Values(ctx).Get("k"); ok {
+			t.Error("expected Get on a missing bag to report not found")
+		}
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected Set on a missing bag to panic")
+			}
+		}()
+		Values(ctx).Set("k", 1)
+	})
+}